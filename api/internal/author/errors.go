@@ -0,0 +1,13 @@
+package author
+
+import "book-api/pkg/apperror"
+
+var (
+	ErrAuthorNotFound        = apperror.NotFound("AUTHOR_NOT_FOUND", "author not found")
+	ErrAuthorAlreadyExists   = apperror.Conflict("AUTHOR_ALREADY_EXISTS", "an author with this name already exists")
+	ErrAuthorHasBooks        = apperror.Conflict("AUTHOR_HAS_BOOKS", "author is still credited on books")
+	ErrInvalidAuthorName     = apperror.Invalid("INVALID_AUTHOR_NAME", "author name must contain a letter or digit")
+	ErrUnknownAuthor         = apperror.Invalid("UNKNOWN_AUTHOR", "credited author does not exist")
+	ErrRepositoryUnavailable = apperror.Unavailable("AUTHOR_REPOSITORY_UNAVAILABLE", "author repository is unavailable")
+	ErrRepositoryFailure     = apperror.Internal("AUTHOR_REPOSITORY_FAILURE", "author repository failed")
+)
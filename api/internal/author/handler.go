@@ -0,0 +1,21 @@
+package author
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/internal/catalog"
+)
+
+// NewHandler serves /author and the list at /authors. GET /author/:id/books is left to the book handler,
+// which owns the credits.
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	repository Repository,
+	opts ...catalog.HandlerOption,
+) *catalog.Handler[AuthorDTO] {
+	return catalog.NewHandler(server, validator, tracer, kind, repository, opts...)
+}
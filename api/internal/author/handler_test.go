@@ -0,0 +1,93 @@
+package author
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+
+	"book-api/internal/catalog"
+	"book-api/pkg/apperror"
+)
+
+func TestNewHandler(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("create and list", func(t *testing.T) {
+		mockRepository := catalog.NewMockRepository[AuthorDTO](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRepository.EXPECT().List(gomock.Any(), catalog.PageRequest{Limit: 20}).
+			Return(&catalog.Page[AuthorDTO]{Records: []AuthorDTO{{Id: uuid.NewString(), Name: "Harper Lee"}}}, nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/author", `{"name":"J.R.R. Tolkien"}`)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get(fiber.HeaderLocation), "/author/"))
+
+		var created struct {
+			Author AuthorDTO `json:"author"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&created))
+		assert.Equal(t, "J.R.R. Tolkien", created.Author.Name)
+
+		res = send(t, server, http.MethodGet, "/authors", "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var page struct {
+			Authors []AuthorDTO `json:"authors"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+		assert.Len(t, page.Authors, 1)
+	})
+
+	t.Run("spelling variant of an existing author", func(t *testing.T) {
+		existingId := uuid.NewString()
+		mockRepository := catalog.NewMockRepository[AuthorDTO](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(ErrAuthorAlreadyExists.WithMeta("authorId", existingId))
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/author", `{"name":"JRR Tolkien"}`)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+		assert.Equal(t, "/author/"+existingId, res.Header.Get(fiber.HeaderLocation))
+	})
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupHandler(repository Repository) *fiber.App {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	NewHandler(server, validate, otel.Tracer("author"), repository).RegisterHandlers()
+
+	return server
+}
@@ -0,0 +1,33 @@
+package author
+
+import (
+	"time"
+
+	"book-api/internal/catalog"
+)
+
+// Role is how an author is credited on a book.
+type Role string
+
+const (
+	RoleAuthor      Role = "author"
+	RoleEditor      Role = "editor"
+	RoleTranslator  Role = "translator"
+	RoleIllustrator Role = "illustrator"
+)
+
+type AuthorDTO struct {
+	Id        string     `json:"id" db:"id" validate:"omitempty,uuid"`
+	Name      string     `json:"name" db:"name" validate:"required,max=255"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+func (a AuthorDTO) Position() catalog.Cursor {
+	return catalog.Cursor{Label: a.Name, Id: a.Id}
+}
+
+func (a AuthorDTO) WithId(id string) AuthorDTO {
+	a.Id = id
+	return a
+}
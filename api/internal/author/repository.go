@@ -0,0 +1,32 @@
+package author
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+)
+
+// kind folds author names with author_name_key, the same key book credits are matched by, so "JRR Tolkien"
+// can't be created next to "J.R.R. Tolkien". An author stays as long as a book credits them.
+var kind = catalog.Kind{
+	Name:         "author",
+	Plural:       "authors",
+	Table:        "authors",
+	Label:        "name",
+	FoldedLabels: true,
+	Errors: catalog.Errors{
+		NotFound:      ErrAuthorNotFound,
+		AlreadyExists: ErrAuthorAlreadyExists,
+		InUse:         ErrAuthorHasBooks,
+		InvalidLabel:  ErrInvalidAuthorName,
+		Unavailable:   ErrRepositoryUnavailable,
+		Failure:       ErrRepositoryFailure,
+	},
+}
+
+type Repository = catalog.Repository[AuthorDTO]
+
+func NewPgRepository(traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *catalog.PgRepository[AuthorDTO] {
+	return catalog.NewPgRepository[AuthorDTO](kind, traceProvider, connectionPool)
+}
@@ -0,0 +1,100 @@
+//go:build !pact
+// +build !pact
+
+package author
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+	"book-api/migrations"
+	"book-api/pkg/migrate"
+)
+
+func TestPgRepository_Authors(t *testing.T) {
+	t.Run("spelling variants are one author", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		tolkien := &AuthorDTO{Id: uuid.NewString(), Name: "J.R.R. Tolkien"}
+		require.NoError(t, pgRepository.Create(ctx, tolkien))
+
+		err := pgRepository.Create(ctx, &AuthorDTO{Id: uuid.NewString(), Name: "JRR  tolkien"})
+		assert.ErrorIs(t, err, ErrAuthorAlreadyExists)
+
+		page, err := pgRepository.List(ctx, catalog.PageRequest{Search: "r.r. tolk", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Records, 1)
+		assert.Equal(t, tolkien.Id, page.Records[0].Id)
+	})
+
+	t.Run("credited authors cannot be deleted", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		bookId := uuid.NewString()
+		_, err := pgRepository.ConnectionPool().Exec(
+			ctx,
+			"insert into books (id, cover_url, isbn, title, author, publication_year) values ($1, '', '', 'Good Omens', 'Terry Pratchett & Neil Gaiman', '1990')",
+			bookId,
+		)
+		require.NoError(t, err)
+		_, err = pgRepository.ConnectionPool().Exec(ctx, "select book_credit_authors($1)", bookId)
+		require.NoError(t, err)
+
+		page, err := pgRepository.List(ctx, catalog.PageRequest{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Records, 2)
+		assert.Equal(t, "Neil Gaiman", page.Records[0].Name)
+		assert.Equal(t, "Terry Pratchett", page.Records[1].Name)
+
+		assert.ErrorIs(t, pgRepository.DeleteById(ctx, page.Records[0].Id), ErrAuthorHasBooks)
+	})
+
+	t.Run("name without letters", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+
+		err := pgRepository.Create(context.TODO(), &AuthorDTO{Id: uuid.NewString(), Name: "..."})
+		assert.ErrorIs(t, err, ErrInvalidAuthorName)
+	})
+}
+
+func setupRepository(t *testing.T) *catalog.PgRepository[AuthorDTO] {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres:alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connectionString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
+
+	return NewPgRepository(trace.NewTracerProvider(), pool)
+}
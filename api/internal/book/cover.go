@@ -0,0 +1,362 @@
+package book
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/bodylimit"
+	"book-api/pkg/storage"
+)
+
+const (
+	DefaultMaxCoverBytes = 10 << 20
+	// maxCoverPixels bounds the decoded image, a 4000x4000 cover already takes 64 MiB as RGBA
+	maxCoverPixels = 16_000_000
+	// maxCoverFormBytes is what a multipart upload may add around the image for its boundaries and part headers
+	maxCoverFormBytes = 64 << 10
+	coverFileField    = "file"
+	originalCover     = "original"
+	jpegQuality       = 85
+)
+
+// coverSizes are the thumbnails made for an upload, widest first so each one is scaled from the one before.
+var coverSizes = []struct {
+	name  string
+	width int
+}{
+	{"large", 640},
+	{"medium", 320},
+	{"small", 160},
+}
+
+// coverTypes are the image types accepted as cover, as sniffed by http.DetectContentType.
+var coverTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// WithCoverStore enables cover uploads, the images go to store and uploads above maxBytes are refused.
+// Without it GET /book/:id/cover only redirects to the cover url of the book.
+func WithCoverStore(store storage.BlobStore, maxBytes int64) HandlerOption {
+	return func(h *Handler) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultMaxCoverBytes
+		}
+		h.covers = store
+		h.maxCoverBytes = maxBytes
+	}
+}
+
+func (h *Handler) PutBookCover(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "PutBookCover")
+	defer span.End()
+
+	bookId := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", bookId))
+
+	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	if h.covers == nil {
+		return ErrCoversDisabled
+	}
+
+	upload, err := uploadOf(ctx, coverFileField, h.maxCoverBytes+maxCoverFormBytes, ErrMissingCoverFile, ErrCoverTooLarge)
+	if err != nil {
+		return err
+	}
+
+	original, err := io.ReadAll(io.LimitReader(upload, h.maxCoverBytes+1))
+	if err != nil && !errors.Is(err, bodylimit.ErrTooLarge) {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+	if err != nil || int64(len(original)) > h.maxCoverBytes {
+		ctx.Context().SetConnectionClose()
+		return ErrCoverTooLarge.WithMeta("maxBytes", h.maxCoverBytes)
+	}
+
+	cover, images, err := processCover(bookId, original)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("digest", cover.Digest), attribute.String("contentType", cover.ContentType))
+
+	if err = saveCover(ctx.Context(), h.repository, h.covers, cover, images); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"cover": cover,
+	})
+}
+
+// GetBookCover serves the uploaded cover in the asked size, a book without an upload is redirected to its
+// cover url. Thumbnails are only made when the original is wider, so a missing size falls back to the original.
+func (h *Handler) GetBookCover(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetBookCover")
+	defer span.End()
+
+	bookId := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", bookId))
+
+	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	var queries GetBookCoverRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	var cover *BookCover
+	if h.covers != nil {
+		var err error
+		cover, err = h.repository.GetBookCover(ctx.Context(), bookId)
+		if err != nil && !errors.Is(err, ErrCoverNotFound) {
+			return err
+		}
+	}
+
+	if cover == nil {
+		book, err := h.repository.GetBookById(ctx.Context(), bookId)
+		if err != nil {
+			return err
+		}
+
+		if book.CoverUrl == "" {
+			return ErrCoverNotFound
+		}
+
+		return ctx.Redirect(book.CoverUrl, fiber.StatusFound)
+	}
+
+	size := queries.Size
+	if size == "" || !slices.Contains(cover.Sizes, size) {
+		size = originalCover
+	}
+
+	contentType := cover.ThumbnailType
+	if size == originalCover {
+		contentType = cover.ContentType
+	}
+
+	etag := strconv.Quote(cover.Digest + "-" + size)
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	if notModified(ctx, etag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	blob, err := h.covers.Get(ctx.Context(), coverKey(bookId, cover.Digest, size))
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	return ctx.SendStream(blob.Body, int(blob.Size))
+}
+
+func (h *Handler) DeleteBookCover(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "DeleteBookCover")
+	defer span.End()
+
+	bookId := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", bookId))
+
+	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	if h.covers == nil {
+		return ErrCoversDisabled
+	}
+
+	cover, err := h.repository.DeleteBookCover(ctx.Context(), bookId)
+	if err != nil {
+		return err
+	}
+	deleteCoverBlobs(h.covers, cover)
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// saveCover writes the images made by processCover to the store before recording the cover, so a recorded
+// cover is always complete. The blobs of the cover it replaced are dropped. Blobs of a cover that could not be
+// recorded are only dropped when no recorded cover can share their keys, which the same image would.
+func saveCover(ctx context.Context, repository Repository, store storage.BlobStore, cover *BookCover, images map[string][]byte) error {
+	for size, content := range images {
+		contentType := cover.ThumbnailType
+		if size == originalCover {
+			contentType = cover.ContentType
+		}
+
+		if err := store.Put(ctx, coverKey(cover.BookId, cover.Digest, size), bytes.NewReader(content), int64(len(content)), contentType); err != nil {
+			return err
+		}
+	}
+
+	previous, err := repository.SaveBookCover(ctx, cover)
+	if err != nil {
+		if errors.Is(err, ErrBookNotFound) || errors.Is(err, ErrCoverUploaded) && previous != nil && previous.Digest != cover.Digest {
+			deleteCoverBlobs(store, cover)
+		}
+		return err
+	}
+
+	// the same image uploaded again keeps its keys, its blobs were just overwritten
+	if previous != nil && previous.Digest != cover.Digest {
+		deleteCoverBlobs(store, previous)
+	}
+
+	return nil
+}
+
+// deleteCoverBlobs is best effort, the cover row is already gone or was never written, so a blob
+// left behind is only wasted space.
+func deleteCoverBlobs(store storage.BlobStore, cover *BookCover) {
+	for _, size := range append([]string{originalCover}, cover.Sizes...) {
+		key := coverKey(cover.BookId, cover.Digest, size)
+		if err := store.Delete(context.Background(), key); err != nil {
+			zap.L().Warn("cover blob could not be deleted", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// deletePurgedCoverBlobs drops the blobs of the covers of purged books, there are none to drop without a store.
+func deletePurgedCoverBlobs(store storage.BlobStore, covers []BookCover) {
+	if store == nil {
+		return
+	}
+
+	for i := range covers {
+		deleteCoverBlobs(store, &covers[i])
+	}
+}
+
+func coverKey(bookId, digest, size string) string {
+	return "covers/" + bookId + "/" + digest + "/" + size
+}
+
+// processCover checks the upload by its content rather than the type the client claims and makes the
+// thumbnails. It returns the encoded images by size name, "original" being the upload itself.
+func processCover(bookId string, original []byte) (*BookCover, map[string][]byte, error) {
+	contentType := http.DetectContentType(original)
+	if !slices.Contains(coverTypes, contentType) {
+		return nil, nil, ErrUnsupportedCoverType.WithMeta("contentType", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return nil, nil, ErrInvalidCoverImage.WithCause(err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxCoverPixels {
+		return nil, nil, ErrInvalidCoverImage.WithMeta("maxPixels", maxCoverPixels)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, nil, ErrInvalidCoverImage.WithCause(err)
+	}
+
+	digest := sha256.Sum256(original)
+	cover := &BookCover{
+		BookId:        bookId,
+		Digest:        hex.EncodeToString(digest[:]),
+		ContentType:   contentType,
+		ThumbnailType: "image/jpeg",
+		Width:         config.Width,
+		Height:        config.Height,
+		ByteSize:      int64(len(original)),
+		Sizes:         []string{},
+	}
+	// png and gif covers may be transparent, their thumbnails stay lossless
+	if contentType != "image/jpeg" {
+		cover.ThumbnailType = "image/png"
+	}
+
+	images := map[string][]byte{originalCover: original}
+	source := toRGBA(decoded)
+	for _, size := range coverSizes {
+		if source.Bounds().Dx() <= size.width {
+			continue
+		}
+
+		source = resize(source, size.width)
+		var encoded bytes.Buffer
+		if cover.ThumbnailType == "image/png" {
+			err = png.Encode(&encoded, source)
+		} else {
+			err = jpeg.Encode(&encoded, source, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, nil, ErrInvalidCoverImage.WithCause(err)
+		}
+
+		images[size.name] = encoded.Bytes()
+		cover.Sizes = append(cover.Sizes, size.name)
+	}
+
+	return cover, images, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resize scales src down to width keeping the aspect ratio, every target pixel is the average of the
+// source pixels it covers.
+func resize(src *image.RGBA, width int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	height := max(1, (srcHeight*width+srcWidth/2)/srcWidth)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r, g, b, a = r+int(row[i]), g+int(row[i+1]), b+int(row[i+2]), a+int(row[i+3])
+					n++
+				}
+			}
+
+			offset := y*dst.Stride + x*4
+			dst.Pix[offset] = uint8((r + n/2) / n)
+			dst.Pix[offset+1] = uint8((g + n/2) / n)
+			dst.Pix[offset+2] = uint8((b + n/2) / n)
+			dst.Pix[offset+3] = uint8((a + n/2) / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,368 @@
+package book
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/cursor"
+	"book-api/pkg/safehttp"
+	"book-api/pkg/storage"
+)
+
+const (
+	defaultCoverCheckInterval    = 10 * time.Minute
+	defaultCoverRecheckAfter     = 24 * time.Hour
+	defaultCoverCheckConcurrency = 4
+	defaultCoverChecksPerSecond  = 5
+	defaultCoverCheckTimeout     = 10 * time.Second
+	coverCheckBatchSize          = 200
+	coverCheckUserAgent          = "book-api cover checker"
+	maxCoverCheckRedirects       = 5
+	maxCoverCheckErrorLength     = 500
+)
+
+// CoverCheckOptions tunes the CoverChecker, zero values fall back to the defaults above.
+// Mirror enables copying healthy cover urls into the blob store as the book's cover,
+// Failing is set to the number of failing cover urls by outcome after every round.
+type CoverCheckOptions struct {
+	Interval          time.Duration
+	RecheckAfter      time.Duration
+	Concurrency       int
+	RequestsPerSecond int
+	Timeout           time.Duration
+	Mirror            storage.BlobStore
+	MirrorMaxBytes    int64
+	Failing           *prometheus.GaugeVec
+}
+
+// CoverChecker periodically HEAD checks the cover url of every live book, so broken covers are found before
+// a reader sees them. Checks run on a few workers and share one request rate, most urls point at the same host.
+// Cover urls come from users, so only public addresses are requested, see safehttp.NewClient.
+type CoverChecker struct {
+	repository Repository
+	client     *http.Client
+	options    CoverCheckOptions
+	now        func() time.Time
+}
+
+func NewCoverChecker(repository Repository, options CoverCheckOptions) *CoverChecker {
+	if options.Interval <= 0 {
+		options.Interval = defaultCoverCheckInterval
+	}
+	if options.RecheckAfter <= 0 {
+		options.RecheckAfter = defaultCoverRecheckAfter
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultCoverCheckConcurrency
+	}
+	if options.RequestsPerSecond <= 0 {
+		options.RequestsPerSecond = defaultCoverChecksPerSecond
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultCoverCheckTimeout
+	}
+	if options.MirrorMaxBytes <= 0 {
+		options.MirrorMaxBytes = DefaultMaxCoverBytes
+	}
+
+	return &CoverChecker{
+		repository: repository,
+		client:     safehttp.NewClient(options.Timeout, maxCoverCheckRedirects),
+		options:    options,
+		now:        time.Now,
+	}
+}
+
+// Run checks what is due right away and then on every interval until ctx is cancelled.
+func (c *CoverChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		checked, err := c.CheckDue(ctx)
+		if err != nil {
+			zap.L().Error("failed to check cover urls", zap.Error(err))
+		} else if checked > 0 {
+			zap.L().Info("checked cover urls", zap.Int("count", checked))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDue checks every cover url that is due batch by batch and then updates the Failing gauge.
+func (c *CoverChecker) CheckDue(ctx context.Context) (int, error) {
+	limiter := time.NewTicker(time.Second / time.Duration(c.options.RequestsPerSecond))
+	defer limiter.Stop()
+
+	total := 0
+	for {
+		targets, err := c.repository.GetDueCoverChecks(ctx, c.now().Add(-c.options.RecheckAfter), coverCheckBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		checked, err := c.checkBatch(ctx, targets, limiter.C)
+		total += checked
+		if err != nil {
+			return total, err
+		}
+
+		if len(targets) < coverCheckBatchSize {
+			break
+		}
+	}
+
+	return total, c.updateGauge(ctx)
+}
+
+func (c *CoverChecker) checkBatch(ctx context.Context, targets []CoverCheckTarget, limiter <-chan time.Time) (int, error) {
+	queue := make(chan CoverCheckTarget)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		checked  int
+		firstErr error
+	)
+
+	for range min(c.options.Concurrency, len(targets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range queue {
+				err := c.checkOne(ctx, target, limiter)
+
+				mu.Lock()
+				if err == nil {
+					checked++
+				} else if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, target := range targets {
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- target:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+
+	return checked, firstErr
+}
+
+// checkOne records the check of target and mirrors it when it is healthy and there is nothing better to serve,
+// a failed mirror is only logged since the check itself went through.
+func (c *CoverChecker) checkOne(ctx context.Context, target CoverCheckTarget, limiter <-chan time.Time) error {
+	if err := wait(ctx, limiter); err != nil {
+		return err
+	}
+
+	check := c.check(ctx, target)
+	if err := c.repository.SaveCoverCheck(ctx, check); err != nil {
+		return err
+	}
+
+	if check.Outcome != CoverOK || !c.shouldMirror(target) {
+		return nil
+	}
+
+	if err := wait(ctx, limiter); err != nil {
+		return err
+	}
+
+	err := c.mirror(ctx, target)
+	if err != nil && !errors.Is(err, ErrCoverUploaded) {
+		zap.L().Warn("failed to mirror cover", zap.String("bookId", target.BookId), zap.String("coverUrl", target.CoverUrl), zap.Error(err))
+	}
+
+	return nil
+}
+
+// check asks for the headers only, servers refusing HEAD get a GET whose body is left unread.
+func (c *CoverChecker) check(ctx context.Context, target CoverCheckTarget) *CoverCheck {
+	checkedAt := c.now().UTC()
+	check := &CoverCheck{BookId: target.BookId, CoverUrl: target.CoverUrl, CheckedAt: &checkedAt}
+
+	res, err := c.request(ctx, http.MethodHead, target.CoverUrl)
+	if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+		res.Body.Close()
+		res, err = c.request(ctx, http.MethodGet, target.CoverUrl)
+	}
+	if err != nil {
+		message := err.Error()
+		if len(message) > maxCoverCheckErrorLength {
+			message = message[:maxCoverCheckErrorLength]
+		}
+		check.Outcome, check.Error = CoverUnreachable, &message
+		return check
+	}
+	res.Body.Close()
+
+	statusCode := res.StatusCode
+	check.StatusCode = &statusCode
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		check.ContentType = &contentType
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	switch {
+	case statusCode < 200 || statusCode > 299:
+		check.Outcome = CoverHTTPError
+	case !strings.HasPrefix(mediaType, "image/"):
+		check.Outcome = CoverNotImage
+	default:
+		check.Outcome = CoverOK
+	}
+
+	return check
+}
+
+// shouldMirror never touches an upload, a mirror is refreshed when the cover url changed.
+func (c *CoverChecker) shouldMirror(target CoverCheckTarget) bool {
+	if c.options.Mirror == nil {
+		return false
+	}
+
+	return !target.HasCover || target.MirroredUrl != nil && *target.MirroredUrl != target.CoverUrl
+}
+
+func (c *CoverChecker) mirror(ctx context.Context, target CoverCheckTarget) error {
+	res, err := c.request(ctx, http.MethodGet, target.CoverUrl)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("cover url answered %s", res.Status)
+	}
+
+	original, err := io.ReadAll(io.LimitReader(res.Body, c.options.MirrorMaxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(original)) > c.options.MirrorMaxBytes {
+		return ErrCoverTooLarge.WithMeta("maxBytes", c.options.MirrorMaxBytes)
+	}
+
+	cover, images, err := processCover(target.BookId, original)
+	if err != nil {
+		return err
+	}
+	cover.SourceUrl = &target.CoverUrl
+
+	return saveCover(ctx, c.repository, c.options.Mirror, cover, images)
+}
+
+func (c *CoverChecker) request(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", coverCheckUserAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Accept", "image/*")
+	}
+
+	return c.client.Do(req)
+}
+
+func (c *CoverChecker) updateGauge(ctx context.Context) error {
+	if c.options.Failing == nil {
+		return nil
+	}
+
+	counts, err := c.repository.CountFailingCovers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, outcome := range []CoverCheckOutcome{CoverHTTPError, CoverNotImage, CoverUnreachable} {
+		c.options.Failing.WithLabelValues(string(outcome)).Set(float64(counts[outcome]))
+	}
+
+	return nil
+}
+
+func wait(ctx context.Context, limiter <-chan time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-limiter:
+		return nil
+	}
+}
+
+// GetFailingCovers lists the books whose cover url failed its last check, longest failing first.
+func (h *Handler) GetFailingCovers(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetFailingCovers")
+	defer span.End()
+
+	var queries GetFailingCoversRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	request := FailingCoversPageRequest{Limit: queries.Limit}
+	if request.Limit == 0 {
+		request.Limit = defaultCursorLimit
+	}
+
+	scope := cursor.Scope{Kind: "covers.failing"}
+	if queries.Cursor != "" {
+		var position FailingCoverCursor
+		if err := h.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.GetFailingCovers(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	response := GetFailingCoversResponse{Covers: page.Covers}
+	if response.Covers == nil {
+		response.Covers = []CoverCheck{}
+	}
+
+	if page.Next != nil {
+		if response.NextCursor, err = h.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
@@ -0,0 +1,145 @@
+package book
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"book-api/pkg/storage"
+)
+
+func TestCoverChecker_CheckDue(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	cover := testCoverImage(t, 400, 600)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cover.png":
+			w.Header().Set("Content-Type", "image/png")
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(cover)
+			}
+		case "/head-refused.jpg":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "image/jpeg")
+		case "/placeholder.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer remote.Close()
+
+	t.Run("records every outcome", func(t *testing.T) {
+		targets := []CoverCheckTarget{
+			{BookId: uuid.NewString(), CoverUrl: remote.URL + "/cover.png"},
+			{BookId: uuid.NewString(), CoverUrl: remote.URL + "/head-refused.jpg"},
+			{BookId: uuid.NewString(), CoverUrl: remote.URL + "/placeholder.html"},
+			{BookId: uuid.NewString(), CoverUrl: remote.URL + "/gone.jpg"},
+			{BookId: uuid.NewString(), CoverUrl: "http://127.0.0.1:1/cover.jpg"},
+		}
+
+		var mu sync.Mutex
+		checks := map[string]*CoverCheck{}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueCoverChecks(gomock.Any(), now.Add(-time.Hour), coverCheckBatchSize).Return(targets, nil)
+		mockRepository.EXPECT().SaveCoverCheck(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, check *CoverCheck) error {
+			mu.Lock()
+			defer mu.Unlock()
+			checks[check.CoverUrl] = check
+			return nil
+		}).Times(len(targets))
+		mockRepository.EXPECT().CountFailingCovers(gomock.Any()).Return(map[CoverCheckOutcome]int{CoverHTTPError: 1, CoverNotImage: 1, CoverUnreachable: 1}, nil)
+
+		failing := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "failing"}, []string{"outcome"})
+		checker := NewCoverChecker(mockRepository, CoverCheckOptions{RecheckAfter: time.Hour, RequestsPerSecond: 1000, Failing: failing})
+		checker.client = remote.Client()
+		checker.now = func() time.Time { return now }
+
+		checked, err := checker.CheckDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, len(targets), checked)
+
+		assert.Equal(t, CoverOK, checks[targets[0].CoverUrl].Outcome)
+		assert.Equal(t, "image/png", *checks[targets[0].CoverUrl].ContentType)
+		assert.Equal(t, now, *checks[targets[0].CoverUrl].CheckedAt)
+		assert.Equal(t, CoverOK, checks[targets[1].CoverUrl].Outcome)
+		assert.Equal(t, CoverNotImage, checks[targets[2].CoverUrl].Outcome)
+		assert.Equal(t, CoverHTTPError, checks[targets[3].CoverUrl].Outcome)
+		assert.Equal(t, http.StatusNotFound, *checks[targets[3].CoverUrl].StatusCode)
+		assert.Equal(t, CoverUnreachable, checks[targets[4].CoverUrl].Outcome)
+		assert.NotEmpty(t, *checks[targets[4].CoverUrl].Error)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(failing.WithLabelValues(string(CoverNotImage))))
+	})
+
+	t.Run("mirrors healthy covers without an upload", func(t *testing.T) {
+		bookId := uuid.NewString()
+		uploadedId := uuid.NewString()
+		targets := []CoverCheckTarget{
+			{BookId: bookId, CoverUrl: remote.URL + "/cover.png"},
+			{BookId: uploadedId, CoverUrl: remote.URL + "/cover.png", HasCover: true},
+		}
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueCoverChecks(gomock.Any(), gomock.Any(), gomock.Any()).Return(targets, nil)
+		mockRepository.EXPECT().SaveCoverCheck(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockRepository.EXPECT().SaveBookCover(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, cover *BookCover) (*BookCover, error) {
+			assert.Equal(t, bookId, cover.BookId)
+			assert.Equal(t, remote.URL+"/cover.png", *cover.SourceUrl)
+			assert.Equal(t, []string{"medium", "small"}, cover.Sizes)
+			return nil, nil
+		})
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		checker := NewCoverChecker(mockRepository, CoverCheckOptions{RequestsPerSecond: 1000, Mirror: store})
+		checker.client = remote.Client()
+		_, err = checker.CheckDue(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("refuses private addresses", func(t *testing.T) {
+		targets := []CoverCheckTarget{
+			{BookId: uuid.NewString(), CoverUrl: remote.URL + "/cover.png"},
+			{BookId: uuid.NewString(), CoverUrl: "file:///etc/passwd"},
+		}
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueCoverChecks(gomock.Any(), gomock.Any(), gomock.Any()).Return(targets, nil)
+		mockRepository.EXPECT().SaveCoverCheck(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, check *CoverCheck) error {
+			assert.Equal(t, CoverUnreachable, check.Outcome)
+			return nil
+		}).Times(len(targets))
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		_, err = NewCoverChecker(mockRepository, CoverCheckOptions{RequestsPerSecond: 1000, Mirror: store}).CheckDue(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetDueCoverChecks(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, ErrRepositoryUnavailable)
+
+		_, err := NewCoverChecker(mockRepository, CoverCheckOptions{}).CheckDue(context.Background())
+		assert.ErrorIs(t, err, ErrRepositoryUnavailable)
+	})
+}
@@ -0,0 +1,33 @@
+package book
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize(t *testing.T) {
+	t.Run("keeps the aspect ratio", func(t *testing.T) {
+		resized := resize(image.NewRGBA(image.Rect(0, 0, 1000, 1500)), 160)
+		assert.Equal(t, image.Rect(0, 0, 160, 240), resized.Bounds())
+	})
+
+	t.Run("averages the pixels it covers", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+		for x := 0; x < 4; x++ {
+			src.Set(x, 0, color.RGBA{R: 200, A: 255})
+			src.Set(x, 1, color.RGBA{B: 100, A: 255})
+		}
+
+		resized := resize(src, 2)
+		assert.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+		assert.Equal(t, color.RGBA{R: 100, B: 50, A: 255}, resized.RGBAAt(1, 0))
+	})
+
+	t.Run("very wide images keep a row", func(t *testing.T) {
+		resized := resize(image.NewRGBA(image.Rect(0, 0, 2000, 1)), 160)
+		assert.Equal(t, image.Rect(0, 0, 160, 1), resized.Bounds())
+	})
+}
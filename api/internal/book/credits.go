@@ -0,0 +1,255 @@
+package book
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"book-api/internal/author"
+)
+
+// querier is what reading credits needs, a pooled connection or a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// prepareCredits resolves the explicit credits of a write before the book row is written, and derives the
+// author byline from them when the client sent none. Without explicit credits it returns nil, the author
+// text is then split by book_credit_authors once the row exists.
+func prepareCredits(ctx context.Context, tx pgx.Tx, book *BookDTO) ([]BookAuthor, error) {
+	if len(book.Authors) == 0 {
+		return nil, nil
+	}
+
+	credits := uniqueCredits(book.Authors)
+	if err := resolveAuthors(ctx, tx, credits); err != nil {
+		return nil, err
+	}
+	// spelling variants only turn out to be the same author once resolved
+	credits = uniqueCredits(credits)
+
+	if strings.TrimSpace(book.Author) == "" {
+		book.Author = byline(credits)
+	}
+
+	return credits, nil
+}
+
+// creditAuthors replaces the credits of a book and returns them as stored.
+func creditAuthors(ctx context.Context, tx pgx.Tx, bookId string, credits []BookAuthor) ([]BookAuthor, error) {
+	if credits == nil {
+		if _, err := tx.Exec(ctx, "select book_credit_authors($1)", bookId); err != nil {
+			return nil, err
+		}
+
+		credited, err := loadAuthors(ctx, tx, bookId)
+		return credited[bookId], err
+	}
+
+	if _, err := tx.Exec(ctx, "delete from book_authors where book_id = $1", bookId); err != nil {
+		return nil, err
+	}
+
+	authorIds := make([]string, len(credits))
+	roles := make([]string, len(credits))
+	positions := make([]int32, len(credits))
+	for i, credit := range credits {
+		authorIds[i], roles[i], positions[i] = credit.Id, string(credit.Role), int32(i+1)
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		"insert into book_authors (book_id, author_id, role, position) select $1::uuid, * from unnest($2::uuid[], $3::text[], $4::int[])",
+		bookId,
+		authorIds,
+		roles,
+		positions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return credits, nil
+}
+
+// resolveAuthors fills in the id and stored name of every credit. Credits by name match on author_name_key,
+// so spelling variants share one author, and names nobody used yet become new authors.
+func resolveAuthors(ctx context.Context, tx pgx.Tx, credits []BookAuthor) error {
+	var ids, names []string
+	for i := range credits {
+		credits[i].Name = strings.TrimSpace(credits[i].Name)
+		if credits[i].Id != "" {
+			ids = append(ids, credits[i].Id)
+		} else {
+			names = append(names, credits[i].Name)
+		}
+	}
+
+	byId := make(map[string]string, len(ids))
+	if len(ids) > 0 {
+		rows, err := tx.Query(ctx, "select id, name from authors where id = any($1::uuid[])", ids)
+		if err != nil {
+			return err
+		}
+		if err := collectNames(rows, byId); err != nil {
+			return err
+		}
+	}
+
+	byName := make(map[string]BookAuthor, len(names))
+	if len(names) > 0 {
+		if _, err := tx.Exec(
+			ctx,
+			"insert into authors (name) select name from unnest($1::text[]) as input(name) where author_name_key(name) <> '' on conflict (name_key) do nothing",
+			names,
+		); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(
+			ctx,
+			"select input.name, authors.id, authors.name from unnest($1::text[]) as input(name) join authors on authors.name_key = author_name_key(input.name)",
+			names,
+		)
+		if err != nil {
+			return err
+		}
+
+		var input string
+		var stored BookAuthor
+		if _, err := pgx.ForEachRow(rows, []any{&input, &stored.Id, &stored.Name}, func() error {
+			byName[input] = stored
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i := range credits {
+		credit := &credits[i]
+		if credit.Role == "" {
+			credit.Role = author.RoleAuthor
+		}
+
+		if credit.Id != "" {
+			name, ok := byId[credit.Id]
+			if !ok {
+				return author.ErrUnknownAuthor.WithMeta("authorId", credit.Id)
+			}
+			credit.Name = name
+			continue
+		}
+
+		stored, ok := byName[credit.Name]
+		if !ok {
+			return author.ErrInvalidAuthorName.WithMeta("name", credit.Name)
+		}
+		credit.Id, credit.Name = stored.Id, stored.Name
+	}
+
+	return nil
+}
+
+func collectNames(rows pgx.Rows, names map[string]string) error {
+	var id, name string
+	_, err := pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+		names[id] = name
+		return nil
+	})
+
+	return err
+}
+
+// loadAuthors reads the credits of the given books in credit order, keyed by book id.
+func loadAuthors(ctx context.Context, q querier, bookIds ...string) (map[string][]BookAuthor, error) {
+	rows, err := q.Query(
+		ctx,
+		"select book_authors.book_id, authors.id, authors.name, book_authors.role from book_authors join authors on authors.id = book_authors.author_id where book_authors.book_id = any($1::uuid[]) order by book_authors.position, book_authors.role",
+		bookIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credited := make(map[string][]BookAuthor, len(bookIds))
+	var bookId string
+	var credit BookAuthor
+	_, err = pgx.ForEachRow(rows, []any{&bookId, &credit.Id, &credit.Name, &credit.Role}, func() error {
+		credited[bookId] = append(credited[bookId], credit)
+		return nil
+	})
+
+	return credited, err
+}
+
+// withAuthors attaches the credits to a page of books with a single query.
+func withAuthors(ctx context.Context, q querier, books []BookDTO) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	bookIds := make([]string, len(books))
+	for i := range books {
+		bookIds[i] = books[i].Id
+	}
+
+	credited, err := loadAuthors(ctx, q, bookIds...)
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		books[i].Authors = credited[books[i].Id]
+	}
+
+	return nil
+}
+
+// uniqueCredits drops repeated credits of the same author in the same role, the first one keeps its position.
+func uniqueCredits(credits []BookAuthor) []BookAuthor {
+	type creditKey struct {
+		id, name string
+		role     author.Role
+	}
+
+	unique := make([]BookAuthor, 0, len(credits))
+	seen := make(map[creditKey]bool, len(credits))
+	for _, credit := range credits {
+		if credit.Role == "" {
+			credit.Role = author.RoleAuthor
+		}
+
+		key := creditKey{id: credit.Id, role: credit.Role}
+		if credit.Id == "" {
+			key.name = strings.ToLower(strings.TrimSpace(credit.Name))
+		}
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		unique = append(unique, credit)
+	}
+
+	return unique
+}
+
+// byline is the author text of a book credited explicitly, the authors proper or everyone credited when
+// there are none, such as an anthology with only editors.
+func byline(credits []BookAuthor) string {
+	names := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		if credit.Role == author.RoleAuthor {
+			names = append(names, credit.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		for _, credit := range credits {
+			names = append(names, credit.Name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
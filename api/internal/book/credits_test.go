@@ -0,0 +1,42 @@
+package book
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"book-api/internal/author"
+)
+
+func TestUniqueCredits(t *testing.T) {
+	credits := uniqueCredits([]BookAuthor{
+		{Name: "Neil Gaiman"},
+		{Name: " neil gaiman ", Role: author.RoleAuthor},
+		{Name: "Neil Gaiman", Role: author.RoleEditor},
+		{Id: "a", Name: "Terry Pratchett"},
+		{Id: "a", Role: author.RoleAuthor},
+	})
+
+	assert.Equal(t, []BookAuthor{
+		{Name: "Neil Gaiman", Role: author.RoleAuthor},
+		{Name: "Neil Gaiman", Role: author.RoleEditor},
+		{Id: "a", Name: "Terry Pratchett", Role: author.RoleAuthor},
+	}, credits)
+}
+
+func TestByline(t *testing.T) {
+	t.Run("authors proper", func(t *testing.T) {
+		assert.Equal(t, "Neil Gaiman, Terry Pratchett", byline([]BookAuthor{
+			{Name: "Neil Gaiman", Role: author.RoleAuthor},
+			{Name: "Paul Kidby", Role: author.RoleIllustrator},
+			{Name: "Terry Pratchett", Role: author.RoleAuthor},
+		}))
+	})
+
+	t.Run("only editors", func(t *testing.T) {
+		assert.Equal(t, "Ellen Datlow, Terri Windling", byline([]BookAuthor{
+			{Name: "Ellen Datlow", Role: author.RoleEditor},
+			{Name: "Terri Windling", Role: author.RoleEditor},
+		}))
+	})
+}
@@ -0,0 +1,91 @@
+package book
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/enrichment"
+	"book-api/pkg/isbn"
+)
+
+// WithMetadataProvider enables POST /book/enrich and ?enrich=true on create. The provider should already
+// cache and time out on its own, the handler waits for it inside the request.
+func WithMetadataProvider(provider enrichment.MetadataProvider) HandlerOption {
+	return func(h *Handler) {
+		h.metadata = provider
+	}
+}
+
+func (h *Handler) EnrichBook(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "EnrichBook")
+	defer span.End()
+
+	var reqBody EnrichBookRequest
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &reqBody); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	isbn13, err := isbn.Normalize(reqBody.ISBN)
+	if err != nil {
+		return ErrInvalidISBN.WithCause(err).WithMeta("isbn", reqBody.ISBN)
+	}
+	span.SetAttributes(attribute.String("isbn13", isbn13))
+
+	metadata, err := h.lookupMetadata(ctx.Context(), isbn13)
+	if err != nil {
+		return err
+	}
+
+	book := CreateBookRequest{ISBN: isbn13}
+	book.fillFrom(metadata)
+	return ctx.JSON(fiber.Map{"book": book, "metadata": metadata})
+}
+
+// enrichCreate fills what the client left empty, a failed lookup is returned for the caller to report
+// but does not stop a create whose body is complete anyway.
+func (h *Handler) enrichCreate(ctx context.Context, reqBody *CreateBookRequest) error {
+	isbn13, err := isbn.Normalize(reqBody.ISBN)
+	if err != nil {
+		return ErrInvalidISBN.WithCause(err)
+	}
+
+	metadata, err := h.lookupMetadata(ctx, isbn13)
+	if err != nil {
+		return err
+	}
+
+	reqBody.fillFrom(metadata)
+	return nil
+}
+
+func (h *Handler) lookupMetadata(ctx context.Context, isbn13 string) (*enrichment.Metadata, error) {
+	if h.metadata == nil {
+		return nil, ErrEnrichmentDisabled
+	}
+
+	return h.metadata.Lookup(ctx, isbn13)
+}
+
+// fillFrom only sets empty fields, whatever the client sent wins over the catalogue.
+func (r *CreateBookRequest) fillFrom(metadata *enrichment.Metadata) {
+	if r.Title == "" {
+		r.Title = metadata.Title
+	}
+	if r.Author == "" {
+		r.Author = strings.Join(metadata.Authors, ", ")
+	}
+	if r.PublicationYear == "" {
+		r.PublicationYear = metadata.PublicationYear
+	}
+	if r.CoverUrl == "" {
+		r.CoverUrl = metadata.CoverUrl
+	}
+}
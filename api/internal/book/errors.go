@@ -0,0 +1,108 @@
+package book
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"book-api/internal/genre"
+	"book-api/internal/publisher"
+	"book-api/internal/series"
+	"book-api/internal/work"
+	"book-api/pkg/apperror"
+)
+
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// liveISBNIndex is the unique index that keeps one live book per canonical ISBN.
+const liveISBNIndex = "books_isbn13_live_idx"
+
+// referenceErrors tells which reference of a book a foreign key violation is about.
+var referenceErrors = map[string]*apperror.Error{
+	"books_work_id_fkey":        work.ErrUnknownWork,
+	"books_publisher_id_fkey":   publisher.ErrUnknownPublisher,
+	"books_series_id_fkey":      series.ErrUnknownSeries,
+	"book_genres_genre_id_fkey": genre.ErrUnknownGenre,
+}
+
+var (
+	ErrBookNotFound          = apperror.NotFound("BOOK_NOT_FOUND", "book not found")
+	ErrUnsortableField       = apperror.Invalid("UNSORTABLE_FIELD", "books cannot be sorted by this field")
+	ErrDuplicateSortField    = apperror.Invalid("DUPLICATE_SORT_FIELD", "sort field is listed more than once")
+	ErrInvalidTimestamp      = apperror.Invalid("INVALID_TIMESTAMP", "timestamp must be RFC 3339 or YYYY-MM-DD")
+	ErrBookAlreadyExists     = apperror.Conflict("BOOK_ALREADY_EXISTS", "book already exists")
+	ErrDuplicateISBN         = apperror.Conflict("DUPLICATE_ISBN", "a book with this ISBN already exists")
+	ErrInvalidISBN           = apperror.Invalid("INVALID_ISBN", "isbn is not a valid ISBN-10 or ISBN-13")
+	ErrUnknownEmbed          = apperror.Invalid("UNKNOWN_EMBED", "embed must list publisher, editions or series")
+	ErrVersionMismatch       = apperror.PreconditionFailed("BOOK_VERSION_MISMATCH", "book was modified since it was read")
+	ErrPreconditionRequired  = apperror.PreconditionRequired("IF_MATCH_REQUIRED", "If-Match header with the book ETag is required")
+	ErrInvalidETag           = apperror.Invalid("INVALID_ETAG", "If-Match must be a single ETag returned by this API")
+	ErrEmptyBulk             = apperror.Invalid("EMPTY_BULK", "bulk request must contain at least one operation")
+	ErrTooManyBulkOperations = apperror.Invalid("TOO_MANY_BULK_OPERATIONS", "bulk request has too many operations")
+	ErrRepositoryUnavailable = apperror.Unavailable("BOOK_REPOSITORY_UNAVAILABLE", "book repository is unavailable")
+	ErrRepositoryFailure     = apperror.Internal("BOOK_REPOSITORY_FAILURE", "book repository failed")
+)
+
+var (
+	ErrUnsupportedImportFormat = apperror.Invalid("UNSUPPORTED_IMPORT_FORMAT", "import must be csv or ndjson, pass ?format= when the upload does not say")
+	ErrMissingImportFile       = apperror.Invalid("MISSING_IMPORT_FILE", "multipart import needs a part named file")
+	ErrInvalidImportHeader     = apperror.Invalid("INVALID_IMPORT_HEADER", "csv header names none of the book fields")
+	ErrMalformedImportRow      = apperror.Invalid("MALFORMED_IMPORT_ROW", "row could not be parsed")
+	ErrImportLineTooLong       = apperror.Invalid("IMPORT_LINE_TOO_LONG", "import line is longer than allowed")
+	ErrImportTooLarge          = apperror.TooLarge("IMPORT_TOO_LARGE", "import upload is larger than allowed")
+	ErrTooManyImports          = apperror.Unavailable("TOO_MANY_IMPORTS", "too many imports are running, try again later")
+	ErrImportJobNotFound       = apperror.NotFound("IMPORT_JOB_NOT_FOUND", "import job not found")
+	ErrImportSpoolFailure      = apperror.Internal("IMPORT_SPOOL_FAILURE", "import upload could not be stored for processing")
+)
+
+var ErrEnrichmentDisabled = apperror.Unavailable("ENRICHMENT_DISABLED", "no metadata provider is configured")
+
+var (
+	ErrCoverNotFound        = apperror.NotFound("COVER_NOT_FOUND", "book has neither an uploaded cover nor a cover url")
+	ErrCoverTooLarge        = apperror.TooLarge("COVER_TOO_LARGE", "cover image is larger than allowed")
+	ErrUnsupportedCoverType = apperror.UnsupportedMediaType("UNSUPPORTED_COVER_TYPE", "cover must be a jpeg, png or gif image")
+	ErrInvalidCoverImage    = apperror.Invalid("INVALID_COVER_IMAGE", "cover image could not be decoded")
+	ErrMissingCoverFile     = apperror.Invalid("MISSING_COVER_FILE", "multipart cover upload needs a part named file")
+	ErrCoversDisabled       = apperror.Unavailable("COVERS_DISABLED", "no cover store is configured")
+	ErrCoverUploaded        = apperror.Conflict("COVER_UPLOADED", "book has an uploaded cover, a mirror never replaces it")
+)
+
+var (
+	ErrUnsupportedPatchType = apperror.UnsupportedMediaType("UNSUPPORTED_PATCH_TYPE", "patch must be application/merge-patch+json or application/json-patch+json")
+	ErrInvalidPatch         = apperror.Invalid("INVALID_PATCH", "patch could not be applied to the book")
+	ErrPatchTestFailed      = apperror.Conflict("PATCH_TEST_FAILED", "a test operation of the patch does not match the book")
+	ErrImmutableBookId      = apperror.Invalid("IMMUTABLE_BOOK_ID", "a patch cannot change the book id")
+)
+
+// mapPgError leaves errors that already carry a domain meaning untouched, so it can wrap whole transactions.
+func mapPgError(err error) error {
+	var appError *apperror.Error
+	if errors.As(err, &appError) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrBookNotFound.WithCause(err)
+	}
+
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		switch pgError.Code {
+		case pgUniqueViolation:
+			if pgError.ConstraintName == liveISBNIndex {
+				return ErrDuplicateISBN.WithCause(err)
+			}
+			return ErrBookAlreadyExists.WithCause(err)
+		case pgForeignKeyViolation:
+			if referenceError, ok := referenceErrors[pgError.ConstraintName]; ok {
+				return referenceError.WithCause(err)
+			}
+		}
+	}
+
+	return ErrRepositoryFailure.WithCause(err)
+}
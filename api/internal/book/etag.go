@@ -0,0 +1,73 @@
+package book
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etagOf derives the strong ETag of a book from its version, e.g. `"3"`.
+func etagOf(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// bookETag is the ETag of a single book, answered alike by reads and by the writes that return one. Renaming a credited author or a filed genre changes
+// the book as served without bumping its version, so the last such change is added, e.g. `"3.1711886400000000"`.
+func bookETag(book *BookDTO) string {
+	if book.RelatedUpdatedAt == nil {
+		return etagOf(book.Version)
+	}
+
+	return strconv.Quote(strconv.Itoa(book.Version) + "." + strconv.FormatInt(book.RelatedUpdatedAt.UnixMicro(), 10))
+}
+
+// anyVersion is the expected version of `If-Match: *`, which only asks for the book to exist.
+const anyVersion = 0
+
+// expectedVersion reads the version a client based its write on from If-Match.
+// Writes without it are refused so a stale client can never overwrite blindly. Only the version of an
+// ETag from bookETag is compared, a write replaces the book's own fields and not its authors or genres.
+// If-Match uses the strong comparison, so a weak ETag never matches and fails the precondition.
+func expectedVersion(ctx *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, ErrPreconditionRequired
+	}
+	if header == "*" {
+		return anyVersion, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, ErrVersionMismatch
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, ErrInvalidETag.WithCause(err)
+	}
+
+	unquoted, _, _ = strings.Cut(unquoted, ".")
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, ErrInvalidETag.WithCause(err)
+	}
+
+	return version, nil
+}
+
+// notModified applies the weak comparison RFC 9110 asks for on If-None-Match.
+func notModified(ctx *fiber.Ctx, etag string) bool {
+	header := ctx.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
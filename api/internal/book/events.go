@@ -0,0 +1,75 @@
+package book
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type EventType string
+
+const (
+	EventCreated  EventType = "created"
+	EventUpdated  EventType = "updated"
+	EventDeleted  EventType = "deleted"
+	EventRestored EventType = "restored"
+	EventPurged   EventType = "purged"
+)
+
+const bookEventColumns = "id, book_id, event_type, version, actor, trace_id, before, after, occurred_at"
+
+type BookEvent struct {
+	Id         int64           `json:"id" db:"id"`
+	BookId     string          `json:"bookId" db:"book_id"`
+	Type       EventType       `json:"type" db:"event_type"`
+	Version    int             `json:"version" db:"version"`
+	Actor      string          `json:"actor" db:"actor"`
+	TraceId    string          `json:"traceId,omitempty" db:"trace_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"before"`
+	After      json.RawMessage `json:"after,omitempty" db:"after"`
+	OccurredAt time.Time       `json:"occurredAt" db:"occurred_at"`
+}
+
+// Audit names who made a change and the request trace it belongs to, it travels in the
+// context so the repository can record it next to the change itself.
+type Audit struct {
+	Actor   string
+	TraceId string
+}
+
+type auditKey struct{}
+
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+func auditOf(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+	return audit
+}
+
+// insertEvent must run on the transaction of the change it records, so a book never changes without its event.
+// after is the row as stored once the change applied, soft deletes included, and nil once the row is purged.
+func insertEvent(ctx context.Context, tx pgx.Tx, eventType EventType, before, after *BookDTO) error {
+	current := after
+	if current == nil {
+		current = before
+	}
+
+	audit := auditOf(ctx)
+	_, err := tx.Exec(
+		ctx,
+		"insert into book_events (book_id, event_type, version, actor, trace_id, before, after) values ($1, $2, $3, $4, $5, $6, $7)",
+		current.Id,
+		eventType,
+		current.Version,
+		audit.Actor,
+		audit.TraceId,
+		before,
+		after,
+	)
+
+	return err
+}
@@ -0,0 +1,213 @@
+package book
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	json "github.com/bytedance/sonic"
+)
+
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportMARCXML ExportFormat = "marcxml"
+)
+
+const marcXMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+var exportContentTypes = map[ExportFormat]string{
+	ExportCSV:     "text/csv; charset=utf-8",
+	ExportNDJSON:  "application/x-ndjson",
+	ExportMARCXML: "application/marcxml+xml",
+}
+
+var exportExtensions = map[ExportFormat]string{
+	ExportCSV:     "csv",
+	ExportNDJSON:  "ndjson",
+	ExportMARCXML: "xml",
+}
+
+// exportEncoder writes one book at a time, close finishes the document once every book is written.
+type exportEncoder interface {
+	encode(book *BookDTO) error
+	close() error
+}
+
+func newExportEncoder(format ExportFormat, w io.Writer) (exportEncoder, error) {
+	switch format {
+	case ExportNDJSON:
+		return &ndjsonExport{w: w}, nil
+	case ExportMARCXML:
+		return newMARCExport(w)
+	default:
+		return newCSVExport(w)
+	}
+}
+
+// csvExportHeader uses the json field names, so an export can be imported again as is.
+var csvExportHeader = []string{"id", "coverUrl", "isbn", "title", "author", "publicationYear", "version", "createdAt", "updatedAt", "deletedAt"}
+
+type csvExport struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVExport(w io.Writer) (*csvExport, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvExportHeader); err != nil {
+		return nil, err
+	}
+
+	return &csvExport{writer: writer, record: make([]string, len(csvExportHeader))}, nil
+}
+
+func (c *csvExport) encode(book *BookDTO) error {
+	c.record[0] = book.Id
+	c.record[1] = book.CoverUrl
+	c.record[2] = book.ISBN
+	c.record[3] = book.Title
+	c.record[4] = book.Author
+	c.record[5] = book.PublicationYear
+	c.record[6] = strconv.Itoa(book.Version)
+	c.record[7] = formatExportTime(book.CreatedAt)
+	c.record[8] = formatExportTime(book.UpdatedAt)
+	c.record[9] = formatExportTime(book.DeletedAt)
+
+	return c.writer.Write(c.record)
+}
+
+func (c *csvExport) close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type ndjsonExport struct {
+	w io.Writer
+}
+
+func (n *ndjsonExport) encode(book *BookDTO) error {
+	line, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.w.Write(append(line, '\n'))
+	return err
+}
+
+func (n *ndjsonExport) close() error {
+	return nil
+}
+
+// marcRecord is a minimal MARC 21 bibliographic record in the MARCXML slim schema.
+type marcRecord struct {
+	XMLName       xml.Name           `xml:"record"`
+	Leader        string             `xml:"leader"`
+	ControlFields []marcControlField `xml:"controlfield"`
+	DataFields    []marcDataField    `xml:"datafield"`
+}
+
+type marcControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcDataField struct {
+	Tag       string         `xml:"tag,attr"`
+	Ind1      string         `xml:"ind1,attr"`
+	Ind2      string         `xml:"ind2,attr"`
+	Subfields []marcSubfield `xml:"subfield"`
+}
+
+type marcSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcExport struct {
+	encoder *xml.Encoder
+}
+
+func newMARCExport(w io.Writer) (*marcExport, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	encoder := xml.NewEncoder(w)
+	collection := xml.StartElement{
+		Name: xml.Name{Local: "collection"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: marcXMLNamespace}},
+	}
+	if err := encoder.EncodeToken(collection); err != nil {
+		return nil, err
+	}
+
+	return &marcExport{encoder: encoder}, nil
+}
+
+func (m *marcExport) encode(book *BookDTO) error {
+	return m.encoder.Encode(marcRecordOf(book))
+}
+
+func (m *marcExport) close() error {
+	if err := m.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "collection"}}); err != nil {
+		return err
+	}
+
+	return m.encoder.Flush()
+}
+
+// marcRecordOf maps a book onto 001 control number, 005 latest transaction, 020 ISBN, 100 main author,
+// 245 title, 264 publication date and 856 cover link. Books in the trash get record status "d" in the leader.
+func marcRecordOf(book *BookDTO) marcRecord {
+	status := 'n'
+	if book.DeletedAt != nil {
+		status = 'd'
+	}
+
+	record := marcRecord{
+		Leader:        fmt.Sprintf("00000%cam a2200000   4500", status),
+		ControlFields: []marcControlField{{Tag: "001", Value: book.Id}},
+	}
+
+	if transaction := book.UpdatedAt; transaction != nil || book.CreatedAt != nil {
+		if transaction == nil {
+			transaction = book.CreatedAt
+		}
+		record.ControlFields = append(record.ControlFields, marcControlField{
+			Tag:   "005",
+			Value: transaction.UTC().Format("20060102150405.0"),
+		})
+	}
+
+	dataField := func(tag, ind1, ind2 string, subfields ...marcSubfield) {
+		for _, subfield := range subfields {
+			if subfield.Value == "" {
+				return
+			}
+		}
+		record.DataFields = append(record.DataFields, marcDataField{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: subfields})
+	}
+
+	dataField("020", " ", " ", marcSubfield{Code: "a", Value: book.ISBN})
+	dataField("100", "1", " ", marcSubfield{Code: "a", Value: book.Author})
+	dataField("245", "1", "0", marcSubfield{Code: "a", Value: book.Title})
+	dataField("264", " ", "1", marcSubfield{Code: "c", Value: book.PublicationYear})
+	dataField("856", "4", "2", marcSubfield{Code: "3", Value: "Cover image"}, marcSubfield{Code: "u", Value: book.CoverUrl})
+
+	return record
+}
@@ -0,0 +1,103 @@
+package book
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEncoders(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	deletedAt := createdAt.Add(time.Hour)
+	books := []*BookDTO{
+		{
+			Id:              "0b7c1d6e-3f4a-4b8e-9c2d-1a2b3c4d5e6f",
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+			Version:         2,
+			CreatedAt:       &createdAt,
+		},
+		{
+			Id:              "7d9e2f1a-6b5c-4d3e-8f7a-9b8c7d6e5f4a",
+			ISBN:            "9780201485677",
+			Title:           "Refactoring, \"2nd\" <Edition>",
+			Author:          "Martin Fowler",
+			PublicationYear: "2018",
+			Version:         1,
+			CreatedAt:       &createdAt,
+			DeletedAt:       &deletedAt,
+		},
+	}
+
+	export := func(format ExportFormat) string {
+		var out bytes.Buffer
+		encoder, err := newExportEncoder(format, &out)
+		require.NoError(t, err)
+		for _, book := range books {
+			require.NoError(t, encoder.encode(book))
+		}
+		require.NoError(t, encoder.close())
+		return out.String()
+	}
+
+	t.Run("csv round trips through the importer", func(t *testing.T) {
+		out := export(ExportCSV)
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, strings.Join(csvExportHeader, ","), lines[0])
+		assert.Contains(t, lines[2], "2024-03-01T13:30:00Z")
+
+		rows, err := newRowReader(strings.NewReader(out), ImportCSV)
+		require.NoError(t, err)
+		row, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, books[0].Title, row.Book.Title)
+		row, err = rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, books[1].Title, row.Book.Title)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		out := export(ExportNDJSON)
+
+		rows, err := newRowReader(strings.NewReader(out), ImportNDJSON)
+		require.NoError(t, err)
+		row, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, books[0].ISBN, row.Book.ISBN)
+		assert.Equal(t, 2, strings.Count(out, "\n"))
+	})
+
+	t.Run("marcxml", func(t *testing.T) {
+		out := export(ExportMARCXML)
+
+		var collection struct {
+			XMLName xml.Name     `xml:"http://www.loc.gov/MARC21/slim collection"`
+			Records []marcRecord `xml:"record"`
+		}
+		require.NoError(t, xml.Unmarshal([]byte(out), &collection))
+		require.Len(t, collection.Records, 2)
+
+		first := collection.Records[0]
+		assert.Len(t, first.Leader, 24)
+		assert.Equal(t, byte('n'), first.Leader[5])
+		assert.Equal(t, "20240301123000.0", first.ControlFields[1].Value)
+		assert.Equal(t, "245", first.DataFields[2].Tag)
+		assert.Equal(t, "Clean Code", first.DataFields[2].Subfields[0].Value)
+		assert.Equal(t, "856", first.DataFields[4].Tag)
+
+		second := collection.Records[1]
+		assert.Equal(t, byte('d'), second.Leader[5])
+		assert.Equal(t, books[1].Title, second.DataFields[2].Subfields[0].Value)
+		assert.Len(t, second.DataFields, 4, "no 856 without a cover")
+	})
+}
@@ -0,0 +1,95 @@
+package book
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fileGenres replaces the genres of a book and returns them as stored.
+func fileGenres(ctx context.Context, tx pgx.Tx, bookId string, genres []BookGenre) ([]BookGenre, error) {
+	if _, err := tx.Exec(ctx, "delete from book_genres where book_id = $1", bookId); err != nil {
+		return nil, err
+	}
+
+	if len(genres) == 0 {
+		return nil, nil
+	}
+
+	genreIds := make([]string, len(genres))
+	for i, genre := range genres {
+		genreIds[i] = genre.Id
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		"insert into book_genres (book_id, genre_id) select $1::uuid, genre_id from unnest($2::uuid[]) as genre_id on conflict do nothing",
+		bookId,
+		genreIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	filed, err := loadGenres(ctx, tx, bookId)
+	return filed[bookId], err
+}
+
+// copyGenres files a batch copied by copyBooks under its genres with a single insert, then reads them back
+// for the created events.
+func copyGenres(ctx context.Context, tx pgx.Tx, writes []BulkWrite) error {
+	var bookIds, genreIds []string
+	for _, write := range writes {
+		for _, genre := range write.Book.Genres {
+			bookIds = append(bookIds, write.Book.Id)
+			genreIds = append(genreIds, genre.Id)
+		}
+	}
+
+	if len(bookIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(
+		ctx,
+		"insert into book_genres (book_id, genre_id) select * from unnest($1::uuid[], $2::uuid[]) on conflict do nothing",
+		bookIds,
+		genreIds,
+	)
+	if err != nil {
+		return err
+	}
+
+	filed, err := loadGenres(ctx, tx, bookIds...)
+	if err != nil {
+		return err
+	}
+
+	for _, write := range writes {
+		write.Book.Genres = filed[write.Book.Id]
+	}
+
+	return nil
+}
+
+// loadGenres reads the genres of the given books by name, keyed by book id.
+func loadGenres(ctx context.Context, q querier, bookIds ...string) (map[string][]BookGenre, error) {
+	rows, err := q.Query(
+		ctx,
+		"select book_genres.book_id, genres.id, genres.name, genres.slug from book_genres join genres on genres.id = book_genres.genre_id where book_genres.book_id = any($1::uuid[]) order by genres.name, genres.id",
+		bookIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	filed := make(map[string][]BookGenre, len(bookIds))
+	var bookId string
+	var genre BookGenre
+	_, err = pgx.ForEachRow(rows, []any{&bookId, &genre.Id, &genre.Name, &genre.Slug}, func() error {
+		filed[bookId] = append(filed[bookId], genre)
+		return nil
+	})
+
+	return filed, err
+}
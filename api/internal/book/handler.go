@@ -1,7 +1,16 @@
 package book
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -9,13 +18,69 @@ import (
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"book-api/internal/author"
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+	"book-api/pkg/bodylimit"
+	"book-api/pkg/cursor"
+	"book-api/pkg/enrichment"
+	"book-api/pkg/isbn"
+	"book-api/pkg/storage"
+)
+
+const (
+	defaultCursorLimit = 20
+	maxBulkOperations  = 1000
+)
+
+const (
+	// exportFlushRows is how often an export flushes to the client and pushes its write deadline forward
+	exportFlushRows    = 500
+	exportWriteTimeout = 30 * time.Second
+)
+
+const (
+	importFileField = "file"
+	// uploads above asyncImportBytes are imported in the background even without ?async=true
+	asyncImportBytes = 8 << 20
+	maxImportBytes   = 512 << 20
+	// importRetryAfter is the Retry-After, in seconds, sent when every background import slot is taken
+	importRetryAfter = "30"
 )
 
+// anonymousActor is recorded on book events while authentication is disabled.
+const anonymousActor = "anonymous"
+
 type Handler struct {
-	server     *fiber.App
-	validator  *validator.Validate
-	tracer     trace.Tracer
-	repository Repository
+	server       *fiber.App
+	validator    *validator.Validate
+	tracer       trace.Tracer
+	repository   Repository
+	cursorSigner *cursor.Signer
+	authorizer   *auth.Authorizer
+	imports      *ImportJobs
+	metadata     enrichment.MetadataProvider
+	covers       storage.BlobStore
+	// maxCoverBytes is only set together with covers
+	maxCoverBytes int64
+}
+
+type HandlerOption func(*Handler)
+
+func WithCursorSigner(cursorSigner *cursor.Signer) HandlerOption {
+	return func(h *Handler) {
+		h.cursorSigner = cursorSigner
+	}
+}
+
+// WithAuthorizer requires the role RegisterHandlers lists for each book route. Without it the book routes
+// are open to anyone, which only tests rely on.
+func WithAuthorizer(authorizer *auth.Authorizer) HandlerOption {
+	return func(h *Handler) {
+		h.authorizer = authorizer
+	}
 }
 
 func NewHandler(
@@ -23,38 +88,82 @@ func NewHandler(
 	validator *validator.Validate,
 	tracer trace.Tracer,
 	repository Repository,
+	opts ...HandlerOption,
 ) *Handler {
-	return &Handler{
+	h := &Handler{
 		server:     server,
 		validator:  validator,
 		tracer:     tracer,
 		repository: repository,
+		imports:    NewImportJobs(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.cursorSigner == nil {
+		h.cursorSigner = cursor.NewSigner("")
 	}
+
+	return h
 }
 
 func (h *Handler) RegisterHandlers() {
-	h.server.Post("/book", h.CreateBook)
-	h.server.Get("/books", h.GetBooks)
-	h.server.Get("/book/:id", h.GetBookById)
-	h.server.Put("/book/:id", h.UpdateBookById)
-	h.server.Delete("/book/:id", h.DeleteBookById)
+	reader := h.authorizer.Require(auth.RoleReader)
+	editor := h.authorizer.Require(auth.RoleEditor)
+	admin := h.authorizer.Require(auth.RoleAdmin)
+
+	h.server.Post("/book", editor, h.CreateBook)
+	h.server.Post("/book/enrich", editor, h.EnrichBook)
+	h.server.Get("/books", reader, h.GetBooks)
+	h.server.Get("/books/export", reader, h.ExportBooks)
+	h.server.Get("/book/:id", reader, h.GetBookById)
+	h.server.Get("/book/isbn/:isbn", reader, h.GetBookByISBN)
+	h.server.Put("/book/:id", editor, h.UpdateBookById)
+	h.server.Patch("/book/:id", editor, h.PatchBookById)
+	h.server.Delete("/book/:id", admin, h.DeleteBookById)
+	h.server.Get("/book/:id/history", reader, h.GetBookHistory)
+	h.server.Put("/book/:id/cover", editor, h.PutBookCover)
+	h.server.Get("/book/:id/cover", reader, h.GetBookCover)
+	h.server.Delete("/book/:id/cover", admin, h.DeleteBookCover)
+	h.server.Get("/books/deleted", editor, h.GetDeletedBooks)
+	h.server.Get("/books/covers/failing", admin, h.GetFailingCovers)
+	h.server.Post("/books/bulk", editor, h.BulkBooks)
+	h.server.Post("/books/import", editor, h.ImportBooks)
+	h.server.Get("/books/import/:jobId", editor, h.GetImportJob)
+	h.server.Post("/book/:id/restore", editor, h.RestoreBookById)
+	h.server.Get("/author/:id/books", reader, h.GetAuthorBooks)
 }
 
 func (h *Handler) CreateBook(ctx *fiber.Ctx) error {
 	_, span := h.tracer.Start(ctx.Context(), "CreateBook")
 	defer span.End()
 
+	var queries CreateBookQuery
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
 	var reqBody CreateBookRequest
 	if err := ctx.BodyParser(&reqBody); err != nil {
-		return err
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	var enrichErr error
+	if queries.Enrich {
+		enrichErr = h.enrichCreate(ctx.Context(), &reqBody)
 	}
 
 	span.SetAttributes(
 		attribute.String("newBook", fmt.Sprintf("%+v", reqBody)),
+		attribute.Bool("enrich", queries.Enrich),
 	)
 
 	if err := h.validator.StructCtx(ctx.Context(), &reqBody); err != nil {
-		return fiber.ErrBadRequest
+		if enrichErr != nil {
+			return apperror.FromValidation(err).WithMeta("enrichment", apperror.NewProblem(enrichErr).Code)
+		}
+		return apperror.FromValidation(err)
 	}
 
 	if reqBody.Id == "" {
@@ -62,18 +171,27 @@ func (h *Handler) CreateBook(ctx *fiber.Ctx) error {
 	}
 
 	now := time.Now().UTC()
-	if err := h.repository.CreateBook(ctx.Context(), &BookDTO{
+	book := &BookDTO{
 		Id:              reqBody.Id,
 		CoverUrl:        reqBody.CoverUrl,
 		ISBN:            reqBody.ISBN,
 		Title:           reqBody.Title,
 		Author:          reqBody.Author,
 		PublicationYear: reqBody.PublicationYear,
+		Authors:         reqBody.Authors,
 		CreatedAt:       &now,
-	}); err != nil {
+	}
+	reqBody.applyEdition(book)
+	reqBody.applyClassification(book)
+	if err := h.repository.CreateBook(auditContext(ctx), book); err != nil {
+		var appError *apperror.Error
+		if errors.Is(err, ErrDuplicateISBN) && errors.As(err, &appError) && appError.Meta["bookId"] != nil {
+			ctx.Location(fmt.Sprintf("/book/%s", appError.Meta["bookId"]))
+		}
 		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, bookETag(book))
 	return ctx.SendStatus(fiber.StatusCreated)
 }
 
@@ -83,7 +201,7 @@ func (h *Handler) GetBooks(ctx *fiber.Ctx) error {
 
 	var queries GetBooksRequest
 	if err := ctx.QueryParser(&queries); err != nil {
-		return err
+		return apperror.ErrInvalidQuery.WithCause(err)
 	}
 
 	span.SetAttributes(
@@ -91,7 +209,21 @@ func (h *Handler) GetBooks(ctx *fiber.Ctx) error {
 	)
 
 	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
-		return fiber.ErrBadRequest
+		return apperror.FromValidation(err)
+	}
+
+	filter, err := bookFilterOf(queries.BookFilterRequest)
+	if err != nil {
+		return err
+	}
+
+	if queries.Cursor != "" || queries.Limit != 0 {
+		return h.getBooksByCursor(ctx, queries, filter)
+	}
+
+	sort, err := ParseSort(queries.Sort)
+	if err != nil {
+		return err
 	}
 
 	if queries.Page == 0 {
@@ -102,12 +234,13 @@ func (h *Handler) GetBooks(ctx *fiber.Ctx) error {
 		queries.PageSize = 5
 	}
 
-	books, totalPage, err := h.repository.GetBooks(
-		ctx.Context(),
-		queries.Page,
-		queries.PageSize,
-		queries.Search,
-	)
+	books, totalPage, err := h.repository.GetBooks(ctx.Context(), ListBooksQuery{
+		Filter:    filter,
+		Sort:      sort,
+		Page:      queries.Page,
+		PageSize:  queries.PageSize,
+		Highlight: queries.Highlight,
+	})
 	if err != nil {
 		return err
 	}
@@ -116,10 +249,199 @@ func (h *Handler) GetBooks(ctx *fiber.Ctx) error {
 		attribute.String("books", fmt.Sprintf("%+v", books)),
 		attribute.Int("totalPage", totalPage),
 	)
-	return ctx.JSON(fiber.Map{
+	response := fiber.Map{
 		"books":     books,
 		"totalPage": totalPage,
+	}
+
+	if queries.Facets {
+		if response["facets"], err = h.repository.GetBookFacets(ctx.Context(), filter); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *Handler) getBooksByCursor(ctx *fiber.Ctx, queries GetBooksRequest, filter BookFilter) error {
+	request := CursorPageRequest{
+		Filter:       filter,
+		Limit:        queries.Limit,
+		IncludeTotal: queries.IncludeTotal,
+		Highlight:    queries.Highlight,
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultCursorLimit
+	}
+
+	// cursor pages are always in created order, so the filter is all a cursor depends on
+	scope := cursor.Scope{Kind: "books", Query: filter}
+	if queries.Cursor != "" {
+		var position BookCursor
+		if err := h.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.GetBooksByCursor(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	response := GetBooksCursorResponse{
+		Books:      page.Books,
+		TotalCount: page.TotalCount,
+	}
+	if response.Books == nil {
+		response.Books = []BookDTO{}
+	}
+
+	if page.Next != nil {
+		if response.NextCursor, err = h.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	if page.Prev != nil {
+		if response.PrevCursor, err = h.cursorSigner.Encode(scope, page.Prev); err != nil {
+			return err
+		}
+	}
+
+	if queries.Facets {
+		if response.Facets, err = h.repository.GetBookFacets(ctx.Context(), filter); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func bookFilterOf(queries BookFilterRequest) (BookFilter, error) {
+	filter := BookFilter{
+		Search:     queries.Search,
+		SearchMode: SearchMode(queries.SearchMode),
+		Author:     queries.Author,
+		ISBN:       queries.ISBN,
+		YearFrom:   queries.YearFrom,
+		YearTo:     queries.YearTo,
+		Genre:      queries.Genre,
+		Tag:        queries.Tag,
+	}
+	if filter.Search != "" && filter.SearchMode == "" {
+		filter.SearchMode = SearchModeFullText
+	}
+
+	if queries.CreatedAfter != "" {
+		createdAfter, err := parseTimestamp(queries.CreatedAfter)
+		if err != nil {
+			return BookFilter{}, ErrInvalidTimestamp.WithMeta("field", "createdAfter").WithCause(err)
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	return filter, nil
+}
+
+// parseTimestamp accepts either a full RFC 3339 timestamp or a plain date, which is read as midnight UTC.
+func parseTimestamp(value string) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp.UTC(), nil
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
+
+func (h *Handler) ExportBooks(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "ExportBooks")
+	defer span.End()
+
+	var queries ExportBooksRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	span.SetAttributes(
+		attribute.String("queries", fmt.Sprintf("%+v", queries)),
+	)
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	filter, err := bookFilterOf(queries.BookFilterRequest)
+	if err != nil {
+		return err
+	}
+
+	sort, err := ParseSort(queries.Sort)
+	if err != nil {
+		return err
+	}
+
+	// the trash is only listed to editors, exporting it along with everything else takes an admin
+	if queries.IncludeDeleted && h.authorizer != nil {
+		if principal := auth.PrincipalFrom(ctx); principal == nil || !principal.HasRole(auth.RoleAdmin) {
+			return auth.ErrForbidden.WithMeta("requiredRole", auth.RoleAdmin)
+		}
+	}
+
+	format := ExportFormat(queries.Format)
+	if format == "" {
+		format = ExportCSV
+	}
+
+	query := ExportBooksQuery{Filter: filter, Sort: sort, IncludeDeleted: queries.IncludeDeleted}
+	// the body is written after the handler returns and the fiber context is recycled, so the
+	// stream writer only captures what it needs up front
+	exportCtx := ctx.UserContext()
+	conn := ctx.Context().Conn()
+
+	ctx.Attachment(fmt.Sprintf("books-%s.%s", time.Now().UTC().Format(time.DateOnly), exportExtensions[format]))
+	ctx.Set(fiber.HeaderContentType, exportContentTypes[format])
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		rows, err := h.exportBooks(exportCtx, query, format, w, func() {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+			}
+		})
+		if err != nil {
+			// the status line is long gone, all that is left is to cut the response short
+			zap.L().Error("book export failed", zap.Int("rows", rows), zap.Error(err))
+		}
+	})
+
+	return nil
+}
+
+func (h *Handler) exportBooks(ctx context.Context, query ExportBooksQuery, format ExportFormat, w *bufio.Writer, extendDeadline func()) (int, error) {
+	encoder, err := newExportEncoder(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err = h.repository.ExportBooks(ctx, query, func(book *BookDTO) error {
+		if err := encoder.encode(book); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			extendDeadline()
+			return w.Flush()
+		}
+		return nil
 	})
+	if err == nil {
+		err = encoder.close()
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+
+	return rows, err
 }
 
 func (h *Handler) GetBookById(ctx *fiber.Ctx) error {
@@ -130,7 +452,17 @@ func (h *Handler) GetBookById(ctx *fiber.Ctx) error {
 	span.SetAttributes(attribute.String("bookId", bookId))
 
 	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
-		return fiber.ErrBadRequest
+		return apperror.FromParamValidation("id", err)
+	}
+
+	var queries GetBookRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	embed, err := parseEmbed(queries.Embed)
+	if err != nil {
+		return err
 	}
 
 	book, err := h.repository.GetBookById(ctx.Context(), bookId)
@@ -140,6 +472,70 @@ func (h *Handler) GetBookById(ctx *fiber.Ctx) error {
 
 	span.SetAttributes(attribute.String("book", fmt.Sprintf("%+v", book)))
 
+	etag := bookETag(book)
+	ctx.Set(fiber.HeaderETag, etag)
+	// the ETag says nothing about the embedded records, so only the plain book can be answered from cache
+	if !embed.Any() && notModified(ctx, etag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	if embed.Any() {
+		if err = h.repository.EmbedBook(ctx.Context(), book, embed); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"book": book,
+	})
+}
+
+// parseEmbed reads the comma separated embed query of GET /book/:id.
+func parseEmbed(raw string) (BookEmbed, error) {
+	var embed BookEmbed
+	if raw == "" {
+		return embed, nil
+	}
+
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case "publisher":
+			embed.Publisher = true
+		case "editions":
+			embed.Editions = true
+		case "series":
+			embed.Series = true
+		default:
+			return embed, ErrUnknownEmbed.WithMeta("embed", name)
+		}
+	}
+
+	return embed, nil
+}
+
+func (h *Handler) GetBookByISBN(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetBookByISBN")
+	defer span.End()
+
+	raw := ctx.Params("isbn")
+	span.SetAttributes(attribute.String("isbn", raw))
+
+	isbn13, err := isbn.Normalize(raw)
+	if err != nil {
+		return ErrInvalidISBN.WithMeta("isbn", raw).WithCause(err)
+	}
+
+	book, err := h.repository.GetBookByISBN(ctx.Context(), isbn13)
+	if err != nil {
+		return err
+	}
+
+	etag := bookETag(book)
+	ctx.Set(fiber.HeaderETag, etag)
+	if notModified(ctx, etag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
 	return ctx.JSON(fiber.Map{
 		"book": book,
 	})
@@ -151,7 +547,7 @@ func (h *Handler) UpdateBookById(ctx *fiber.Ctx) error {
 
 	var reqBody CreateBookRequest
 	if err := ctx.BodyParser(&reqBody); err != nil {
-		return err
+		return apperror.ErrInvalidBody.WithCause(err)
 	}
 
 	bookId := ctx.Params("id")
@@ -164,22 +560,32 @@ func (h *Handler) UpdateBookById(ctx *fiber.Ctx) error {
 		CreateBookRequest: reqBody,
 		Id:                bookId,
 	}); err != nil {
-		return fiber.ErrBadRequest
+		return apperror.FromValidation(err)
+	}
+
+	version, err := expectedVersion(ctx)
+	if err != nil {
+		return err
 	}
 
 	now := time.Now().UTC()
-	if err := h.repository.UpdateBookById(ctx.Context(), bookId, &BookDTO{
+	book := &BookDTO{
 		Id:              bookId,
 		CoverUrl:        reqBody.CoverUrl,
 		ISBN:            reqBody.ISBN,
 		Title:           reqBody.Title,
 		Author:          reqBody.Author,
 		PublicationYear: reqBody.PublicationYear,
+		Authors:         reqBody.Authors,
 		UpdatedAt:       &now,
-	}); err != nil {
+	}
+	reqBody.applyEdition(book)
+	reqBody.applyClassification(book)
+	if err = h.repository.UpdateBookById(auditContext(ctx), bookId, version, book); err != nil {
 		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, bookETag(book))
 	return ctx.SendStatus(fiber.StatusNoContent)
 }
 
@@ -191,12 +597,551 @@ func (h *Handler) DeleteBookById(ctx *fiber.Ctx) error {
 	span.SetAttributes(attribute.String("bookId", id))
 
 	if err := h.validator.VarCtx(ctx.Context(), id, "required,uuid4"); err != nil {
-		return fiber.ErrBadRequest
+		return apperror.FromParamValidation("id", err)
 	}
 
-	if err := h.repository.DeleteBookById(ctx.Context(), id); err != nil {
+	var queries DeleteBookRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+	span.SetAttributes(attribute.Bool("hard", queries.Hard))
+
+	version, err := expectedVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if queries.Hard {
+		var covers []BookCover
+		covers, err = h.repository.PurgeBookById(auditContext(ctx), id, version)
+		deletePurgedCoverBlobs(h.covers, covers)
+	} else {
+		err = h.repository.DeleteBookById(auditContext(ctx), id, version)
+	}
+
+	if err != nil {
 		return err
 	}
 
 	return ctx.SendStatus(fiber.StatusNoContent)
 }
+
+func (h *Handler) GetDeletedBooks(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetDeletedBooks")
+	defer span.End()
+
+	var queries GetDeletedBooksRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	request := DeletedPageRequest{Limit: queries.Limit}
+	if request.Limit == 0 {
+		request.Limit = defaultCursorLimit
+	}
+
+	scope := cursor.Scope{Kind: "books.deleted"}
+	if queries.Cursor != "" {
+		var position DeletedBookCursor
+		if err := h.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.GetDeletedBooks(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	response := GetBooksCursorResponse{Books: page.Books}
+	if response.Books == nil {
+		response.Books = []BookDTO{}
+	}
+
+	if page.Next != nil {
+		if response.NextCursor, err = h.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *Handler) RestoreBookById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "RestoreBookById")
+	defer span.End()
+
+	id := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", id))
+
+	if err := h.validator.VarCtx(ctx.Context(), id, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	version, err := expectedVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	book, err := h.repository.RestoreBookById(auditContext(ctx), id, version)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, bookETag(book))
+	return ctx.JSON(fiber.Map{
+		"book": book,
+	})
+}
+
+func (h *Handler) GetBookHistory(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetBookHistory")
+	defer span.End()
+
+	bookId := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", bookId))
+
+	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	var queries GetBookHistoryRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	request := HistoryPageRequest{BookId: bookId, Limit: queries.Limit}
+	if request.Limit == 0 {
+		request.Limit = defaultCursorLimit
+	}
+
+	scope := cursor.Scope{Kind: "books.history", Query: bookId}
+	if queries.Cursor != "" {
+		var position HistoryCursor
+		if err := h.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil || position.BookId != bookId {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.GetBookHistory(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	response := GetBookHistoryResponse{Events: page.Events}
+	if response.Events == nil {
+		response.Events = []BookEvent{}
+	}
+
+	if page.Next != nil {
+		if response.NextCursor, err = h.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *Handler) GetAuthorBooks(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetAuthorBooks")
+	defer span.End()
+
+	authorId := ctx.Params("id")
+	span.SetAttributes(attribute.String("authorId", authorId))
+
+	if err := h.validator.VarCtx(ctx.Context(), authorId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	var queries GetAuthorBooksRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	request := AuthorBooksPageRequest{AuthorId: authorId, Role: author.Role(queries.Role), Limit: queries.Limit}
+	if request.Limit == 0 {
+		request.Limit = defaultCursorLimit
+	}
+
+	scope := cursor.Scope{Kind: "books.author", Query: []string{authorId, queries.Role}}
+	if queries.Cursor != "" {
+		var position AuthorBooksCursor
+		if err := h.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil || position.AuthorId != authorId {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.GetAuthorBooks(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	response := GetBooksCursorResponse{Books: page.Books}
+	if response.Books == nil {
+		response.Books = []BookDTO{}
+	}
+
+	if page.Next != nil {
+		if response.NextCursor, err = h.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *Handler) BulkBooks(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "BulkBooks")
+	defer span.End()
+
+	var queries BulkBooksRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	var operations []BulkOperation
+	if err := ctx.BodyParser(&operations); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	if len(operations) == 0 {
+		return ErrEmptyBulk
+	}
+
+	if len(operations) > maxBulkOperations {
+		return ErrTooManyBulkOperations.WithMeta("max", maxBulkOperations)
+	}
+
+	atomic := BulkMode(queries.Mode) != BulkModeBestEffort
+	span.SetAttributes(attribute.Int("operations", len(operations)), attribute.Bool("atomic", atomic))
+
+	results := make([]BulkItemResult, len(operations))
+	writes := make([]BulkWrite, 0, len(operations))
+	positions := make([]int, 0, len(operations))
+	var invalidFields []apperror.FieldError
+	hasDeletes := false
+	for i, operation := range operations {
+		write, err := h.bulkWriteOf(ctx, operation)
+		results[i] = BulkItemResult{Index: i, Op: write.Op, Id: write.Book.Id}
+		if err != nil {
+			results[i].fail(err)
+			for _, field := range err.Fields {
+				field.Field = fmt.Sprintf("[%d].%s", i, field.Field)
+				invalidFields = append(invalidFields, field)
+			}
+			continue
+		}
+
+		hasDeletes = hasDeletes || write.Op == BulkDelete
+		writes = append(writes, write)
+		positions = append(positions, i)
+	}
+
+	if atomic && len(invalidFields) > 0 {
+		return apperror.ErrValidation.WithFields(invalidFields)
+	}
+
+	// the route only asks for editors, deleting still takes an admin like DELETE /book/:id does
+	if hasDeletes && h.authorizer != nil {
+		if principal := auth.PrincipalFrom(ctx); principal == nil || !principal.HasRole(auth.RoleAdmin) {
+			return auth.ErrForbidden.WithMeta("requiredRole", auth.RoleAdmin)
+		}
+	}
+
+	errs, err := h.repository.BulkWrite(auditContext(ctx), writes, atomic)
+	if err != nil {
+		if index := slices.IndexFunc(errs, func(err error) bool { return err != nil }); index >= 0 {
+			var appError *apperror.Error
+			if errors.As(err, &appError) {
+				return appError.WithMeta("index", positions[index])
+			}
+		}
+
+		return err
+	}
+
+	response := BulkBooksResponse{Results: results}
+	for j, write := range writes {
+		result := &response.Results[positions[j]]
+		if errs[j] != nil {
+			result.fail(errs[j])
+			continue
+		}
+
+		result.Status = bulkStatus[write.Op]
+		result.Version = write.Book.Version
+	}
+
+	for _, result := range response.Results {
+		if result.Error != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+var bulkStatus = map[BulkOp]int{
+	BulkCreate: fiber.StatusCreated,
+	BulkUpdate: fiber.StatusOK,
+	BulkDelete: fiber.StatusNoContent,
+}
+
+// bulkWriteOf validates one bulk operation with the same rules as its single book endpoint.
+func (h *Handler) bulkWriteOf(ctx *fiber.Ctx, operation BulkOperation) (BulkWrite, *apperror.Error) {
+	if operation.Op == "" {
+		operation.Op = BulkCreate
+	}
+
+	write := BulkWrite{
+		Op:              operation.Op,
+		ExpectedVersion: operation.Version,
+		Book: &BookDTO{
+			Id:              operation.Id,
+			CoverUrl:        operation.CoverUrl,
+			ISBN:            operation.ISBN,
+			Title:           operation.Title,
+			Author:          operation.Author,
+			PublicationYear: operation.PublicationYear,
+			Authors:         operation.Authors,
+		},
+	}
+	operation.applyEdition(write.Book)
+	operation.applyClassification(write.Book)
+
+	if err := h.validator.VarCtx(ctx.Context(), operation.Op, "oneof=create update delete"); err != nil {
+		return write, apperror.FromParamValidation("op", err)
+	}
+
+	var err error
+	switch operation.Op {
+	case BulkCreate:
+		if write.Book.Id == "" {
+			write.Book.Id = uuid.NewString()
+		}
+		err = h.validator.StructCtx(ctx.Context(), &operation.CreateBookRequest)
+	case BulkUpdate:
+		err = h.validator.StructCtx(ctx.Context(), &UpdateBookRequest{CreateBookRequest: operation.CreateBookRequest, Id: operation.Id})
+	case BulkDelete:
+		if err = h.validator.VarCtx(ctx.Context(), operation.Id, "required,uuid4"); err != nil {
+			return write, apperror.FromParamValidation("id", err)
+		}
+	}
+
+	if err != nil {
+		return write, apperror.FromValidation(err)
+	}
+
+	if operation.Op != BulkCreate {
+		if err = h.validator.VarCtx(ctx.Context(), operation.Version, "required,min=1"); err != nil {
+			return write, apperror.FromParamValidation("version", err)
+		}
+	}
+
+	return write, nil
+}
+
+func (h *Handler) ImportBooks(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "ImportBooks")
+	defer span.End()
+
+	var queries ImportBooksRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	upload, err := uploadOf(ctx, importFileField, maxImportBytes, ErrMissingImportFile, ErrImportTooLarge)
+	if err != nil {
+		return err
+	}
+
+	format, err := importFormatOf(upload.contentType, upload.fileName, queries.Format)
+	if err != nil {
+		return err
+	}
+
+	async := queries.Async || ctx.Request().Header.ContentLength() > asyncImportBytes
+	span.SetAttributes(
+		attribute.String("format", string(format)),
+		attribute.Bool("dryRun", queries.DryRun),
+		attribute.Bool("async", async),
+	)
+
+	if !async {
+		rows, err := newRowReader(upload, format)
+		if err != nil {
+			return err
+		}
+
+		report, err := h.importBooks(auditContext(ctx), rows, queries.DryRun, nil)
+		if err != nil {
+			// earlier batches are already committed, the client needs to know where to resume
+			var appError *apperror.Error
+			if errors.As(err, &appError) {
+				return appError.WithMeta("rows", report.Rows).WithMeta("imported", report.Imported)
+			}
+			return err
+		}
+
+		return ctx.JSON(report)
+	}
+
+	if !h.imports.reserve() {
+		ctx.Set(fiber.HeaderRetryAfter, importRetryAfter)
+		return ErrTooManyImports.WithMeta("maxRunning", maxRunningImports)
+	}
+
+	// the request body is gone once the handler returns, so the upload is spooled to disk for the job
+	spool, err := os.CreateTemp("", "book-import-*")
+	if err != nil {
+		h.imports.release()
+		return ErrImportSpoolFailure.WithCause(err)
+	}
+
+	if _, err = io.Copy(spool, upload); err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		h.imports.release()
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		if errors.Is(err, bodylimit.ErrTooLarge) {
+			ctx.Context().SetConnectionClose()
+			return ErrImportTooLarge.WithMeta("maxBytes", maxImportBytes)
+		}
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	job := h.imports.create(queries.DryRun)
+	audit := auditOf(auditContext(ctx))
+	go h.runImport(WithAudit(context.Background(), audit), job.Id, spool, format, queries.DryRun)
+
+	ctx.Location("/books/import/" + job.Id)
+	return ctx.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// runImport owns the slot reserved for it and the spool file, both are given back when it returns.
+func (h *Handler) runImport(ctx context.Context, jobId string, spool *os.File, format ImportFormat, dryRun bool) {
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		h.imports.release()
+	}()
+
+	h.imports.progress(jobId, ImportReport{DryRun: dryRun, Errors: []ImportRowError{}})
+
+	rows, err := newRowReader(spool, format)
+	if err != nil {
+		h.imports.finish(jobId, ImportReport{DryRun: dryRun, Errors: []ImportRowError{}}, err)
+		return
+	}
+
+	report, err := h.importBooks(ctx, rows, dryRun, func(report ImportReport) {
+		h.imports.progress(jobId, report)
+	})
+	if err != nil {
+		zap.L().Error("book import failed", zap.String("jobId", jobId), zap.Error(err))
+	}
+
+	h.imports.finish(jobId, report, err)
+}
+
+func (h *Handler) GetImportJob(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetImportJob")
+	defer span.End()
+
+	jobId := ctx.Params("jobId")
+	span.SetAttributes(attribute.String("jobId", jobId))
+
+	if err := h.validator.VarCtx(ctx.Context(), jobId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("jobId", err)
+	}
+
+	job, ok := h.imports.get(jobId)
+	if !ok {
+		return ErrImportJobNotFound
+	}
+
+	return ctx.JSON(job)
+}
+
+// upload is a file sent either as a part of a multipart form or as the raw body, with the content type
+// and file name the client gave it.
+type upload struct {
+	io.Reader
+	contentType string
+	fileName    string
+}
+
+// uploadOf finds the field part of a multipart form without buffering it, or takes the raw body of any other
+// request. The whole body is capped at limit so a form cannot stream past it in parts other than field.
+// A form without field is answered with missing and a body past limit with tooLarge.
+func uploadOf(ctx *fiber.Ctx, field string, limit int64, missing, tooLarge *apperror.Error) (*upload, error) {
+	body := bodylimit.Body(ctx, limit)
+
+	contentType := ctx.Get(fiber.HeaderContentType)
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != fiber.MIMEMultipartForm {
+		return &upload{Reader: body, contentType: contentType}, nil
+	}
+
+	parts := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, missing
+		}
+
+		if errors.Is(err, bodylimit.ErrTooLarge) {
+			ctx.Context().SetConnectionClose()
+			return nil, tooLarge.WithMeta("maxBytes", limit)
+		}
+		if err != nil {
+			return nil, apperror.ErrInvalidBody.WithCause(err)
+		}
+
+		if part.FormName() == field {
+			return &upload{Reader: part, contentType: part.Header.Get(fiber.HeaderContentType), fileName: part.FileName()}, nil
+		}
+	}
+}
+
+// auditContext hands the repository who is making the change and the trace of the request,
+// so both end up in the book_events row written with it.
+func auditContext(ctx *fiber.Ctx) context.Context {
+	audit := Audit{Actor: anonymousActor}
+	if principal := auth.PrincipalFrom(ctx); principal != nil {
+		audit.Actor = principal.Subject
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx.UserContext()); spanContext.HasTraceID() {
+		audit.TraceId = spanContext.TraceID().String()
+	}
+
+	return WithAudit(ctx.Context(), audit)
+}
@@ -1,10 +1,18 @@
 package book
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -18,6 +26,15 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
+
+	"book-api/internal/author"
+	"book-api/internal/work"
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+	"book-api/pkg/cursor"
+	"book-api/pkg/enrichment"
+	"book-api/pkg/isbn"
+	"book-api/pkg/storage"
 )
 
 func TestHandler_NewHandler(t *testing.T) {
@@ -65,6 +82,206 @@ func TestHandler_CreateBook(t *testing.T) {
 
 	})
 
+	t.Run("isbn forms pkg/isbn accepts", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		for _, form := range []string{"978-0-13-235-088-4", "0-8044-2957-x"} {
+			body := fmt.Sprintf(`{"coverUrl":"https://img.com/cover.jpg","isbn":%q,"title":"Clean Code","author":"Robert C. Martin","publicationYear":"2008"}`, form)
+			req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, res.StatusCode, form)
+		}
+	})
+
+	t.Run("duplicate isbn", func(t *testing.T) {
+		existingId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(ErrDuplicateISBN.WithMeta("bookId", existingId))
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		marshalledReqBody, err := json.Marshal(CreateBookRequest{
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "0-13-235088-2",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(string(marshalledReqBody)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+		assert.Equal(t, "/book/"+existingId, res.Header.Get(fiber.HeaderLocation))
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, "DUPLICATE_ISBN", problem.Code)
+		assert.Equal(t, existingId, problem.Meta["bookId"])
+	})
+
+	t.Run("credits without author text", func(t *testing.T) {
+		tolkienId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, book *BookDTO) error {
+			assert.Empty(t, book.Author, "the repository derives the byline")
+			assert.Equal(t, []BookAuthor{
+				{Id: tolkienId, Role: author.RoleAuthor},
+				{Name: "Christopher Tolkien", Role: author.RoleEditor},
+			}, book.Authors)
+			return nil
+		})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		body := fmt.Sprintf(`{"coverUrl":"https://img.com/silmarillion.jpg","isbn":"9780261102736","title":"The Silmarillion","publicationYear":"1977","authors":[{"id":%q,"role":"author"},{"name":"Christopher Tolkien","role":"editor"}]}`, tolkienId)
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("edition of a work in a series", func(t *testing.T) {
+		workId, publisherId, seriesId := uuid.NewString(), uuid.NewString(), uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, book *BookDTO) error {
+			assert.Equal(t, workId, book.WorkId)
+			assert.Equal(t, &publisherId, book.PublisherId)
+			assert.Equal(t, &seriesId, book.SeriesId)
+			require.NotNil(t, book.SeriesPosition)
+			assert.Equal(t, 4, *book.SeriesPosition)
+			return nil
+		})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		body := fmt.Sprintf(`{"coverUrl":"https://img.com/mort.jpg","isbn":"0-552-13106-7","title":"Mort","author":"Terry Pratchett","publicationYear":"1988","workId":%q,"publisherId":%q,"seriesId":%q,"seriesPosition":4}`, workId, publisherId, seriesId)
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("genres and tags", func(t *testing.T) {
+		genreId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, book *BookDTO) error {
+			assert.Equal(t, []BookGenre{{Id: genreId}}, book.Genres)
+			assert.Equal(t, []string{"discworld", "death"}, book.Tags)
+			return nil
+		})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		body := fmt.Sprintf(`{"coverUrl":"https://img.com/mort.jpg","isbn":"0-552-13106-7","title":"Mort","author":"Terry Pratchett","publicationYear":"1988","genreIds":[%q],"tags":["Discworld"," death ","discworld"]}`, genreId)
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("series position without a series", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(`{"coverUrl":"https://img.com/mort.jpg","isbn":"0-552-13106-7","title":"Mort","author":"Terry Pratchett","publicationYear":"1988","seriesPosition":4}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, []apperror.FieldError{{Field: "seriesPosition", Rule: "excluded_without", Param: "SeriesId"}}, problem.Errors)
+	})
+
+	t.Run("invalid credits", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(`{"coverUrl":"https://img.com/silmarillion.jpg","isbn":"9780261102736","title":"The Silmarillion","publicationYear":"1977","authors":[{"role":"ghostwriter"}]}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.ElementsMatch(t, []apperror.FieldError{
+			{Field: "authors[0].name", Rule: "required_without", Param: "Id"},
+			{Field: "authors[0].role", Rule: "oneof", Param: "author editor translator illustrator"},
+		}, problem.Errors)
+	})
+
+	t.Run("enrich fills the fields left empty", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, book *BookDTO) error {
+			assert.Equal(t, "To Kill a Mockingbird", book.Title)
+			assert.Equal(t, "Harper Lee", book.Author)
+			assert.Equal(t, "1960", book.PublicationYear, "client values win")
+			assert.Equal(t, "https://covers.example/mockingbird.jpg", book.CoverUrl)
+			return nil
+		})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithMetadataProvider(testMetadataProvider(t)))
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, "/book?enrich=true", strings.NewReader(`{"isbn":"0-06-112008-1","publicationYear":"1960"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+	})
+
+	t.Run("failed enrichment is named in the validation problem", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithMetadataProvider(testMetadataProvider(t)))
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, "/book?enrich=true", strings.NewReader(`{"isbn":"9780201485677"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, "VALIDATION_FAILED", problem.Code)
+		assert.Equal(t, "METADATA_NOT_FOUND", problem.Meta["enrichment"])
+	})
+
 	t.Run("invalid request body", func(t *testing.T) {
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, nil)
@@ -120,9 +337,66 @@ func TestHandler_CreateBook(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+			assert.Equal(t, apperror.MIMEApplicationProblemJSON, res.Header.Get(fiber.HeaderContentType))
 		}
 	})
 
+	t.Run("validation problem details", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		marshalledReqBody, err := json.Marshal(CreateBookRequest{
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "invalid-isbn",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(string(marshalledReqBody)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, "VALIDATION_FAILED", problem.Code)
+		assert.Equal(t, []apperror.FieldError{{Field: "isbn", Rule: "isbn"}}, problem.Errors)
+	})
+
+	t.Run("duplicate book", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			CreateBook(gomock.Any(), gomock.Any()).
+			Return(ErrBookAlreadyExists)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		marshalledReqBody, err := json.Marshal(CreateBookRequest{
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/book", strings.NewReader(string(marshalledReqBody)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
 	t.Run("repository error", func(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
@@ -189,7 +463,7 @@ func TestHandler_GetBooks(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
 			EXPECT().
-			GetBooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			GetBooks(gomock.Any(), gomock.Any()).
 			Return(books, 1, nil).
 			Times(1)
 
@@ -265,14 +539,14 @@ func TestHandler_GetBooks(t *testing.T) {
 		res, err := server.Test(req, -1)
 
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 	})
 
 	t.Run("repository error", func(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
 			EXPECT().
-			GetBooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			GetBooks(gomock.Any(), gomock.Any()).
 			Return(nil, 0, fiber.NewError(fiber.StatusInternalServerError, "repository error"))
 
 		server, validate, tracer := setupServer()
@@ -290,251 +564,539 @@ func TestHandler_GetBooks(t *testing.T) {
 	})
 }
 
-func TestHandler_GetBookById(t *testing.T) {
+func TestHandler_GetBooksWithFilters(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
 
 	t.Run("happy path", func(t *testing.T) {
+		createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetBooks(gomock.Any(), ListBooksQuery{
+				Filter: BookFilter{
+					Author:       "Robert C. Martin",
+					ISBN:         "9780132350884",
+					YearFrom:     2000,
+					YearTo:       2010,
+					CreatedAfter: &createdAfter,
+				},
+				Sort: []SortField{
+					{Field: "title"},
+					{Field: "publicationYear", Descending: true},
+				},
+				Page:     1,
+				PageSize: 5,
+			}).
+			Return(&[]BookDTO{}, 1, nil)
 
-		id := uuid.NewString()
-		now := time.Now().UTC()
-		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(&BookDTO{
-			Id:              id,
-			Title:           "",
-			Author:          "",
-			PublicationYear: "",
-			CreatedAt:       &now,
-		}, nil)
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		query := url.Values{
+			"author":       {"Robert C. Martin"},
+			"isbn":         {"9780132350884"},
+			"yearFrom":     {"2000"},
+			"yearTo":       {"2010"},
+			"createdAfter": {"2024-01-01"},
+			"sort":         {"title,-publicationYear"},
+		}
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?"+query.Encode(), nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("full-text search with highlight", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetBooks(gomock.Any(), ListBooksQuery{
+				Filter:    BookFilter{Search: `"clean code" -martin`, SearchMode: SearchModeFullText},
+				Page:      1,
+				PageSize:  5,
+				Highlight: true,
+			}).
+			Return(&[]BookDTO{{
+				Id:        uuid.NewString(),
+				Title:     "Clean Code",
+				Highlight: &BookHighlight{Title: "<mark>Clean</mark> <mark>Code</mark>", Author: "Robert C. Martin"},
+			}}, 1, nil)
 
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", id), nil)
-		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		query := url.Values{"search": {`"clean code" -martin`}, "highlight": {"true"}}
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?"+query.Encode(), nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		res, err := server.Test(req, -1)
-		assert.NoError(t, err)
-		assert.Equal(t, fiber.StatusOK, res.StatusCode)
-		assert.Equal(t, fiber.MIMEApplicationJSON, res.Header.Get(fiber.HeaderContentType))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"highlight":{"title":"<mark>Clean</mark> <mark>Code</mark>"`)
 	})
 
-	t.Run("invalid book id", func(t *testing.T) {
+	t.Run("genre and tag with facets", func(t *testing.T) {
+		filter := BookFilter{Genre: "fantasy", Tag: "Space Opera"}
+		facets := &BookFacets{
+			Genres:  []FacetCount{{Value: "fantasy", Label: "Fantasy", Count: 2}, {Value: "epic-fantasy", Label: "Epic Fantasy", Count: 1}},
+			Tags:    []FacetCount{{Value: "space opera", Count: 2}},
+			Authors: []FacetCount{{Value: "Frank Herbert", Count: 2}},
+			Decades: []FacetCount{{Value: "1960", Count: 1}, {Value: "1980", Count: 1}},
+		}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetBooks(gomock.Any(), ListBooksQuery{Filter: filter, Page: 1, PageSize: 5}).
+			Return(&[]BookDTO{{Id: uuid.NewString(), Title: "Dune"}}, 1, nil)
+		mockRepository.EXPECT().GetBookFacets(gomock.Any(), filter).Return(facets, nil)
+
 		server, validate, tracer := setupServer()
-		h := NewHandler(server, validate, tracer, nil)
+		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req := httptest.NewRequest(http.MethodGet, "/book/123", nil)
-		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?genre=fantasy&tag=Space+Opera&facets=true", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		res, err := server.Test(req, -1)
-		assert.NoError(t, err)
-		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+		var body struct {
+			Facets *BookFacets `json:"facets"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, facets, body.Facets)
 	})
 
-	t.Run("repository error", func(t *testing.T) {
+	t.Run("facets of a cursor page", func(t *testing.T) {
+		filter := BookFilter{Tag: "classic"}
 		mockRepository := NewMockRepository(mockController)
-		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(nil, fiber.NewError(fiber.StatusInternalServerError, "repository error"))
+		mockRepository.
+			EXPECT().
+			GetBooksByCursor(gomock.Any(), CursorPageRequest{Filter: filter, Limit: 10}).
+			Return(&CursorPage{}, nil)
+		mockRepository.
+			EXPECT().
+			GetBookFacets(gomock.Any(), filter).
+			Return(&BookFacets{Genres: []FacetCount{}, Tags: []FacetCount{}, Authors: []FacetCount{}, Decades: []FacetCount{}}, nil)
 
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
-		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?tag=classic&limit=10&facets=true", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		res, err := server.Test(req, -1)
-		assert.NoError(t, err)
-		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+		var page GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+		require.NotNil(t, page.Facets)
+		assert.Empty(t, page.Facets.Genres)
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		for _, query := range []string{
+			"searchMode=regex",
+			"sort=coverUrl",
+			"sort=title,title",
+			"sort=title&limit=5",
+			"yearFrom=2010&yearTo=2000",
+			"createdAfter=yesterday",
+		} {
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?"+query, nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		}
 	})
 }
 
-func TestHandler_UpdateBookById(t *testing.T) {
+func TestHandler_GetBooksByCursor(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
 
+	now := time.Now().UTC()
+	books := []BookDTO{
+		{
+			Id:              uuid.NewString(),
+			Title:           "Clean Code",
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+			CreatedAt:       &now,
+		},
+	}
+	signer := cursor.NewSigner("secret")
+
 	t.Run("happy path", func(t *testing.T) {
+		totalCount := 3
+		next := &BookCursor{CreatedAt: now.UnixMicro(), Id: books[0].Id}
 		mockRepository := NewMockRepository(mockController)
 		mockRepository.
 			EXPECT().
-			UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any()).
-			Return(nil).
-			Times(3)
+			GetBooksByCursor(gomock.Any(), CursorPageRequest{Limit: 1, IncludeTotal: true}).
+			Return(&CursorPage{Books: books, Next: next, TotalCount: &totalCount}, nil)
+		mockRepository.
+			EXPECT().
+			GetBooksByCursor(gomock.Any(), CursorPageRequest{Limit: defaultCursorLimit, Cursor: next}).
+			Return(&CursorPage{}, nil)
 
 		server, validate, tracer := setupServer()
-		h := NewHandler(server, validate, tracer, mockRepository)
+		h := NewHandler(server, validate, tracer, mockRepository, WithCursorSigner(signer))
 		h.RegisterHandlers()
 
-		requestBody := []CreateBookRequest{
-			{
-				CoverUrl:        "https://img.com/cover-1.jpg",
-				Title:           "Clean Code",
-				ISBN:            "9780132350884",
-				Author:          "Robert C. Martin",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-2.jpg",
-				Title:           "Refactoring",
-				ISBN:            "9780132350884",
-				Author:          "Martin Fowler",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-3.jpg",
-				Title:           "Test-Driven Development",
-				ISBN:            "9780132350884",
-				Author:          "Kent Beck",
-				PublicationYear: "2008",
-			},
-		}
+		req := httptest.NewRequest(http.MethodGet, "/books?limit=1&includeTotal=true", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		for _, body := range requestBody {
-			marshalledReqBody, err := json.Marshal(body)
-			assert.NoError(t, err)
+		var firstPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&firstPage))
+		assert.Len(t, firstPage.Books, 1)
+		assert.NotEmpty(t, firstPage.NextCursor)
+		assert.Empty(t, firstPage.PrevCursor)
+		assert.Equal(t, &totalCount, firstPage.TotalCount)
 
-			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
-			assert.NoError(t, err)
+		req = httptest.NewRequest(http.MethodGet, "/books?cursor="+url.QueryEscape(firstPage.NextCursor), nil)
+		res, err = server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
-			req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		var secondPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&secondPage))
+		assert.Empty(t, secondPage.Books)
+		assert.Empty(t, secondPage.NextCursor)
+		assert.Nil(t, secondPage.TotalCount)
+	})
 
-			res, err := server.Test(req, -1)
-			assert.NoError(t, err)
-			assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	t.Run("invalid cursor", func(t *testing.T) {
+		position := BookCursor{CreatedAt: now.UnixMicro(), Id: books[0].Id}
+		forged, err := cursor.NewSigner("other").Encode(cursor.Scope{Kind: "books", Query: BookFilter{}}, position)
+		require.NoError(t, err)
+		// a cursor of one search does not page through another
+		otherSearch, err := signer.Encode(cursor.Scope{Kind: "books", Query: BookFilter{Search: "clean", SearchMode: SearchModeFullText}}, position)
+		require.NoError(t, err)
+		otherList, err := signer.Encode(cursor.Scope{Kind: "books.deleted"}, DeletedBookCursor{})
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		for _, query := range []string{
+			"cursor=" + url.QueryEscape(forged),
+			"search=code&cursor=" + url.QueryEscape(otherSearch),
+			"cursor=" + url.QueryEscape(otherSearch),
+			"cursor=" + url.QueryEscape(otherList),
+			"cursor=garbage",
+			"limit=1000",
+			"cursor=abc&page=2",
+			"cursor=abc&pageSize=10",
+			"limit=5&pageSize=10",
+		} {
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?"+query, nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
 		}
 	})
 
-	t.Run("invalid request body", func(t *testing.T) {
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetBooksByCursor(gomock.Any(), gomock.Any()).
+			Return(nil, ErrRepositoryUnavailable)
+
 		server, validate, tracer := setupServer()
-		h := NewHandler(server, validate, tracer, nil)
+		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		requestBody := []CreateBookRequest{
-			{},
-			{
-				CoverUrl:        "invalid-url",
-				Title:           "Clean Code",
-				ISBN:            "9780132350884",
-				Author:          "Robert C. Martin",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-2.jpg",
-				Title:           "",
-				ISBN:            "9780132350884",
-				Author:          "Martin Fowler",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-3.jpg",
-				Title:           "Test-Driven Development",
-				ISBN:            "invalid-isbn",
-				Author:          "",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-3.jpg",
-				Title:           "Test-Driven Development",
-				ISBN:            "9780132350884",
-				Author:          "",
-				PublicationYear: "2008",
-			}, {
-				CoverUrl:        "https://img.com/cover-3.jpg",
-				Title:           "Test-Driven Development",
-				ISBN:            "9780132350884",
-				Author:          "Martin Fowler",
-				PublicationYear: "invalid-pub-year",
-			},
-		}
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books?limit=5", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+}
 
-		for _, body := range requestBody {
-			marshalledReqBody, err := json.Marshal(body)
-			assert.NoError(t, err)
+func TestHandler_GetBookByISBN(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
 
-			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
-			assert.NoError(t, err)
+	t.Run("isbn-10 finds the isbn-13 book", func(t *testing.T) {
+		isbn13 := "9780061120084"
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookByISBN(gomock.Any(), isbn13).Return(&BookDTO{
+			Id:      uuid.NewString(),
+			ISBN:    "978-0-06-112008-4",
+			ISBN13:  &isbn13,
+			Version: 3,
+		}, nil)
 
-			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
-			req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
 
-			res, err := server.Test(req, -1)
-			assert.NoError(t, err)
-			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/book/isbn/0-06-112008-1", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, `"3"`, res.Header.Get(fiber.HeaderETag))
+
+		var body struct {
+			Book BookDTO `json:"book"`
 		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		require.NotNil(t, body.Book.ISBN13)
+		assert.Equal(t, isbn13, *body.Book.ISBN13)
 	})
 
-	t.Run("repository error", func(t *testing.T) {
+	t.Run("invalid checksum", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/book/isbn/9780061120085", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, "INVALID_ISBN", problem.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
-		mockRepository.EXPECT().UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any()).Return(fiber.NewError(fiber.StatusInternalServerError, "repository error"))
+		mockRepository.EXPECT().GetBookByISBN(gomock.Any(), "9780132350884").Return(nil, ErrBookNotFound)
 
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		requestBody := CreateBookRequest{
-			Title:           "Clean Code",
-			CoverUrl:        "https://img.com/cover.jpg",
-			ISBN:            "9780132350884",
-			Author:          "Robert C. Martin",
-			PublicationYear: "2008",
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/book/isbn/978-0-13-235088-4", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_EnrichBook(t *testing.T) {
+	enrich := func(t *testing.T, h *Handler, server *fiber.App, body string) *http.Response {
+		h.RegisterHandlers()
+		req := httptest.NewRequest(http.MethodPost, "/book/enrich", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("prefills a create request", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithMetadataProvider(testMetadataProvider(t)))
+
+		res := enrich(t, h, server, `{"isbn":"0-06-112008-1"}`)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var body struct {
+			Book     CreateBookRequest   `json:"book"`
+			Metadata enrichment.Metadata `json:"metadata"`
 		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, CreateBookRequest{
+			CoverUrl:        "https://covers.example/mockingbird.jpg",
+			ISBN:            "9780061120084",
+			Title:           "To Kill a Mockingbird",
+			Author:          "Harper Lee",
+			PublicationYear: "2006",
+		}, body.Book)
+		assert.Equal(t, []string{"Harper Perennial"}, body.Metadata.Publishers)
+		assert.NoError(t, validate.Struct(body.Book))
+	})
 
-		marshalledReqBody, err := json.Marshal(requestBody)
-		assert.NoError(t, err)
+	t.Run("unknown isbn", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithMetadataProvider(testMetadataProvider(t)))
 
-		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
-		assert.NoError(t, err)
+		res := enrich(t, h, server, `{"isbn":"9780201485677"}`)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
 
-		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
-		req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+	t.Run("invalid isbn", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithMetadataProvider(testMetadataProvider(t)))
 
-		res, err := server.Test(req, -1)
-		assert.NoError(t, err)
-		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+		res := enrich(t, h, server, `{"isbn":"9780061120085"}`)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("no provider configured", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+
+		res := enrich(t, h, server, `{"isbn":"9780061120084"}`)
+		assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, "ENRICHMENT_DISABLED", problem.Code)
 	})
 }
 
-func TestHandler_DeleteBookById(t *testing.T) {
+func TestHandler_GetBookById(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
 
 	t.Run("happy path", func(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
-		mockRepository.EXPECT().DeleteBookById(gomock.Any(), gomock.Any()).Return(nil)
+
+		id := uuid.NewString()
+		now := time.Now().UTC()
+		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(&BookDTO{
+			Id:              id,
+			Title:           "",
+			Author:          "",
+			PublicationYear: "",
+			Version:         2,
+			CreatedAt:       &now,
+		}, nil)
 
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
-		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", id), nil)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 
 		res, err := server.Test(req, -1)
 		assert.NoError(t, err)
-		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, fiber.MIMEApplicationJSON, res.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `"2"`, res.Header.Get(fiber.HeaderETag))
 	})
 
-	t.Run("invalid request body", func(t *testing.T) {
+	t.Run("not modified", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(&BookDTO{Id: uuid.NewString(), Version: 4}, nil).Times(2)
+
 		server, validate, tracer := setupServer()
-		h := NewHandler(server, validate, tracer, nil)
+		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", "invalid-id"), nil)
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, `"3", W/"4"`)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+		assert.Equal(t, `"4"`, res.Header.Get(fiber.HeaderETag))
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, `"3"`)
+
+		res, err = server.Test(req, -1)
 		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("etag covers author and genre changes", func(t *testing.T) {
+		renamedAt := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(&BookDTO{Id: uuid.NewString(), Version: 4, RelatedUpdatedAt: &renamedAt}, nil).Times(2)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, `"4"`)
 
 		res, err := server.Test(req, -1)
 		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, `"4.1711886400000000"`, res.Header.Get(fiber.HeaderETag))
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, `"4.1711886400000000"`)
+
+		res, err = server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+	})
+
+	t.Run("embeds editions and series", func(t *testing.T) {
+		seriesId := uuid.NewString()
+		position := 4
+		book := &BookDTO{Id: uuid.NewString(), Title: "Mort", WorkId: uuid.NewString(), SeriesId: &seriesId, SeriesPosition: &position, Version: 4}
+		editions := []work.EditionDTO{{Id: uuid.NewString(), ISBN: "0-552-13106-7", Title: "Mort", PublicationYear: "1988"}}
+		series := &BookSeries{Id: seriesId, Name: "Discworld", Entries: []SeriesEntry{{Id: book.Id, WorkId: book.WorkId, Title: "Mort", Position: &position}}}
+
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), book.Id).Return(book, nil)
+		mockRepository.EXPECT().EmbedBook(gomock.Any(), book, BookEmbed{Editions: true, Series: true}).
+			DoAndReturn(func(_ context.Context, book *BookDTO, _ BookEmbed) error {
+				book.Editions, book.Series = editions, series
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		// the ETag of the plain book does not cover what is embedded
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s?embed=editions,series", book.Id), nil)
+		req.Header.Set(fiber.HeaderIfNoneMatch, `"4"`)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, `"4"`, res.Header.Get(fiber.HeaderETag))
+
+		var body struct {
+			Book BookDTO `json:"book"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, editions, body.Book.Editions)
+		assert.Equal(t, series, body.Book.Series)
+		assert.Nil(t, body.Book.Publisher)
+	})
+
+	t.Run("unknown embed", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s?embed=reviews", uuid.NewString()), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
 		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
 
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, "UNKNOWN_EMBED", problem.Code)
+	})
+
+	t.Run("invalid book id", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/book/123", nil)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
 	})
 
 	t.Run("repository error", func(t *testing.T) {
 		mockRepository := NewMockRepository(mockController)
-		mockRepository.EXPECT().DeleteBookById(gomock.Any(), gomock.Any()).Return(fiber.NewError(fiber.StatusInternalServerError, "repository error"))
+		mockRepository.EXPECT().GetBookById(gomock.Any(), gomock.Any()).Return(nil, fiber.NewError(fiber.StatusInternalServerError, "repository error"))
 
 		server, validate, tracer := setupServer()
 		h := NewHandler(server, validate, tracer, mockRepository)
 		h.RegisterHandlers()
 
-		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
-		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 
 		res, err := server.Test(req, -1)
 		assert.NoError(t, err)
@@ -542,12 +1104,1806 @@ func TestHandler_DeleteBookById(t *testing.T) {
 	})
 }
 
-func setupServer() (*fiber.App, *validator.Validate, trace.Tracer) {
-	server := fiber.New(fiber.Config{
-		JSONDecoder:           json.Unmarshal,
-		JSONEncoder:           json.Marshal,
-		DisableStartupMessage: true,
-	})
+func TestHandler_UpdateBookById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			UpdateBookById(gomock.Any(), gomock.Any(), 2, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ int, book *BookDTO) error {
+				book.Version = 3
+				return nil
+			}).
+			Times(3)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		requestBody := []CreateBookRequest{
+			{
+				CoverUrl:        "https://img.com/cover-1.jpg",
+				Title:           "Clean Code",
+				ISBN:            "9780132350884",
+				Author:          "Robert C. Martin",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-2.jpg",
+				Title:           "Refactoring",
+				ISBN:            "9780132350884",
+				Author:          "Martin Fowler",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-3.jpg",
+				Title:           "Test-Driven Development",
+				ISBN:            "9780132350884",
+				Author:          "Kent Beck",
+				PublicationYear: "2008",
+			},
+		}
+
+		for _, body := range requestBody {
+			marshalledReqBody, err := json.Marshal(body)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
+			assert.NoError(t, err)
+
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+
+			res, err := server.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+			assert.Equal(t, `"3"`, res.Header.Get(fiber.HeaderETag))
+		}
+	})
+
+	t.Run("etag covers authors", func(t *testing.T) {
+		renamedAt := time.UnixMicro(1711886400000000)
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			UpdateBookById(gomock.Any(), gomock.Any(), 2, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ int, book *BookDTO) error {
+				book.Version = 3
+				book.RelatedUpdatedAt = &renamedAt
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString(), strings.NewReader(`{"coverUrl":"https://img.com/cover.jpg","isbn":"9780132350884","title":"Clean Code","author":"Robert C. Martin","publicationYear":"2008"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderIfMatch, `"2.1711886300000000"`)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, `"3.1711886400000000"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("isbn forms pkg/isbn accepts", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().UpdateBookById(gomock.Any(), gomock.Any(), 2, gomock.Any()).Return(nil).Times(2)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		for _, form := range []string{"978-0-13-235-088-4", "0-8044-2957-x"} {
+			body := fmt.Sprintf(`{"coverUrl":"https://img.com/cover.jpg","isbn":%q,"title":"Clean Code","author":"Robert C. Martin","publicationYear":"2008"}`, form)
+			req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString(), strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusNoContent, res.StatusCode, form)
+		}
+	})
+
+	t.Run("precondition headers", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().UpdateBookById(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(ErrVersionMismatch.WithMeta("currentVersion", 2))
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		marshalledReqBody, err := json.Marshal(CreateBookRequest{
+			Title:           "Clean Code",
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		})
+		assert.NoError(t, err)
+
+		testCases := []struct {
+			ifMatch        string
+			expectedStatus int
+		}{
+			{ifMatch: "", expectedStatus: fiber.StatusPreconditionRequired},
+			{ifMatch: "1", expectedStatus: fiber.StatusBadRequest},
+			{ifMatch: `W/"1"`, expectedStatus: fiber.StatusPreconditionFailed},
+			{ifMatch: `"1"`, expectedStatus: fiber.StatusPreconditionFailed},
+		}
+
+		for _, testCase := range testCases {
+			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
+			assert.NoError(t, err)
+
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if testCase.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, testCase.ifMatch)
+			}
+
+			res, err := server.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, res.StatusCode, testCase.ifMatch)
+		}
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		requestBody := []CreateBookRequest{
+			{},
+			{
+				CoverUrl:        "invalid-url",
+				Title:           "Clean Code",
+				ISBN:            "9780132350884",
+				Author:          "Robert C. Martin",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-2.jpg",
+				Title:           "",
+				ISBN:            "9780132350884",
+				Author:          "Martin Fowler",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-3.jpg",
+				Title:           "Test-Driven Development",
+				ISBN:            "invalid-isbn",
+				Author:          "",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-3.jpg",
+				Title:           "Test-Driven Development",
+				ISBN:            "9780132350884",
+				Author:          "",
+				PublicationYear: "2008",
+			}, {
+				CoverUrl:        "https://img.com/cover-3.jpg",
+				Title:           "Test-Driven Development",
+				ISBN:            "9780132350884",
+				Author:          "Martin Fowler",
+				PublicationYear: "invalid-pub-year",
+			},
+		}
+
+		for _, body := range requestBody {
+			marshalledReqBody, err := json.Marshal(body)
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
+			assert.NoError(t, err)
+
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+
+			res, err := server.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(fiber.NewError(fiber.StatusInternalServerError, "repository error"))
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		requestBody := CreateBookRequest{
+			Title:           "Clean Code",
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		}
+
+		marshalledReqBody, err := json.Marshal(requestBody)
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/book/%s", uuid.NewString()), strings.NewReader(string(marshalledReqBody)))
+		assert.NoError(t, err)
+
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestHandler_PatchBookById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	bookId := uuid.NewString()
+	authorId := uuid.NewString()
+	publisherId := uuid.NewString()
+	stored := func() *BookDTO {
+		return &BookDTO{
+			Id:              bookId,
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+			Version:         2,
+			WorkId:          uuid.NewString(),
+			PublisherId:     &publisherId,
+			Tags:            []string{"software"},
+			Authors:         []BookAuthor{{Id: authorId, Name: "Robert C. Martin", Role: author.RoleAuthor}},
+		}
+	}
+
+	sendPatch := func(server *fiber.App, contentType, ifMatch, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPatch, "/book/"+bookId, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, contentType)
+		if ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+
+		return res
+	}
+
+	problemOf := func(res *http.Response) apperror.Problem {
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		return problem
+	}
+
+	t.Run("merge patch", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(stored(), nil)
+		mockRepository.
+			EXPECT().
+			PatchBookById(gomock.Any(), bookId, 2, gomock.Any(), []string{"title", "publisherId", "tags"}).
+			DoAndReturn(func(_ context.Context, _ string, _ int, book *BookDTO, _ []string) error {
+				assert.Equal(t, "Clean Code, 2nd Edition", book.Title)
+				assert.Equal(t, "https://img.com/cover.jpg", book.CoverUrl)
+				assert.Nil(t, book.PublisherId)
+				assert.Equal(t, []string{"software", "craft"}, book.Tags)
+				assert.NotNil(t, book.UpdatedAt)
+				book.Version = 3
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendPatch(server, "application/merge-patch+json", `"2"`, `{"title":"Clean Code, 2nd Edition","publisherId":null,"tags":["software","Craft"]}`)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, `"3"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(stored(), nil)
+		mockRepository.
+			EXPECT().
+			PatchBookById(gomock.Any(), bookId, 2, gomock.Any(), []string{"authors", "tags"}).
+			DoAndReturn(func(_ context.Context, _ string, _ int, book *BookDTO, _ []string) error {
+				assert.Equal(t, []BookAuthor{
+					{Id: authorId, Name: "Robert C. Martin", Role: author.RoleAuthor},
+					{Name: "Dean Wampler", Role: author.RoleEditor},
+				}, book.Authors)
+				assert.Equal(t, []string{}, book.Tags)
+				book.Version = 3
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendPatch(server, "application/json-patch+json", `"2"`, `[
+			{"op":"test","path":"/authors/0/id","value":"`+authorId+`"},
+			{"op":"add","path":"/authors/-","value":{"name":"Dean Wampler","role":"editor"}},
+			{"op":"remove","path":"/tags/0"}
+		]`)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, `"3"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("patch without changes writes nothing", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(stored(), nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendPatch(server, "application/merge-patch+json", `"2"`, `{"title":"Clean Code","tags":["Software"]}`)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, `"2"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("if-match with an etag covering authors", func(t *testing.T) {
+		renamedAt := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+		book := stored()
+		book.RelatedUpdatedAt = &renamedAt
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(book, nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendPatch(server, "application/merge-patch+json", `"2.1711886400000000"`, `{"title":"Clean Code"}`)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+		assert.Equal(t, `"2.1711886400000000"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("rejected patches", func(t *testing.T) {
+		testCases := []struct {
+			name           string
+			contentType    string
+			body           string
+			expectedStatus int
+			expectedCode   string
+		}{
+			{name: "failed test", contentType: "application/json-patch+json", body: `[{"op":"test","path":"/title","value":"Refactoring"}]`, expectedStatus: fiber.StatusConflict, expectedCode: "PATCH_TEST_FAILED"},
+			{name: "missing path", contentType: "application/json-patch+json", body: `[{"op":"replace","path":"/subtitle","value":"x"}]`, expectedStatus: fiber.StatusBadRequest, expectedCode: "INVALID_PATCH"},
+			{name: "unknown field", contentType: "application/merge-patch+json", body: `{"subtitle":"x"}`, expectedStatus: fiber.StatusBadRequest, expectedCode: "INVALID_PATCH"},
+			{name: "wrong type", contentType: "application/merge-patch+json", body: `{"seriesPosition":"first"}`, expectedStatus: fiber.StatusBadRequest, expectedCode: "INVALID_PATCH"},
+			{name: "invalid result", contentType: "application/merge-patch+json", body: `{"title":null,"coverUrl":"not a url"}`, expectedStatus: fiber.StatusBadRequest, expectedCode: "VALIDATION_FAILED"},
+			{name: "changed id", contentType: "application/merge-patch+json", body: `{"id":"` + uuid.NewString() + `"}`, expectedStatus: fiber.StatusBadRequest, expectedCode: "IMMUTABLE_BOOK_ID"},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				mockRepository := NewMockRepository(mockController)
+				mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(stored(), nil)
+
+				server, validate, tracer := setupServer()
+				NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+				res := sendPatch(server, testCase.contentType, `"2"`, testCase.body)
+				assert.Equal(t, testCase.expectedStatus, res.StatusCode)
+				assert.Equal(t, testCase.expectedCode, problemOf(res).Code)
+			})
+		}
+	})
+
+	t.Run("preconditions", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(stored(), nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendPatch(server, fiber.MIMEApplicationJSON, `"2"`, `{"title":"x"}`)
+		assert.Equal(t, fiber.StatusUnsupportedMediaType, res.StatusCode)
+
+		res = sendPatch(server, "application/merge-patch+json", "", `{"title":"x"}`)
+		assert.Equal(t, fiber.StatusPreconditionRequired, res.StatusCode)
+
+		res = sendPatch(server, "application/merge-patch+json", `"1"`, `{"title":"x"}`)
+		assert.Equal(t, fiber.StatusPreconditionFailed, res.StatusCode)
+		assert.Equal(t, float64(2), problemOf(res).Meta["currentVersion"])
+	})
+}
+
+func TestHandler_DeleteBookById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeleteBookById(gomock.Any(), gomock.Any(), 1).Return(nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", "invalid-id"), nil)
+		assert.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+	})
+
+	t.Run("hard delete", func(t *testing.T) {
+		bookId := uuid.NewString()
+		cover := BookCover{BookId: bookId, Digest: "digest", Sizes: []string{"small"}}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().PurgeBookById(gomock.Any(), bookId, 1).Return([]BookCover{cover}, nil)
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		for _, size := range []string{originalCover, "small"} {
+			require.NoError(t, store.Put(context.TODO(), coverKey(bookId, cover.Digest, size), strings.NewReader("image"), 5, "image/png"))
+		}
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0))
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s?hard=true", bookId), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+
+		for _, size := range []string{originalCover, "small"} {
+			_, err = store.Get(context.TODO(), coverKey(bookId, cover.Digest, size))
+			assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		}
+	})
+
+	t.Run("if-match any version", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeleteBookById(gomock.Any(), gomock.Any(), anyVersion).Return(nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, "*")
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("missing if-match", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusPreconditionRequired, res.StatusCode)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeleteBookById(gomock.Any(), gomock.Any(), gomock.Any()).Return(fiber.NewError(fiber.StatusInternalServerError, "repository error"))
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestHandler_GetDeletedBooks(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	deletedAt := time.Now().UTC()
+	signer := cursor.NewSigner("secret")
+
+	t.Run("happy path", func(t *testing.T) {
+		book := BookDTO{Id: uuid.NewString(), Title: "Clean Code", Version: 2, DeletedAt: &deletedAt}
+		next := &DeletedBookCursor{DeletedAt: deletedAt.UnixMicro(), Id: book.Id}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetDeletedBooks(gomock.Any(), DeletedPageRequest{Limit: 1}).
+			Return(&DeletedPage{Books: []BookDTO{book}, Next: next}, nil)
+		mockRepository.
+			EXPECT().
+			GetDeletedBooks(gomock.Any(), DeletedPageRequest{Limit: defaultCursorLimit, Cursor: next}).
+			Return(&DeletedPage{}, nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/deleted?limit=1", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var firstPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&firstPage))
+		assert.Len(t, firstPage.Books, 1)
+		assert.NotEmpty(t, firstPage.NextCursor)
+
+		req = httptest.NewRequest(http.MethodGet, "/books/deleted?cursor="+url.QueryEscape(firstPage.NextCursor), nil)
+		res, err = server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var secondPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&secondPage))
+		assert.Empty(t, secondPage.Books)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/deleted?limit=1000", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_GetFailingCovers(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	failingSince := time.Now().UTC()
+	signer := cursor.NewSigner("secret")
+
+	t.Run("happy path", func(t *testing.T) {
+		statusCode := http.StatusNotFound
+		check := CoverCheck{BookId: uuid.NewString(), Title: "Mort", CoverUrl: "https://img.com/mort.jpg", Outcome: CoverHTTPError, StatusCode: &statusCode, Failures: 3, FailingSince: &failingSince}
+		next := &FailingCoverCursor{FailingSince: failingSince.UnixMicro(), Id: check.BookId}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetFailingCovers(gomock.Any(), FailingCoversPageRequest{Limit: 1}).
+			Return(&FailingCoversPage{Covers: []CoverCheck{check}, Next: next}, nil)
+		mockRepository.EXPECT().GetFailingCovers(gomock.Any(), FailingCoversPageRequest{Limit: defaultCursorLimit, Cursor: next}).
+			Return(&FailingCoversPage{}, nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCursorSigner(signer)).RegisterHandlers()
+
+		res, err := server.Test(httptest.NewRequest(http.MethodGet, "/books/covers/failing?limit=1", nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var firstPage GetFailingCoversResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&firstPage))
+		require.Len(t, firstPage.Covers, 1)
+		assert.Equal(t, CoverHTTPError, firstPage.Covers[0].Outcome)
+		assert.Equal(t, 3, firstPage.Covers[0].Failures)
+		require.NotEmpty(t, firstPage.NextCursor)
+
+		res, err = server.Test(httptest.NewRequest(http.MethodGet, "/books/covers/failing?cursor="+url.QueryEscape(firstPage.NextCursor), nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var secondPage GetFailingCoversResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&secondPage))
+		assert.Equal(t, []CoverCheck{}, secondPage.Covers)
+	})
+
+	t.Run("editor cannot list", func(t *testing.T) {
+		authorizer := auth.NewAuthorizer(auth.NewAPIKeyAuthenticator([]auth.APIKey{
+			{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+		}))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/covers/failing", nil)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
+
+func TestHandler_RestoreBookById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		bookId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RestoreBookById(gomock.Any(), bookId, 2).Return(&BookDTO{Id: bookId, Version: 3}, nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/book/%s/restore", bookId), nil)
+		req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `"3"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("missing if-match", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/book/%s/restore", uuid.NewString()), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusPreconditionRequired, res.StatusCode)
+	})
+
+	t.Run("not in trash", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().RestoreBookById(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, ErrBookNotFound)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/book/%s/restore", uuid.NewString()), nil)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_BulkBooks(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	validBook := map[string]any{
+		"coverUrl":        "https://img.com/cover.jpg",
+		"isbn":            "9780132350884",
+		"title":           "Clean Code",
+		"author":          "Robert C. Martin",
+		"publicationYear": "2008",
+	}
+	withFields := func(fields map[string]any) map[string]any {
+		merged := make(map[string]any, len(validBook)+len(fields))
+		for key, value := range validBook {
+			merged[key] = value
+		}
+		for key, value := range fields {
+			merged[key] = value
+		}
+		return merged
+	}
+
+	send := func(server *fiber.App, query string, body any, headers ...string) *http.Response {
+		marshalledReqBody, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/books/bulk"+query, strings.NewReader(string(marshalledReqBody)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("plain array of books", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			BulkWrite(gomock.Any(), gomock.Len(2), true).
+			DoAndReturn(func(_ context.Context, writes []BulkWrite, _ bool) ([]error, error) {
+				for _, write := range writes {
+					assert.Equal(t, BulkCreate, write.Op)
+					assert.NotEmpty(t, write.Book.Id)
+					write.Book.Version = 1
+				}
+				return make([]error, len(writes)), nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		res := send(server, "", []map[string]any{validBook, validBook})
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var response BulkBooksResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, 2, response.Succeeded)
+		assert.Equal(t, fiber.StatusCreated, response.Results[1].Status)
+		assert.Equal(t, 1, response.Results[1].Version)
+	})
+
+	t.Run("best effort reports every item", func(t *testing.T) {
+		updateId, deleteId := uuid.NewString(), uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			BulkWrite(gomock.Any(), gomock.Len(3), false).
+			DoAndReturn(func(_ context.Context, writes []BulkWrite, _ bool) ([]error, error) {
+				assert.Equal(t, BulkUpdate, writes[1].Op)
+				assert.Equal(t, 4, writes[1].ExpectedVersion)
+				return []error{nil, ErrVersionMismatch, nil}, nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		res := send(server, "?mode=bestEffort", []map[string]any{
+			validBook,
+			withFields(map[string]any{"title": ""}),
+			withFields(map[string]any{"op": "update", "id": updateId, "version": 4}),
+			{"op": "delete", "id": deleteId, "version": 1},
+		})
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var response BulkBooksResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, 2, response.Succeeded)
+		assert.Equal(t, 2, response.Failed)
+		assert.Equal(t, fiber.StatusBadRequest, response.Results[1].Status)
+		assert.Equal(t, "title", response.Results[1].Error.Errors[0].Field)
+		assert.Equal(t, fiber.StatusPreconditionFailed, response.Results[2].Status)
+		assert.Equal(t, updateId, response.Results[2].Id)
+		assert.Equal(t, fiber.StatusNoContent, response.Results[3].Status)
+	})
+
+	t.Run("atomic rejects the whole request on invalid items", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		res := send(server, "?mode=atomic", []map[string]any{
+			validBook,
+			withFields(map[string]any{"isbn": "invalid"}),
+			{"op": "delete", "id": uuid.NewString()},
+			{"op": "archive"},
+		})
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		fields := make([]string, 0, len(problem.Errors))
+		for _, field := range problem.Errors {
+			fields = append(fields, field.Field)
+		}
+		assert.Equal(t, []string{"[1].isbn", "[2].version", "[3].op"}, fields)
+	})
+
+	t.Run("atomic failure names the item", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			BulkWrite(gomock.Any(), gomock.Any(), true).
+			Return([]error{nil, ErrBookAlreadyExists}, ErrBookAlreadyExists)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		res := send(server, "", []map[string]any{validBook, validBook})
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.EqualValues(t, 1, problem.Meta["index"])
+	})
+
+	t.Run("deletes need an admin", func(t *testing.T) {
+		authorizer := auth.NewAuthorizer(auth.NewAPIKeyAuthenticator([]auth.APIKey{
+			{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+		}))
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer))
+		h.RegisterHandlers()
+
+		res := send(server, "", []map[string]any{{"op": "delete", "id": uuid.NewString(), "version": 1}}, auth.HeaderAPIKey, "editor-key")
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		assert.Equal(t, fiber.StatusBadRequest, send(server, "", []map[string]any{}).StatusCode)
+		assert.Equal(t, fiber.StatusBadRequest, send(server, "?mode=eventually", []map[string]any{validBook}).StatusCode)
+		assert.Equal(t, fiber.StatusBadRequest, send(server, "", make([]map[string]any, maxBulkOperations+1)).StatusCode)
+		assert.Equal(t, fiber.StatusBadRequest, send(server, "", validBook).StatusCode)
+	})
+}
+
+func TestHandler_GetBookHistory(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	bookId := uuid.NewString()
+	signer := cursor.NewSigner("secret")
+
+	t.Run("happy path", func(t *testing.T) {
+		next := &HistoryCursor{BookId: bookId, Id: 7}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetBookHistory(gomock.Any(), HistoryPageRequest{BookId: bookId, Limit: 1}).
+			Return(&HistoryPage{Events: []BookEvent{{Id: 7, BookId: bookId, Type: EventUpdated, Version: 2}}, Next: next}, nil)
+		mockRepository.
+			EXPECT().
+			GetBookHistory(gomock.Any(), HistoryPageRequest{BookId: bookId, Limit: defaultCursorLimit, Cursor: next}).
+			Return(&HistoryPage{}, nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s/history?limit=1", bookId), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var firstPage GetBookHistoryResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&firstPage))
+		assert.Len(t, firstPage.Events, 1)
+		assert.NotEmpty(t, firstPage.NextCursor)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s/history?cursor=%s", bookId, url.QueryEscape(firstPage.NextCursor)), nil)
+		res, err = server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var secondPage GetBookHistoryResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&secondPage))
+		assert.Empty(t, secondPage.Events)
+		assert.Empty(t, secondPage.NextCursor)
+	})
+
+	t.Run("cursor of another book", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		otherBookId := uuid.NewString()
+		token, err := signer.Encode(cursor.Scope{Kind: "books.history", Query: otherBookId}, HistoryCursor{BookId: otherBookId, Id: 3})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s/history?cursor=%s", bookId, url.QueryEscape(token)), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookHistory(gomock.Any(), gomock.Any()).Return(nil, ErrBookNotFound)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s/history", bookId), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_GetAuthorBooks(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authorId := uuid.NewString()
+	signer := cursor.NewSigner("secret")
+
+	t.Run("happy path", func(t *testing.T) {
+		book := BookDTO{Id: uuid.NewString(), Title: "The Hobbit", Authors: []BookAuthor{{Id: authorId, Name: "J.R.R. Tolkien", Role: author.RoleAuthor}}}
+		next := &AuthorBooksCursor{AuthorId: authorId, CreatedAt: 1, Id: book.Id}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			GetAuthorBooks(gomock.Any(), AuthorBooksPageRequest{AuthorId: authorId, Role: author.RoleAuthor, Limit: 1}).
+			Return(&AuthorBooksPage{Books: []BookDTO{book}, Next: next}, nil)
+		mockRepository.
+			EXPECT().
+			GetAuthorBooks(gomock.Any(), AuthorBooksPageRequest{AuthorId: authorId, Role: author.RoleAuthor, Limit: defaultCursorLimit, Cursor: next}).
+			Return(&AuthorBooksPage{}, nil)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/author/%s/books?role=author&limit=1", authorId), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var firstPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&firstPage))
+		require.Len(t, firstPage.Books, 1)
+		assert.Equal(t, book.Authors, firstPage.Books[0].Authors)
+		assert.NotEmpty(t, firstPage.NextCursor)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/author/%s/books?role=author&cursor=%s", authorId, url.QueryEscape(firstPage.NextCursor)), nil)
+		res, err = server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var secondPage GetBooksCursorResponse
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&secondPage))
+		assert.Empty(t, secondPage.Books)
+		assert.Empty(t, secondPage.NextCursor)
+	})
+
+	t.Run("cursor of another author", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithCursorSigner(signer))
+		h.RegisterHandlers()
+
+		otherAuthorId := uuid.NewString()
+		token, err := signer.Encode(cursor.Scope{Kind: "books.author", Query: []string{otherAuthorId, ""}}, AuthorBooksCursor{AuthorId: otherAuthorId, CreatedAt: 1, Id: uuid.NewString()})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/author/%s/books?cursor=%s", authorId, url.QueryEscape(token)), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("invalid author id", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/author/not-a-uuid/books", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("unknown author", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetAuthorBooks(gomock.Any(), gomock.Any()).Return(nil, author.ErrAuthorNotFound)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/author/%s/books", authorId), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_Authorization(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authorizer := auth.NewAuthorizer(auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+		{Name: "admin", Key: "admin-key", Roles: []string{"admin"}},
+	}))
+
+	t.Run("missing credentials", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer))
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get(fiber.HeaderWWWAuthenticate))
+	})
+
+	t.Run("editor cannot delete", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer))
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("editor cannot delete a cover", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer))
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s/cover", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("admin can delete", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			DeleteBookById(gomock.Any(), gomock.Any(), 1).
+			DoAndReturn(func(ctx context.Context, _ string, _ int) error {
+				assert.Equal(t, "admin", auditOf(ctx).Actor)
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithAuthorizer(authorizer))
+		h.RegisterHandlers()
+
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/book/%s", uuid.NewString()), nil)
+		assert.NoError(t, err)
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+		req.Header.Set(auth.HeaderAPIKey, "admin-key")
+
+		res, err := server.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestHandler_ExportBooks(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	book := &BookDTO{
+		Id:              uuid.NewString(),
+		CoverUrl:        "https://img.com/cover.jpg",
+		ISBN:            "9780132350884",
+		Title:           "Clean Code",
+		Author:          "Robert C. Martin",
+		PublicationYear: "2008",
+		Version:         1,
+		CreatedAt:       &createdAt,
+	}
+
+	t.Run("csv with list filters", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query ExportBooksQuery, yield func(*BookDTO) error) error {
+				assert.Equal(t, "Robert C. Martin", query.Filter.Author)
+				assert.Equal(t, 2000, query.Filter.YearFrom)
+				assert.Equal(t, []SortField{{Field: "title"}}, query.Sort)
+				assert.False(t, query.IncludeDeleted)
+				for range exportFlushRows + 1 {
+					if err := yield(book); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?author=Robert%20C.%20Martin&yearFrom=2000&sort=title", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get(fiber.HeaderContentType))
+		assert.Contains(t, res.Header.Get(fiber.HeaderContentDisposition), ".csv")
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		assert.Len(t, lines, exportFlushRows+2)
+		assert.Equal(t, "id,coverUrl,isbn,title,author,publicationYear,version,createdAt,updatedAt,deletedAt", lines[0])
+	})
+
+	t.Run("marcxml", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ ExportBooksQuery, yield func(*BookDTO) error) error {
+				return yield(book)
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=marcxml", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/marcxml+xml", res.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `<controlfield tag="001">`+book.Id+`</controlfield>`)
+		assert.True(t, strings.HasSuffix(string(body), "</collection>"))
+	})
+
+	t.Run("deleted books need an admin", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, query ExportBooksQuery, _ func(*BookDTO) error) error {
+				assert.True(t, query.IncludeDeleted)
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithAuthorizer(auth.NewAuthorizer(
+			auth.NewAPIKeyAuthenticator([]auth.APIKey{
+				{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+				{Name: "admin", Key: "admin-key", Roles: []string{"admin"}},
+			}),
+		)))
+		h.RegisterHandlers()
+
+		for key, status := range map[string]int{"editor-key": fiber.StatusForbidden, "admin-key": fiber.StatusOK} {
+			req := httptest.NewRequest(http.MethodGet, "/books/export?format=ndjson&includeDeleted=true", nil)
+			req.Header.Set(auth.HeaderAPIKey, key)
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, status, res.StatusCode, key)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=xlsx", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("unsortable field", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?sort=coverUrl", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_ImportBooks(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	const csvBooks = "title,author,isbn,publicationYear,coverUrl\n" +
+		"Clean Code,Robert C. Martin,9780132350884,2008,https://img.com/cover.jpg\n" +
+		"Refactoring,,9780134757599,2018,https://img.com/r.jpg\n" +
+		"The Pragmatic Programmer,Andy Hunt,9780135957059,2019,https://img.com/p.jpg\n"
+
+	multipartBody := func(fileName, content string) (string, *bytes.Buffer) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("note", "ignored"))
+		part, err := writer.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		return writer.FormDataContentType(), body
+	}
+
+	send := func(server *fiber.App, query, contentType string, body io.Reader) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/books/import"+query, body)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("csv upload reports invalid rows", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			BulkWrite(gomock.Any(), gomock.Len(2), false).
+			DoAndReturn(func(_ context.Context, writes []BulkWrite, _ bool) ([]error, error) {
+				assert.Equal(t, "Clean Code", writes[0].Book.Title)
+				assert.NotEmpty(t, writes[0].Book.Id)
+				return []error{nil, ErrBookAlreadyExists}, nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		contentType, body := multipartBody("books.csv", csvBooks)
+		res := send(server, "", contentType, body)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var report ImportReport
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&report))
+		assert.Equal(t, 3, report.Rows)
+		assert.Equal(t, 2, report.Valid)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 2, report.Failed)
+		require.Len(t, report.Errors, 2)
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Equal(t, "author", report.Errors[0].Error.Errors[0].Field)
+		assert.Equal(t, 4, report.Errors[1].Line)
+		assert.Equal(t, "BOOK_ALREADY_EXISTS", report.Errors[1].Error.Code)
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, NewMockRepository(mockController))
+		h.RegisterHandlers()
+
+		ndjson := `{"title":"Clean Code","author":"Robert C. Martin","isbn":"9780132350884","publicationYear":"2008","coverUrl":"https://img.com/cover.jpg"}` + "\n" +
+			`{"title":"Clean Code","isbn":"not-an-isbn"}` + "\n"
+		res := send(server, "?dryRun=true", "application/x-ndjson", strings.NewReader(ndjson))
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var report ImportReport
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Valid)
+		assert.Equal(t, 0, report.Imported)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, 2, report.Errors[0].Line)
+	})
+
+	t.Run("streamed body", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().BulkWrite(gomock.Any(), gomock.Len(importBatchSize), false).Return(make([]error, importBatchSize), nil)
+		mockRepository.EXPECT().BulkWrite(gomock.Any(), gomock.Len(1), false).Return(make([]error, 1), nil)
+
+		server := fiber.New(fiber.Config{
+			JSONDecoder:       json.Unmarshal,
+			JSONEncoder:       json.Marshal,
+			ErrorHandler:      apperror.ErrorHandler,
+			StreamRequestBody: true,
+			BodyLimit:         1024,
+		})
+		_, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		upload := strings.Builder{}
+		upload.WriteString("title,author,isbn,publicationYear,coverUrl\n")
+		for range importBatchSize + 1 {
+			upload.WriteString("Clean Code,Robert C. Martin,9780132350884,2008,https://img.com/cover.jpg\n")
+		}
+
+		res := send(server, "", "text/csv", strings.NewReader(upload.String()))
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var report ImportReport
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&report))
+		assert.Equal(t, importBatchSize+1, report.Imported)
+	})
+
+	t.Run("async import is tracked as a job", func(t *testing.T) {
+		imported := make(chan struct{})
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.
+			EXPECT().
+			BulkWrite(gomock.Any(), gomock.Len(2), false).
+			DoAndReturn(func(ctx context.Context, writes []BulkWrite, _ bool) ([]error, error) {
+				defer close(imported)
+				assert.Equal(t, "jane", auditOf(ctx).Actor)
+				return make([]error, len(writes)), nil
+			})
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository, WithAuthorizer(auth.NewAuthorizer(
+			auth.NewAPIKeyAuthenticator([]auth.APIKey{{Name: "jane", Key: "editor-key", Roles: []string{"editor"}}}),
+		)))
+		h.RegisterHandlers()
+
+		contentType, body := multipartBody("books.csv", csvBooks)
+		req := httptest.NewRequest(http.MethodPost, "/books/import?async=true", body)
+		req.Header.Set(fiber.HeaderContentType, contentType)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusAccepted, res.StatusCode)
+
+		var job ImportJob
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&job))
+		assert.Equal(t, "/books/import/"+job.Id, res.Header.Get(fiber.HeaderLocation))
+
+		<-imported
+		require.Eventually(t, func() bool {
+			job, _ := h.imports.get(job.Id)
+			return job.Status == ImportCompleted
+		}, time.Second, 10*time.Millisecond)
+
+		req = httptest.NewRequest(http.MethodGet, "/books/import/"+job.Id, nil)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+		res, err = server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&job))
+		assert.Equal(t, ImportCompleted, job.Status)
+		assert.Equal(t, 2, job.Report.Imported)
+		assert.Equal(t, 1, job.Report.Failed)
+		assert.NotNil(t, job.FinishedAt)
+	})
+
+	t.Run("async import is refused while every slot is taken", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+		for range maxRunningImports {
+			require.True(t, h.imports.reserve())
+		}
+
+		contentType, body := multipartBody("books.csv", csvBooks)
+		res := send(server, "?async=true", contentType, body)
+		assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, importRetryAfter, res.Header.Get(fiber.HeaderRetryAfter))
+
+		h.imports.release()
+		assert.True(t, h.imports.reserve(), "a released slot is free again")
+	})
+
+	t.Run("missing file part", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("note", "no file"))
+		require.NoError(t, writer.Close())
+
+		res := send(server, "", writer.FormDataContentType(), body)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		contentType, body := multipartBody("books.xlsx", "binary")
+		res := send(server, "", contentType, body)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("repository error keeps progress", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().BulkWrite(gomock.Any(), gomock.Any(), false).Return(nil, ErrRepositoryUnavailable)
+
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, mockRepository)
+		h.RegisterHandlers()
+
+		res := send(server, "", "text/csv", strings.NewReader(csvBooks))
+		assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.EqualValues(t, 3, problem.Meta["rows"])
+	})
+}
+
+func TestHandler_GetImportJob(t *testing.T) {
+	t.Run("unknown job", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/import/"+uuid.NewString(), nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		h := NewHandler(server, validate, tracer, nil)
+		h.RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/import/42", nil)
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_PutBookCover(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		bookId := uuid.NewString()
+		previous := &BookCover{BookId: bookId, Digest: "old", Sizes: []string{"small"}}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().SaveBookCover(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, cover *BookCover) (*BookCover, error) {
+			assert.Equal(t, bookId, cover.BookId)
+			assert.Equal(t, "image/png", cover.ContentType)
+			assert.Equal(t, "image/png", cover.ThumbnailType)
+			assert.Equal(t, []string{"large", "medium", "small"}, cover.Sizes)
+			return previous, nil
+		})
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.TODO(), coverKey(bookId, "old", "small"), strings.NewReader("old"), 3, "image/png"))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+bookId+"/cover", bytes.NewReader(testCoverImage(t, 800, 1200)))
+		req.Header.Set(fiber.HeaderContentType, "image/png")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var body struct {
+			Cover BookCover `json:"cover"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, 800, body.Cover.Width)
+		assert.Equal(t, 1200, body.Cover.Height)
+		assert.Len(t, body.Cover.Digest, 64)
+
+		blob, err := store.Get(context.TODO(), coverKey(bookId, body.Cover.Digest, "medium"))
+		require.NoError(t, err)
+		defer blob.Body.Close()
+		thumbnail, _, err := image.DecodeConfig(blob.Body)
+		require.NoError(t, err)
+		assert.Equal(t, 320, thumbnail.Width)
+		assert.Equal(t, 480, thumbnail.Height)
+
+		_, err = store.Get(context.TODO(), coverKey(bookId, "old", "small"))
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("multipart upload of a small image", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().SaveBookCover(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, cover *BookCover) (*BookCover, error) {
+			assert.Equal(t, []string{}, cover.Sizes)
+			return nil, nil
+		})
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		part, err := writer.CreateFormFile("file", "cover.png")
+		require.NoError(t, err)
+		_, err = part.Write(testCoverImage(t, 100, 150))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", &form)
+		req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("form without the file part", func(t *testing.T) {
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithCoverStore(store, 0)).RegisterHandlers()
+
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		require.NoError(t, writer.WriteField("note", "no file"))
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", &form)
+		req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, ErrMissingCoverFile.Code, problem.Code)
+	})
+
+	t.Run("book is gone", func(t *testing.T) {
+		bookId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().SaveBookCover(gomock.Any(), gomock.Any()).Return(nil, ErrBookNotFound)
+
+		dir := t.TempDir()
+		store, err := storage.NewFileSystemStore(dir)
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+bookId+"/cover", bytes.NewReader(testCoverImage(t, 800, 1200)))
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+
+		blobs, err := filepath.Glob(filepath.Join(dir, "covers", bookId, "*", "*"))
+		require.NoError(t, err)
+		assert.Empty(t, blobs)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithCoverStore(store, 1024)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", bytes.NewReader(make([]byte, 2048)))
+		req.Header.Set(fiber.HeaderContentType, "image/jpeg")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("not an image whatever the content type says", func(t *testing.T) {
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithCoverStore(store, 0)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", strings.NewReader("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		req.Header.Set(fiber.HeaderContentType, "image/png")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("truncated image", func(t *testing.T) {
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithCoverStore(store, 0)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", bytes.NewReader(testCoverImage(t, 800, 1200)[:64]))
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("no cover store", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodPut, "/book/"+uuid.NewString()+"/cover", bytes.NewReader(testCoverImage(t, 10, 10)))
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+	})
+}
+
+func TestHandler_GetBookCover(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("thumbnail with etag", func(t *testing.T) {
+		bookId := uuid.NewString()
+		cover := &BookCover{BookId: bookId, Digest: "abc", ContentType: "image/jpeg", ThumbnailType: "image/jpeg", Sizes: []string{"large", "medium", "small"}}
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookCover(gomock.Any(), bookId).Return(cover, nil).Times(2)
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.TODO(), coverKey(bookId, "abc", "small"), strings.NewReader("small"), 5, "image/jpeg"))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		res := sendCoverRequest(t, server, "/book/"+bookId+"/cover?size=small", "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/jpeg", res.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `"abc-small"`, res.Header.Get(fiber.HeaderETag))
+		content, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "small", string(content))
+
+		res = sendCoverRequest(t, server, "/book/"+bookId+"/cover?size=small", `"abc-small"`)
+		assert.Equal(t, fiber.StatusNotModified, res.StatusCode)
+	})
+
+	t.Run("size the original is too small for", func(t *testing.T) {
+		bookId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookCover(gomock.Any(), bookId).
+			Return(&BookCover{BookId: bookId, Digest: "abc", ContentType: "image/gif", ThumbnailType: "image/png", Sizes: []string{"small"}}, nil)
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.TODO(), coverKey(bookId, "abc", "original"), strings.NewReader("GIF89a"), 6, "image/gif"))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		res := sendCoverRequest(t, server, "/book/"+bookId+"/cover?size=large", "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/gif", res.Header.Get(fiber.HeaderContentType))
+		assert.Equal(t, `"abc-original"`, res.Header.Get(fiber.HeaderETag))
+	})
+
+	t.Run("redirects to the cover url without an upload", func(t *testing.T) {
+		bookId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookCover(gomock.Any(), bookId).Return(nil, ErrCoverNotFound)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(&BookDTO{Id: bookId, CoverUrl: "https://img.com/mort.jpg"}, nil)
+
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+		res := sendCoverRequest(t, server, "/book/"+bookId+"/cover", "")
+		assert.Equal(t, fiber.StatusFound, res.StatusCode)
+		assert.Equal(t, "https://img.com/mort.jpg", res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("neither upload nor cover url", func(t *testing.T) {
+		bookId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetBookById(gomock.Any(), bookId).Return(&BookDTO{Id: bookId}, nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := sendCoverRequest(t, server, "/book/"+bookId+"/cover", "")
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil).RegisterHandlers()
+
+		res := sendCoverRequest(t, server, "/book/"+uuid.NewString()+"/cover?size=huge", "")
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_DeleteBookCover(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	bookId := uuid.NewString()
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().DeleteBookCover(gomock.Any(), bookId).Return(&BookCover{BookId: bookId, Digest: "abc", Sizes: []string{"small"}}, nil)
+	mockRepository.EXPECT().DeleteBookCover(gomock.Any(), bookId).Return(nil, ErrCoverNotFound)
+
+	store, err := storage.NewFileSystemStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.TODO(), coverKey(bookId, "abc", "original"), strings.NewReader("x"), 1, "image/jpeg"))
+	require.NoError(t, store.Put(context.TODO(), coverKey(bookId, "abc", "small"), strings.NewReader("x"), 1, "image/jpeg"))
+
+	server, validate, tracer := setupServer()
+	NewHandler(server, validate, tracer, mockRepository, WithCoverStore(store, 0)).RegisterHandlers()
+
+	res, err := server.Test(httptest.NewRequest(http.MethodDelete, "/book/"+bookId+"/cover", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+
+	for _, size := range []string{"original", "small"} {
+		_, err = store.Get(context.TODO(), coverKey(bookId, "abc", size))
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	}
+
+	res, err = server.Test(httptest.NewRequest(http.MethodDelete, "/book/"+bookId+"/cover", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
+
+func sendCoverRequest(t *testing.T, server *fiber.App, target, ifNoneMatch string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set(fiber.HeaderIfNoneMatch, ifNoneMatch)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func testCoverImage(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, img))
+	return encoded.Bytes()
+}
+
+func testMetadataProvider(t *testing.T) enrichment.MetadataProvider {
+	provider, err := enrichment.NewStaticProvider(map[string]*enrichment.Metadata{
+		"9780061120084": {
+			Title:           "To Kill a Mockingbird",
+			Authors:         []string{"Harper Lee"},
+			Publishers:      []string{"Harper Perennial"},
+			PublicationYear: "2006",
+			CoverUrl:        "https://covers.example/mockingbird.jpg",
+		},
+	})
+	require.NoError(t, err)
+
+	return provider
+}
+
+func setupServer() (*fiber.App, *validator.Validate, trace.Tracer) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+	if err := isbn.RegisterValidation(validate); err != nil {
+		panic(err)
+	}
 
-	return server, validator.New(), otel.Tracer("book")
+	return server, validate, otel.Tracer("book")
 }
@@ -0,0 +1,133 @@
+package book
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"book-api/pkg/apperror"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportPending   ImportJobStatus = "pending"
+	ImportRunning   ImportJobStatus = "running"
+	ImportCompleted ImportJobStatus = "completed"
+	ImportFailed    ImportJobStatus = "failed"
+)
+
+const (
+	// finishedImportJobTTL is how long the outcome of an import stays available to poll.
+	finishedImportJobTTL = 24 * time.Hour
+	// maxRunningImports bounds the background imports of an instance, each one holds a spool file on disk
+	maxRunningImports = 4
+)
+
+type ImportJob struct {
+	Id         string            `json:"id"`
+	Status     ImportJobStatus   `json:"status"`
+	Report     ImportReport      `json:"report"`
+	Error      *apperror.Problem `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+}
+
+// ImportJobs tracks the imports running in the background of this instance, jobs live in memory
+// so a restart forgets them and they are only visible on the instance that accepted the upload.
+type ImportJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*ImportJob
+	now     func() time.Time
+	running chan struct{}
+}
+
+func NewImportJobs() *ImportJobs {
+	return &ImportJobs{
+		jobs:    make(map[string]*ImportJob),
+		now:     time.Now,
+		running: make(chan struct{}, maxRunningImports),
+	}
+}
+
+// reserve takes one of the maxRunningImports slots for a background import, false when all are taken.
+// The slot is given back with release once the import finished or failed to start.
+func (s *ImportJobs) reserve() bool {
+	select {
+	case s.running <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *ImportJobs) release() {
+	<-s.running
+}
+
+func (s *ImportJobs) create(dryRun bool) ImportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > finishedImportJobTTL {
+			delete(s.jobs, id)
+		}
+	}
+
+	job := &ImportJob{
+		Id:        uuid.NewString(),
+		Status:    ImportPending,
+		Report:    ImportReport{DryRun: dryRun, Errors: []ImportRowError{}},
+		CreatedAt: now,
+	}
+	s.jobs[job.Id] = job
+
+	return *job
+}
+
+func (s *ImportJobs) get(id string) (ImportJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return ImportJob{}, false
+	}
+
+	return *job, true
+}
+
+// progress stores a copy of the report, the import keeps appending to its own error list.
+func (s *ImportJobs) progress(id string, report ImportReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		report.Errors = slices.Clone(report.Errors)
+		job.Status = ImportRunning
+		job.Report = report
+	}
+}
+
+func (s *ImportJobs) finish(id string, report ImportReport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := s.now().UTC()
+	job.Report = report
+	job.FinishedAt = &now
+	job.Status = ImportCompleted
+	if err != nil {
+		job.Status = ImportFailed
+		job.Error = apperror.NewProblem(err)
+	}
+}
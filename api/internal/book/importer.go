@@ -0,0 +1,324 @@
+package book
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/google/uuid"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/bodylimit"
+)
+
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+const (
+	importBatchSize    = 500
+	maxImportErrors    = 1000
+	maxImportLineBytes = 1 << 20
+)
+
+var importMediaTypes = map[string]ImportFormat{
+	"text/csv":             ImportCSV,
+	"application/csv":      ImportCSV,
+	"application/x-ndjson": ImportNDJSON,
+	"application/ndjson":   ImportNDJSON,
+	"application/jsonl":    ImportNDJSON,
+}
+
+var importExtensions = map[string]ImportFormat{
+	".csv":    ImportCSV,
+	".ndjson": ImportNDJSON,
+	".jsonl":  ImportNDJSON,
+}
+
+// importColumns maps a normalized CSV header onto the CreateBookRequest field it fills,
+// spreadsheets rarely use our json names so the common spellings are accepted too. Tags are separated by semicolons.
+var importColumns = map[string]func(*CreateBookRequest, string){
+	"id":              func(r *CreateBookRequest, v string) { r.Id = v },
+	"coverurl":        func(r *CreateBookRequest, v string) { r.CoverUrl = v },
+	"cover":           func(r *CreateBookRequest, v string) { r.CoverUrl = v },
+	"isbn":            func(r *CreateBookRequest, v string) { r.ISBN = v },
+	"isbn13":          func(r *CreateBookRequest, v string) { r.ISBN = v },
+	"title":           func(r *CreateBookRequest, v string) { r.Title = v },
+	"author":          func(r *CreateBookRequest, v string) { r.Author = v },
+	"publicationyear": func(r *CreateBookRequest, v string) { r.PublicationYear = v },
+	"year":            func(r *CreateBookRequest, v string) { r.PublicationYear = v },
+	"tags":            func(r *CreateBookRequest, v string) { r.Tags = strings.Split(v, ";") },
+}
+
+// importFormatOf picks the format from the explicit query first, then the media type and finally the file name,
+// browsers send most uploads as application/octet-stream.
+func importFormatOf(mediaType, fileName, format string) (ImportFormat, error) {
+	if format != "" {
+		return ImportFormat(format), nil
+	}
+
+	if mediaType != "" {
+		if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+			if format, ok := importMediaTypes[parsed]; ok {
+				return format, nil
+			}
+		}
+	}
+
+	if format, ok := importExtensions[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return format, nil
+	}
+
+	return "", ErrUnsupportedImportFormat
+}
+
+// importRow is one decoded record, Err is set when the record itself could not be read
+// so the import reports it and carries on with the next one.
+type importRow struct {
+	Line int
+	Book CreateBookRequest
+	Err  error
+}
+
+type rowReader interface {
+	// Next returns io.EOF once the input is exhausted, any other error aborts the import.
+	Next() (importRow, error)
+}
+
+func newRowReader(r io.Reader, format ImportFormat) (rowReader, error) {
+	switch format {
+	case ImportCSV:
+		return newCSVRows(r)
+	case ImportNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineBytes)
+		return &ndjsonRows{scanner: scanner}, nil
+	}
+
+	return nil, ErrUnsupportedImportFormat
+}
+
+type csvRows struct {
+	reader  *csv.Reader
+	columns []func(*CreateBookRequest, string)
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImportHeader.WithCause(err)
+	}
+
+	rows := &csvRows{reader: reader, columns: make([]func(*CreateBookRequest, string), len(header))}
+	known := 0
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		if set, ok := importColumns[normalizeColumn(name)]; ok {
+			rows.columns[i] = set
+			known++
+		}
+	}
+
+	if known == 0 {
+		return nil, ErrInvalidImportHeader.WithMeta("header", header)
+	}
+
+	return rows, nil
+}
+
+func normalizeColumn(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+func (c *csvRows) Next() (importRow, error) {
+	record, err := c.reader.Read()
+
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		return importRow{Line: parseError.StartLine, Err: ErrMalformedImportRow.WithCause(err)}, nil
+	}
+
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := importRow{Line: line}
+	for i, value := range record {
+		if i < len(c.columns) && c.columns[i] != nil {
+			c.columns[i](&row.Book, strings.TrimSpace(value))
+		}
+	}
+
+	return row, nil
+}
+
+type ndjsonRows struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonRows) Next() (importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		line := n.scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		row := importRow{Line: n.line}
+		if err := json.Unmarshal(line, &row.Book); err != nil {
+			row.Err = ErrMalformedImportRow.WithCause(err)
+		}
+
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, ErrImportLineTooLong.WithMeta("line", n.line+1).WithMeta("maxBytes", maxImportLineBytes)
+		}
+		return importRow{}, err
+	}
+
+	return importRow{}, io.EOF
+}
+
+type ImportRowError struct {
+	Line  int               `json:"line"`
+	Error *apperror.Problem `json:"error"`
+}
+
+// ImportReport counts every row read, only the first maxImportErrors failures are listed in Errors.
+type ImportReport struct {
+	DryRun    bool             `json:"dryRun"`
+	Rows      int              `json:"rows"`
+	Valid     int              `json:"valid"`
+	Imported  int              `json:"imported"`
+	Failed    int              `json:"failed"`
+	Errors    []ImportRowError `json:"errors"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+func (r *ImportReport) fail(line int, err error) {
+	r.Failed++
+	if len(r.Errors) >= maxImportErrors {
+		r.Truncated = true
+		return
+	}
+
+	r.Errors = append(r.Errors, ImportRowError{Line: line, Error: apperror.NewProblem(err)})
+}
+
+// importBooks validates every row like POST /book does and writes the valid ones in best effort batches,
+// so one bad or duplicate row never costs the rows around it. progress is called after every batch.
+func (h *Handler) importBooks(ctx context.Context, rows rowReader, dryRun bool, progress func(ImportReport)) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Errors: []ImportRowError{}}
+	batch := make([]BulkWrite, 0, importBatchSize)
+	lines := make([]int, 0, importBatchSize)
+
+	flush := func() error {
+		if len(batch) > 0 {
+			errs, err := h.repository.BulkWrite(ctx, batch, false)
+			if err != nil {
+				return err
+			}
+
+			for i, err := range errs {
+				if err != nil {
+					report.fail(lines[i], err)
+				} else {
+					report.Imported++
+				}
+			}
+			batch, lines = batch[:0], lines[:0]
+		}
+
+		if progress != nil {
+			progress(report)
+		}
+		return nil
+	}
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if errors.Is(err, bodylimit.ErrTooLarge) {
+			return report, ErrImportTooLarge.WithMeta("maxBytes", maxImportBytes)
+		}
+		if err != nil {
+			var appError *apperror.Error
+			if !errors.As(err, &appError) {
+				err = apperror.ErrInvalidBody.WithCause(err)
+			}
+			return report, err
+		}
+
+		report.Rows++
+		if row.Err != nil {
+			report.fail(row.Line, row.Err)
+			continue
+		}
+
+		if err = h.validator.StructCtx(ctx, &row.Book); err != nil {
+			report.fail(row.Line, apperror.FromValidation(err))
+			continue
+		}
+
+		report.Valid++
+		if !dryRun {
+			if row.Book.Id == "" {
+				row.Book.Id = uuid.NewString()
+			}
+
+			book := &BookDTO{
+				Id:              row.Book.Id,
+				CoverUrl:        row.Book.CoverUrl,
+				ISBN:            row.Book.ISBN,
+				Title:           row.Book.Title,
+				Author:          row.Book.Author,
+				PublicationYear: row.Book.PublicationYear,
+				Authors:         row.Book.Authors,
+			}
+			row.Book.applyEdition(book)
+			row.Book.applyClassification(book)
+
+			batch = append(batch, BulkWrite{Op: BulkCreate, Book: book})
+			lines = append(lines, row.Line)
+		}
+
+		if report.Valid%importBatchSize == 0 {
+			if err = flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, flush()
+}
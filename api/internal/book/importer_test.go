@@ -0,0 +1,144 @@
+package book
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFormatOf(t *testing.T) {
+	cases := []struct {
+		name      string
+		mediaType string
+		fileName  string
+		format    string
+		expected  ImportFormat
+	}{
+		{name: "query wins", mediaType: "text/csv", format: "ndjson", expected: ImportNDJSON},
+		{name: "media type", mediaType: "text/csv; charset=utf-8", expected: ImportCSV},
+		{name: "ndjson media type", mediaType: "application/x-ndjson", expected: ImportNDJSON},
+		{name: "extension", mediaType: "application/octet-stream", fileName: "books.JSONL", expected: ImportNDJSON},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			format, err := importFormatOf(c.mediaType, c.fileName, c.format)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, format)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := importFormatOf("application/pdf", "books.pdf", "")
+
+		assert.ErrorIs(t, err, ErrUnsupportedImportFormat)
+	})
+}
+
+func TestCSVRows(t *testing.T) {
+	t.Run("maps header aliases", func(t *testing.T) {
+		input := "\ufeffTitle,Author,ISBN,Year,cover_url,shelf\n" +
+			"Clean Code,Robert C. Martin,9780132350884,2008,https://img.com/cover.jpg,A3\n" +
+			"\"Refactoring, 2nd Edition\",Martin Fowler,9780134757599,2018,https://img.com/r.jpg,B1\n"
+
+		rows, err := newRowReader(strings.NewReader(input), ImportCSV)
+		require.NoError(t, err)
+
+		first, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 2, first.Line)
+		assert.Equal(t, CreateBookRequest{
+			CoverUrl:        "https://img.com/cover.jpg",
+			ISBN:            "9780132350884",
+			Title:           "Clean Code",
+			Author:          "Robert C. Martin",
+			PublicationYear: "2008",
+		}, first.Book)
+
+		second, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 3, second.Line)
+		assert.Equal(t, "Refactoring, 2nd Edition", second.Book.Title)
+
+		_, err = rows.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("tags separated by semicolons", func(t *testing.T) {
+		rows, err := newRowReader(strings.NewReader("title,tags\nDune,space opera;Hugo Award\n"), ImportCSV)
+		require.NoError(t, err)
+
+		row, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"space opera", "Hugo Award"}, row.Book.Tags)
+	})
+
+	t.Run("malformed row does not stop the import", func(t *testing.T) {
+		input := "title,author\n\"broken,row\nnext,row\n"
+
+		rows, err := newRowReader(strings.NewReader(input), ImportCSV)
+		require.NoError(t, err)
+
+		row, err := rows.Next()
+		require.NoError(t, err)
+		assert.ErrorIs(t, row.Err, ErrMalformedImportRow)
+	})
+
+	t.Run("unknown header", func(t *testing.T) {
+		_, err := newRowReader(strings.NewReader("name,shelf\nx,y\n"), ImportCSV)
+
+		assert.ErrorIs(t, err, ErrInvalidImportHeader)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		_, err := newRowReader(strings.NewReader(""), ImportCSV)
+
+		assert.ErrorIs(t, err, ErrInvalidImportHeader)
+	})
+}
+
+func TestNDJSONRows(t *testing.T) {
+	t.Run("skips blank lines and keeps line numbers", func(t *testing.T) {
+		input := `{"title":"Clean Code","isbn":"9780132350884"}` + "\n\n" + `{"title":` + "\n"
+
+		rows, err := newRowReader(strings.NewReader(input), ImportNDJSON)
+		require.NoError(t, err)
+
+		first, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 1, first.Line)
+		assert.Equal(t, "Clean Code", first.Book.Title)
+
+		second, err := rows.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 3, second.Line)
+		assert.ErrorIs(t, second.Err, ErrMalformedImportRow)
+
+		_, err = rows.Next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("line too long", func(t *testing.T) {
+		input := `{"title":"` + strings.Repeat("x", maxImportLineBytes) + `"}` + "\n"
+
+		rows, err := newRowReader(strings.NewReader(input), ImportNDJSON)
+		require.NoError(t, err)
+
+		_, err = rows.Next()
+		assert.ErrorIs(t, err, ErrImportLineTooLong)
+	})
+}
+
+func TestImportReport_Fail(t *testing.T) {
+	report := ImportReport{}
+	for line := 1; line <= maxImportErrors+5; line++ {
+		report.fail(line, ErrMalformedImportRow)
+	}
+
+	assert.Equal(t, maxImportErrors+5, report.Failed)
+	assert.Len(t, report.Errors, maxImportErrors)
+	assert.True(t, report.Truncated)
+}
@@ -1,14 +1,82 @@
 package book
 
-import "time"
+import (
+	"time"
+
+	"book-api/internal/author"
+	"book-api/internal/work"
+	"book-api/pkg/apperror"
+)
 
 type CreateBookRequest struct {
 	Id              string `json:"id,omitempty" validate:"omitempty,uuid"`
-	CoverUrl        string `json:"coverUrl" validate:"required,url"`
+	CoverUrl        string `json:"coverUrl" validate:"omitempty,url"`
 	ISBN            string `json:"isbn" validate:"required,isbn"`
 	Title           string `json:"title" validate:"required,min=1"`
-	Author          string `json:"author" validate:"required,min=1"`
+	Author          string `json:"author" validate:"required_without=Authors"`
 	PublicationYear string `json:"publicationYear" validate:"required,number,min=3,max=4"`
+	// Authors credits the book explicitly, without it the author text is split into credited authors
+	Authors []BookAuthor `json:"authors,omitempty" validate:"omitempty,max=50,dive"`
+	// WorkId makes the book an edition of an existing work, without it the book gets a work of its own
+	WorkId         string   `json:"workId,omitempty" validate:"omitempty,uuid"`
+	PublisherId    string   `json:"publisherId,omitempty" validate:"omitempty,uuid"`
+	SeriesId       string   `json:"seriesId,omitempty" validate:"omitempty,uuid"`
+	SeriesPosition int      `json:"seriesPosition,omitempty" validate:"omitempty,min=1,excluded_without=SeriesId"`
+	GenreIds       []string `json:"genreIds,omitempty" validate:"omitempty,max=20,dive,uuid"`
+	Tags           []string `json:"tags,omitempty" validate:"omitempty,max=50,dive,max=50"`
+}
+
+// applyEdition copies the work, publisher and series of the request onto the book it writes.
+func (r CreateBookRequest) applyEdition(book *BookDTO) {
+	book.WorkId = r.WorkId
+	if r.PublisherId != "" {
+		book.PublisherId = &r.PublisherId
+	}
+	if r.SeriesId != "" {
+		book.SeriesId = &r.SeriesId
+	}
+	if r.SeriesPosition != 0 {
+		book.SeriesPosition = &r.SeriesPosition
+	}
+}
+
+// applyClassification copies the genres and the normalized tags of the request onto the book it writes.
+func (r CreateBookRequest) applyClassification(book *BookDTO) {
+	book.Tags = normalizeTags(r.Tags)
+	book.Genres = make([]BookGenre, 0, len(r.GenreIds))
+	for _, genreId := range r.GenreIds {
+		book.Genres = append(book.Genres, BookGenre{Id: genreId})
+	}
+}
+
+// BookAuthor credits an author on a book. Requests name the author by id or by name, responses carry both.
+type BookAuthor struct {
+	Id   string      `json:"id,omitempty" validate:"omitempty,uuid"`
+	Name string      `json:"name,omitempty" validate:"required_without=Id,max=255"`
+	Role author.Role `json:"role,omitempty" validate:"omitempty,oneof=author editor translator illustrator"`
+}
+
+type GetBookRequest struct {
+	Embed string `query:"embed,omitempty"`
+}
+
+// BookEmbed names the related records GET /book/:id loads into the book.
+type BookEmbed struct {
+	Publisher bool
+	Editions  bool
+	Series    bool
+}
+
+func (e BookEmbed) Any() bool {
+	return e.Publisher || e.Editions || e.Series
+}
+
+type CreateBookQuery struct {
+	Enrich bool `query:"enrich,omitempty"`
+}
+
+type EnrichBookRequest struct {
+	ISBN string `json:"isbn" validate:"required"`
 }
 
 type UpdateBookRequest struct {
@@ -16,10 +84,42 @@ type UpdateBookRequest struct {
 	Id string `json:"id" validate:"required,uuid4"`
 }
 
+// BookFilterRequest holds the filters GET /books and GET /books/export share.
+type BookFilterRequest struct {
+	Search       string `query:"search,omitempty"`
+	SearchMode   string `query:"searchMode,omitempty" validate:"omitempty,oneof=fulltext substring"`
+	Author       string `query:"author,omitempty"`
+	ISBN         string `query:"isbn,omitempty"`
+	YearFrom     int    `query:"yearFrom,omitempty" validate:"omitempty,min=1,max=9999"`
+	YearTo       int    `query:"yearTo,omitempty" validate:"omitempty,min=1,max=9999,gtefield=YearFrom"`
+	CreatedAfter string `query:"createdAfter,omitempty"`
+	Genre        string `query:"genre,omitempty"`
+	Tag          string `query:"tag,omitempty"`
+}
+
 type GetBooksRequest struct {
-	Page     int    `query:"page,omitempty"`
-	PageSize int    `query:"pageSize,omitempty"`
-	Search   string `query:"search,omitempty"`
+	BookFilterRequest
+	Page         int    `query:"page,omitempty" validate:"omitempty,min=1,excluded_with=Cursor"`
+	PageSize     int    `query:"pageSize,omitempty" validate:"omitempty,min=1,max=100,excluded_with=Cursor Limit"`
+	Highlight    bool   `query:"highlight,omitempty"`
+	Sort         string `query:"sort,omitempty" validate:"excluded_with=Cursor Limit"`
+	Cursor       string `query:"cursor,omitempty"`
+	Limit        int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+	IncludeTotal bool   `query:"includeTotal,omitempty"`
+	Facets       bool   `query:"facets,omitempty"`
+}
+
+type ExportBooksRequest struct {
+	BookFilterRequest
+	Format         string `query:"format,omitempty" validate:"omitempty,oneof=csv ndjson marcxml"`
+	Sort           string `query:"sort,omitempty"`
+	IncludeDeleted bool   `query:"includeDeleted,omitempty"`
+}
+
+type ExportBooksQuery struct {
+	Filter         BookFilter
+	Sort           []SortField
+	IncludeDeleted bool
 }
 
 type GetBooksResponse struct {
@@ -27,14 +127,328 @@ type GetBooksResponse struct {
 	TotalPage int
 }
 
+type GetBooksCursorResponse struct {
+	Books      []BookDTO   `json:"books"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	PrevCursor string      `json:"prevCursor,omitempty"`
+	TotalCount *int        `json:"totalCount,omitempty"`
+	Facets     *BookFacets `json:"facets,omitempty"`
+}
+
+// BookFacets counts the books matching a filter by genre, tag, author and decade. A genre counts the books
+// filed under it or any genre below it, a decade like "1990" counts the books published from 1990 to 1999.
+type BookFacets struct {
+	Genres  []FacetCount `json:"genres"`
+	Tags    []FacetCount `json:"tags"`
+	Authors []FacetCount `json:"authors"`
+	Decades []FacetCount `json:"decades"`
+}
+
+// FacetCount is a value to filter by with the number of matching books, Label is the display name of a genre
+// whose value is its slug.
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// BookCursor is the keyset position behind an opaque cursor, CreatedAt is in unix microseconds
+// to match the precision of the created_at column.
+type BookCursor struct {
+	CreatedAt int64  `json:"c"`
+	Id        string `json:"i"`
+	Backward  bool   `json:"b,omitempty"`
+}
+
+type CursorPageRequest struct {
+	Filter       BookFilter
+	Cursor       *BookCursor
+	Limit        int
+	IncludeTotal bool
+	Highlight    bool
+}
+
+type GetAuthorBooksRequest struct {
+	Role   string `query:"role,omitempty" validate:"omitempty,oneof=author editor translator illustrator"`
+	Limit  int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor,omitempty"`
+}
+
+// AuthorBooksCursor names its author, so a cursor is not reused for the books of another author.
+type AuthorBooksCursor struct {
+	AuthorId  string `json:"a"`
+	CreatedAt int64  `json:"c"`
+	Id        string `json:"i"`
+}
+
+type AuthorBooksPageRequest struct {
+	AuthorId string
+	Role     author.Role
+	Cursor   *AuthorBooksCursor
+	Limit    int
+}
+
+type AuthorBooksPage struct {
+	Books []BookDTO
+	Next  *AuthorBooksCursor
+}
+
+type BulkOp string
+
+const (
+	BulkCreate BulkOp = "create"
+	BulkUpdate BulkOp = "update"
+	BulkDelete BulkOp = "delete"
+)
+
+type BulkMode string
+
+const (
+	BulkModeAtomic     BulkMode = "atomic"
+	BulkModeBestEffort BulkMode = "bestEffort"
+)
+
+type BulkBooksRequest struct {
+	Mode string `query:"mode,omitempty" validate:"omitempty,oneof=atomic bestEffort"`
+}
+
+// BulkOperation is a CreateBookRequest with an optional op, so a plain array of books bulk creates them.
+// Updates and deletes name the book by id and carry the version the client last read.
+type BulkOperation struct {
+	Op      BulkOp `json:"op,omitempty"`
+	Version int    `json:"version,omitempty"`
+	CreateBookRequest
+}
+
+type BulkItemResult struct {
+	Index   int               `json:"index"`
+	Op      BulkOp            `json:"op"`
+	Id      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Version int               `json:"version,omitempty"`
+	Error   *apperror.Problem `json:"error,omitempty"`
+}
+
+func (r *BulkItemResult) fail(err error) {
+	r.Error = apperror.NewProblem(err)
+	r.Status = r.Error.Status
+}
+
+type BulkBooksResponse struct {
+	Results   []BulkItemResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+type BulkWrite struct {
+	Op              BulkOp
+	ExpectedVersion int
+	Book            *BookDTO
+}
+
+type ImportBooksRequest struct {
+	Format string `query:"format,omitempty" validate:"omitempty,oneof=csv ndjson"`
+	DryRun bool   `query:"dryRun,omitempty"`
+	Async  bool   `query:"async,omitempty"`
+}
+
+type GetDeletedBooksRequest struct {
+	Cursor string `query:"cursor,omitempty"`
+	Limit  int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+type DeleteBookRequest struct {
+	Hard bool `query:"hard,omitempty"`
+}
+
+// DeletedBookCursor is the keyset position in the trash, which is ordered by deletion time.
+type DeletedBookCursor struct {
+	DeletedAt int64  `json:"d"`
+	Id        string `json:"i"`
+}
+
+type DeletedPageRequest struct {
+	Cursor *DeletedBookCursor
+	Limit  int
+}
+
+type DeletedPage struct {
+	Books []BookDTO
+	Next  *DeletedBookCursor
+}
+
+type GetBookHistoryRequest struct {
+	Cursor string `query:"cursor,omitempty"`
+	Limit  int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+type GetBookHistoryResponse struct {
+	Events     []BookEvent `json:"events"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// HistoryCursor points at the last event of a history page, it is bound to the book so it cannot be replayed on another.
+type HistoryCursor struct {
+	BookId string `json:"b"`
+	Id     int64  `json:"i"`
+}
+
+type HistoryPageRequest struct {
+	BookId string
+	Cursor *HistoryCursor
+	Limit  int
+}
+
+type HistoryPage struct {
+	Events []BookEvent
+	Next   *HistoryCursor
+}
+
+type CursorPage struct {
+	Books      []BookDTO
+	Next       *BookCursor
+	Prev       *BookCursor
+	TotalCount *int
+}
+
 type BookDTO struct {
 	Id              string     `json:"id" db:"id"`
 	CoverUrl        string     `json:"coverUrl" db:"cover_url"`
 	ISBN            string     `json:"isbn" db:"isbn"`
+	ISBN13          *string    `json:"isbn13,omitempty" db:"isbn13"`
 	Title           string     `json:"title" db:"title"`
 	Author          string     `json:"author" db:"author"`
 	PublicationYear string     `json:"publicationYear" db:"publication_year"`
+	Version         int        `json:"version" db:"version"`
 	CreatedAt       *time.Time `json:"createdAt" db:"created_at"`
 	DeletedAt       *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
 	UpdatedAt       *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+
+	WorkId         string   `json:"workId" db:"work_id"`
+	PublisherId    *string  `json:"publisherId,omitempty" db:"publisher_id"`
+	SeriesId       *string  `json:"seriesId,omitempty" db:"series_id"`
+	SeriesPosition *int     `json:"seriesPosition,omitempty" db:"series_position"`
+	Tags           []string `json:"tags" db:"tags"`
+
+	// Authors is only loaded for single books and the books of an author, Genres for single books only,
+	// lists carry the author text and the tags
+	Authors   []BookAuthor   `json:"authors,omitempty" db:"-"`
+	Genres    []BookGenre    `json:"genres,omitempty" db:"-"`
+	Highlight *BookHighlight `json:"highlight,omitempty" db:"-"`
+	// RelatedUpdatedAt is the last change of a credited author or filed genre, only loaded for single books
+	// to build their ETag
+	RelatedUpdatedAt *time.Time `json:"-" db:"-"`
+
+	// Publisher, Editions and Series are only loaded when GET /book/:id asks to embed them
+	Publisher *BookPublisher    `json:"publisher,omitempty" db:"-"`
+	Editions  []work.EditionDTO `json:"editions,omitempty" db:"-"`
+	Series    *BookSeries       `json:"series,omitempty" db:"-"`
+}
+
+type BookGenre struct {
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+type BookPublisher struct {
+	Id   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+// BookSeries is the series of a book with the live books in it, in series order.
+type BookSeries struct {
+	Id      string        `json:"id" db:"id"`
+	Name    string        `json:"name" db:"name"`
+	Entries []SeriesEntry `json:"entries" db:"-"`
+}
+
+type SeriesEntry struct {
+	Id              string `json:"id" db:"id"`
+	WorkId          string `json:"workId" db:"work_id"`
+	Title           string `json:"title" db:"title"`
+	PublicationYear string `json:"publicationYear" db:"publication_year"`
+	Position        *int   `json:"position,omitempty" db:"series_position"`
+}
+
+type BookHighlight struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// BookCover describes the image uploaded for a book, the original and its thumbnails live in the blob store.
+// Sizes lists the thumbnails that were made, a source narrower than a thumbnail width does not get that one.
+type BookCover struct {
+	BookId        string     `json:"-" db:"book_id"`
+	Digest        string     `json:"digest" db:"digest"`
+	ContentType   string     `json:"contentType" db:"content_type"`
+	ThumbnailType string     `json:"thumbnailType" db:"thumbnail_type"`
+	Width         int        `json:"width" db:"width"`
+	Height        int        `json:"height" db:"height"`
+	ByteSize      int64      `json:"byteSize" db:"byte_size"`
+	Sizes         []string   `json:"sizes" db:"sizes"`
+	UploadedAt    *time.Time `json:"uploadedAt" db:"uploaded_at"`
+	// SourceUrl is the cover url a mirrored cover was copied from, uploads have none
+	SourceUrl *string `json:"sourceUrl,omitempty" db:"source_url"`
+}
+
+type GetBookCoverRequest struct {
+	Size string `query:"size" validate:"omitempty,oneof=small medium large original"`
+}
+
+type CoverCheckOutcome string
+
+const (
+	CoverOK          CoverCheckOutcome = "ok"
+	CoverHTTPError   CoverCheckOutcome = "http_error"
+	CoverNotImage    CoverCheckOutcome = "not_image"
+	CoverUnreachable CoverCheckOutcome = "unreachable"
+)
+
+// CoverCheck is the latest health check of a book's cover url. Failures counts the checks failed in a row.
+type CoverCheck struct {
+	BookId       string            `json:"bookId" db:"book_id"`
+	Title        string            `json:"title,omitempty" db:"title"`
+	CoverUrl     string            `json:"coverUrl" db:"cover_url"`
+	Outcome      CoverCheckOutcome `json:"outcome" db:"outcome"`
+	StatusCode   *int              `json:"statusCode,omitempty" db:"status_code"`
+	ContentType  *string           `json:"contentType,omitempty" db:"content_type"`
+	Error        *string           `json:"error,omitempty" db:"error"`
+	Failures     int               `json:"failures" db:"failures"`
+	FailingSince *time.Time        `json:"failingSince,omitempty" db:"failing_since"`
+	CheckedAt    *time.Time        `json:"checkedAt" db:"checked_at"`
+}
+
+// CoverCheckTarget is a live book whose cover url is due for a check, with what it needs to decide on mirroring.
+type CoverCheckTarget struct {
+	BookId      string  `db:"book_id"`
+	CoverUrl    string  `db:"cover_url"`
+	HasCover    bool    `db:"has_cover"`
+	MirroredUrl *string `db:"mirrored_url"`
+}
+
+type GetFailingCoversRequest struct {
+	Cursor string `query:"cursor,omitempty"`
+	Limit  int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+type GetFailingCoversResponse struct {
+	Covers     []CoverCheck `json:"covers"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
+
+// FailingCoverCursor is the keyset position in the failing covers, which are ordered longest failing first.
+type FailingCoverCursor struct {
+	FailingSince int64  `json:"f"`
+	Id           string `json:"i"`
+}
+
+type FailingCoversPageRequest struct {
+	Cursor *FailingCoverCursor
+	Limit  int
+}
+
+type FailingCoversPage struct {
+	Covers []CoverCheck
+	Next   *FailingCoverCursor
 }
@@ -17,12 +17,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+
+	"book-api/pkg/apperror"
 )
 
 func Test_PactProvider(t *testing.T) {
 	handler := &Handler{
 		server: fiber.New(fiber.Config{
 			DisableStartupMessage: true,
+			ErrorHandler:          apperror.ErrorHandler,
 		}),
 		validator: validator.New(),
 	}
@@ -71,7 +74,7 @@ func Test_PactProvider(t *testing.T) {
 			mockRepository.
 				EXPECT().
 				CreateBook(gomock.Any(), gomock.Any()).
-				Return(fiber.ErrInternalServerError).
+				Return(ErrRepositoryFailure).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -83,7 +86,7 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				GetBooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				GetBooks(gomock.Any(), gomock.Any()).
 				Return(&[]BookDTO{
 					{
 						Id:              "7c9affa4-5c43-4cb4-a5fb-dcc291fbba59",
@@ -105,8 +108,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				GetBooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(nil, 0, fiber.ErrNotFound).
+				GetBooks(gomock.Any(), gomock.Any()).
+				Return(nil, 0, ErrBookNotFound).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -117,8 +120,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				GetBooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(nil, 0, fiber.ErrInternalServerError).
+				GetBooks(gomock.Any(), gomock.Any()).
+				Return(nil, 0, ErrRepositoryFailure).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -152,7 +155,7 @@ func Test_PactProvider(t *testing.T) {
 			mockRepository.
 				EXPECT().
 				GetBookById(gomock.Any(), gomock.Any()).
-				Return(nil, fiber.ErrNotFound).
+				Return(nil, ErrBookNotFound).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -164,7 +167,7 @@ func Test_PactProvider(t *testing.T) {
 			mockRepository.
 				EXPECT().
 				GetBookById(gomock.Any(), gomock.Any()).
-				Return(nil, fiber.ErrInternalServerError).
+				Return(nil, ErrRepositoryFailure).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -176,7 +179,7 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any()).
+				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(nil).
 				Times(1)
 
@@ -188,8 +191,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(fiber.ErrNotFound).
+				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(ErrBookNotFound).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -200,8 +203,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(fiber.ErrInternalServerError).
+				UpdateBookById(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(ErrRepositoryFailure).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -213,7 +216,7 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				DeleteBookById(gomock.Any(), gomock.Any()).
+				DeleteBookById(gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(nil).
 				Times(1)
 
@@ -225,8 +228,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				DeleteBookById(gomock.Any(), gomock.Any()).
-				Return(fiber.ErrNotFound).
+				DeleteBookById(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(ErrBookNotFound).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -237,8 +240,8 @@ func Test_PactProvider(t *testing.T) {
 			}
 			mockRepository.
 				EXPECT().
-				DeleteBookById(gomock.Any(), gomock.Any()).
-				Return(fiber.ErrInternalServerError).
+				DeleteBookById(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(ErrRepositoryFailure).
 				Times(1)
 
 			return models.ProviderStateResponse{}, nil
@@ -0,0 +1,213 @@
+package book
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/patch"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// patchDocument is the book as a patch sees it. Every field is present, unset ones as null or an empty list,
+// so a JSON Patch can test, replace or append to any of them.
+type patchDocument struct {
+	Id              string       `json:"id"`
+	CoverUrl        string       `json:"coverUrl"`
+	ISBN            string       `json:"isbn"`
+	Title           string       `json:"title"`
+	Author          string       `json:"author"`
+	PublicationYear string       `json:"publicationYear"`
+	Authors         []BookAuthor `json:"authors"`
+	WorkId          string       `json:"workId"`
+	PublisherId     *string      `json:"publisherId"`
+	SeriesId        *string      `json:"seriesId"`
+	SeriesPosition  *int         `json:"seriesPosition"`
+	GenreIds        []string     `json:"genreIds"`
+	Tags            []string     `json:"tags"`
+}
+
+func patchDocumentOf(book *BookDTO) patchDocument {
+	document := patchDocument{
+		Id:              book.Id,
+		CoverUrl:        book.CoverUrl,
+		ISBN:            book.ISBN,
+		Title:           book.Title,
+		Author:          book.Author,
+		PublicationYear: book.PublicationYear,
+		Authors:         make([]BookAuthor, 0, len(book.Authors)),
+		WorkId:          book.WorkId,
+		PublisherId:     book.PublisherId,
+		SeriesId:        book.SeriesId,
+		SeriesPosition:  book.SeriesPosition,
+		GenreIds:        make([]string, 0, len(book.Genres)),
+		Tags:            book.Tags,
+	}
+	for _, credit := range book.Authors {
+		document.Authors = append(document.Authors, BookAuthor{Id: credit.Id, Name: credit.Name, Role: credit.Role})
+	}
+	for _, genre := range book.Genres {
+		document.GenreIds = append(document.GenreIds, genre.Id)
+	}
+	if document.Tags == nil {
+		document.Tags = []string{}
+	}
+
+	return document
+}
+
+// PatchBookById applies a merge patch or a JSON Patch to the book and writes the fields it changed.
+// The patched book is validated like a PUT body, a patch that changes nothing writes nothing.
+func (h *Handler) PatchBookById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "PatchBookById")
+	defer span.End()
+
+	bookId := ctx.Params("id")
+	span.SetAttributes(attribute.String("bookId", bookId))
+
+	if err := h.validator.VarCtx(ctx.Context(), bookId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if mediaType != mimeMergePatch && mediaType != mimeJSONPatch {
+		return ErrUnsupportedPatchType.WithMeta("contentType", mediaType)
+	}
+	span.SetAttributes(attribute.String("patchType", mediaType))
+
+	version, err := expectedVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := h.repository.GetBookById(ctx.Context(), bookId)
+	if err != nil {
+		return err
+	}
+	if version == anyVersion {
+		// the patch is applied to the book just read, so the write still has to find it unchanged
+		version = current.Version
+	}
+	if current.Version != version {
+		return ErrVersionMismatch.WithMeta("currentVersion", current.Version)
+	}
+
+	document, err := json.Marshal(patchDocumentOf(current))
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	if mediaType == mimeMergePatch {
+		patched, err = patch.Merge(document, ctx.Body())
+	} else {
+		patched, err = patch.Apply(document, ctx.Body())
+	}
+	if errors.Is(err, patch.ErrTestFailed) {
+		return ErrPatchTestFailed.WithCause(err)
+	}
+	if err != nil {
+		return ErrInvalidPatch.WithCause(err)
+	}
+
+	before, err := decodePatched(document)
+	if err != nil {
+		return err
+	}
+	after, err := decodePatched(patched)
+	if err != nil {
+		return err
+	}
+	if after.Id != bookId {
+		return ErrImmutableBookId
+	}
+
+	if err = h.validator.StructCtx(ctx.Context(), &UpdateBookRequest{
+		CreateBookRequest: after,
+		Id:                bookId,
+	}); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	changed := changedFields(before, after)
+	span.SetAttributes(attribute.StringSlice("changed", changed))
+	if len(changed) == 0 {
+		ctx.Set(fiber.HeaderETag, bookETag(current))
+		return ctx.SendStatus(fiber.StatusNoContent)
+	}
+
+	now := time.Now().UTC()
+	book := &BookDTO{
+		Id:              bookId,
+		CoverUrl:        after.CoverUrl,
+		ISBN:            after.ISBN,
+		Title:           after.Title,
+		Author:          after.Author,
+		PublicationYear: after.PublicationYear,
+		Authors:         after.Authors,
+		UpdatedAt:       &now,
+	}
+	after.applyEdition(book)
+	after.applyClassification(book)
+	if err = h.repository.PatchBookById(auditContext(ctx), bookId, version, book, changed); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, bookETag(book))
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// decodePatched reads a patched document as a PUT body, members the book does not have are refused
+// so a misspelt field fails instead of being dropped.
+func decodePatched(document []byte) (CreateBookRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var request CreateBookRequest
+	if err := decoder.Decode(&request); err != nil {
+		return request, ErrInvalidPatch.WithCause(err)
+	}
+
+	return request, nil
+}
+
+// changedFields names the fields, as sent in JSON, whose value differs between before and after.
+func changedFields(before, after CreateBookRequest) []string {
+	fields := []struct {
+		name      string
+		unchanged bool
+	}{
+		{"coverUrl", before.CoverUrl == after.CoverUrl},
+		{"isbn", before.ISBN == after.ISBN},
+		{"title", before.Title == after.Title},
+		{"author", before.Author == after.Author},
+		{"publicationYear", before.PublicationYear == after.PublicationYear},
+		{"authors", slices.Equal(before.Authors, after.Authors)},
+		{"workId", before.WorkId == after.WorkId},
+		{"publisherId", before.PublisherId == after.PublisherId},
+		{"seriesId", before.SeriesId == after.SeriesId},
+		{"seriesPosition", before.SeriesPosition == after.SeriesPosition},
+		{"genreIds", slices.Equal(before.GenreIds, after.GenreIds)},
+		{"tags", slices.Equal(normalizeTags(before.Tags), normalizeTags(after.Tags))},
+	}
+
+	var changed []string
+	for _, field := range fields {
+		if !field.unchanged {
+			changed = append(changed, field.name)
+		}
+	}
+
+	return changed
+}
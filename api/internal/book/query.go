@@ -0,0 +1,294 @@
+package book
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	"book-api/pkg/isbn"
+)
+
+const bookColumns = "id, cover_url, isbn, isbn13, title, author, publication_year, version, created_at, updated_at, deleted_at, work_id, publisher_id, series_id, series_position, tags"
+
+// failingCoverCondition keeps the failed checks of live books that still have the url that was checked.
+const failingCoverCondition = "failing_since is not null and books.deleted_at is null and cover_checks.cover_url = books.cover_url"
+
+const coverColumns = "book_id, digest, content_type, thumbnail_type, width, height, byte_size, sizes, uploaded_at, source_url"
+
+// textSearchQuery parses the search term with web search syntax, it is always bound to $1 by filterConditions.
+const textSearchQuery = "websearch_to_tsquery('english', $1)"
+
+// publicationYearExpression compares publication_year numerically even though the column is a varchar.
+const publicationYearExpression = `nullif(regexp_replace(publication_year, '\D', '', 'g'), '')::int`
+
+var sortableColumns = map[string]string{
+	"title":           "title",
+	"author":          "author",
+	"isbn":            "isbn",
+	"publicationYear": publicationYearExpression,
+	"createdAt":       "created_at",
+	"updatedAt":       "updated_at",
+}
+
+// patchColumns writes a field a patch changed, the placeholder in set takes the value of the field on the book.
+// Like a PUT, a patch that drops the workId keeps the book's work.
+var patchColumns = map[string]struct {
+	set   string
+	value func(book *BookDTO) any
+}{
+	"coverUrl":        {"cover_url = %s", func(book *BookDTO) any { return book.CoverUrl }},
+	"isbn":            {"isbn = %s", func(book *BookDTO) any { return book.ISBN }},
+	"title":           {"title = %s", func(book *BookDTO) any { return book.Title }},
+	"author":          {"author = %s", func(book *BookDTO) any { return book.Author }},
+	"publicationYear": {"publication_year = %s", func(book *BookDTO) any { return book.PublicationYear }},
+	"workId":          {"work_id = coalesce(nullif(%s, '')::uuid, work_id)", func(book *BookDTO) any { return book.WorkId }},
+	"publisherId":     {"publisher_id = %s", func(book *BookDTO) any { return book.PublisherId }},
+	"seriesId":        {"series_id = %s", func(book *BookDTO) any { return book.SeriesId }},
+	"seriesPosition":  {"series_position = %s", func(book *BookDTO) any { return book.SeriesPosition }},
+	"tags":            {"tags = coalesce(%s::text[], '{}')", func(book *BookDTO) any { return book.Tags }},
+}
+
+type SearchMode string
+
+const (
+	SearchModeFullText  SearchMode = "fulltext"
+	SearchModeSubstring SearchMode = "substring"
+)
+
+type BookFilter struct {
+	Search       string
+	SearchMode   SearchMode
+	Author       string
+	ISBN         string
+	YearFrom     int
+	YearTo       int
+	CreatedAfter *time.Time
+	// Genre names a genre by slug or id, books filed under any genre below it match too
+	Genre string
+	Tag   string
+}
+
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+type ListBooksQuery struct {
+	Filter    BookFilter
+	Sort      []SortField
+	Page      int
+	PageSize  int
+	Highlight bool
+}
+
+// bookRow carries the optional search columns next to the book, they are only selected when highlighting.
+type bookRow struct {
+	BookDTO
+	TitleHighlight  *string `db:"title_highlight"`
+	AuthorHighlight *string `db:"author_highlight"`
+}
+
+func (r bookRow) toDTO() BookDTO {
+	book := r.BookDTO
+	if r.TitleHighlight != nil || r.AuthorHighlight != nil {
+		book.Highlight = &BookHighlight{}
+		if r.TitleHighlight != nil {
+			book.Highlight.Title = markHighlight(*r.TitleHighlight)
+		}
+		if r.AuthorHighlight != nil {
+			book.Highlight.Author = markHighlight(*r.AuthorHighlight)
+		}
+	}
+
+	return book
+}
+
+// ts_headline copies the text around the matches as is, so it marks them with private use characters
+// and the text is HTML escaped before they become <mark> tags.
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+func markHighlight(headline string) string {
+	return highlightMarks.Replace(html.EscapeString(headline))
+}
+
+func toDTOs(rows []bookRow) []BookDTO {
+	books := make([]BookDTO, 0, len(rows))
+	for _, row := range rows {
+		books = append(books, row.toDTO())
+	}
+
+	return books
+}
+
+// ParseSort reads a comma separated list like "title,-publicationYear" where a leading "-" sorts descending.
+func ParseSort(raw string) ([]SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	fields := make([]SortField, 0, 2)
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Descending: strings.HasPrefix(part, "-")}
+
+		if _, ok := sortableColumns[field.Field]; !ok {
+			return nil, ErrUnsortableField.WithMeta("field", field.Field)
+		}
+
+		if seen[field.Field] {
+			return nil, ErrDuplicateSortField.WithMeta("field", field.Field)
+		}
+		seen[field.Field] = true
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// NormalizeISBN strips separators so "978-0-06-112008-4" and "9780061120084" compare equal.
+func NormalizeISBN(value string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, value))
+}
+
+// NormalizeTag lowercases a tag and collapses its whitespace, so "Space  Opera" and "space opera" are one tag.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// normalizeTags normalizes the tags of a write and drops the blank and repeated ones, keeping their order.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+func filterConditions(filter BookFilter) (string, []any) {
+	condition, args := searchCondition(filter, make([]any, 0, 8))
+
+	var builder strings.Builder
+	builder.WriteString(condition)
+
+	if filter.Author != "" {
+		args = append(args, filter.Author)
+		fmt.Fprintf(&builder, " and lower(author) = lower($%d)", len(args))
+	}
+
+	if filter.ISBN != "" {
+		// a valid ISBN is looked up by its canonical form so ISBN-10 and ISBN-13 find the same book,
+		// anything else falls back to comparing the stored text without separators
+		if isbn13, err := isbn.Normalize(filter.ISBN); err == nil {
+			args = append(args, isbn13)
+			fmt.Fprintf(&builder, " and isbn13 = $%d", len(args))
+		} else {
+			args = append(args, NormalizeISBN(filter.ISBN))
+			fmt.Fprintf(&builder, " and upper(regexp_replace(isbn, '[- ]', '', 'g')) = $%d", len(args))
+		}
+	}
+
+	if filter.YearFrom != 0 {
+		args = append(args, filter.YearFrom)
+		fmt.Fprintf(&builder, " and %s >= $%d", publicationYearExpression, len(args))
+	}
+
+	if filter.YearTo != 0 {
+		args = append(args, filter.YearTo)
+		fmt.Fprintf(&builder, " and %s <= $%d", publicationYearExpression, len(args))
+	}
+
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		fmt.Fprintf(&builder, " and created_at > $%d", len(args))
+	}
+
+	if filter.Genre != "" {
+		args = append(args, filter.Genre)
+		fmt.Fprintf(&builder, " and id in (select book_genres.book_id from book_genres where book_genres.genre_id in (select genre_subtree($%d)))", len(args))
+	}
+
+	if filter.Tag != "" {
+		args = append(args, NormalizeTag(filter.Tag))
+		fmt.Fprintf(&builder, " and tags @> array[$%d::text]", len(args))
+	}
+
+	return builder.String(), args
+}
+
+func searchCondition(filter BookFilter, args []any) (string, []any) {
+	if filter.Search == "" {
+		return "", args
+	}
+
+	if filter.SearchMode == SearchModeSubstring {
+		args = append(args, "%"+filter.Search+"%")
+		return " and (title ILIKE $1 OR author ILIKE $1 OR id::text ILIKE $1 OR publication_year ILIKE $1)", args
+	}
+
+	args = append(args, filter.Search)
+	return " and search_vector @@ " + textSearchQuery, args
+}
+
+func isFullTextSearch(filter BookFilter) bool {
+	return filter.Search != "" && filter.SearchMode != SearchModeSubstring
+}
+
+func selectClause(filter BookFilter, highlight bool) string {
+	if !highlight || !isFullTextSearch(filter) {
+		return "select " + bookColumns
+	}
+
+	const options = "'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true'"
+	return fmt.Sprintf(
+		"select %s, ts_headline('english', title, %[2]s, %[3]s) as title_highlight, ts_headline('english', author, %[2]s, %[3]s) as author_highlight",
+		bookColumns,
+		textSearchQuery,
+		options,
+	)
+}
+
+// orderClause only ever interpolates expressions from sortableColumns, and always ends with id so pages are stable.
+// Without an explicit sort full-text results come back by relevance.
+func orderClause(sort []SortField, filter BookFilter) string {
+	if len(sort) == 0 && isFullTextSearch(filter) {
+		return " order by ts_rank_cd(search_vector, " + textSearchQuery + ") desc, created_at desc, id desc"
+	}
+
+	if len(sort) == 0 {
+		return " order by created_at desc, id desc"
+	}
+
+	expressions := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := sortableColumns[field.Field]
+		if !ok {
+			continue
+		}
+
+		direction := "asc"
+		if field.Descending {
+			direction = "desc"
+		}
+		expressions = append(expressions, fmt.Sprintf("%s %s nulls last", column, direction))
+	}
+
+	return " order by " + strings.Join(append(expressions, "id asc"), ", ")
+}
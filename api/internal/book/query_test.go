@@ -0,0 +1,109 @@
+package book
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		sort, err := ParseSort("title, -publicationYear")
+
+		require.NoError(t, err)
+		assert.Equal(t, []SortField{
+			{Field: "title"},
+			{Field: "publicationYear", Descending: true},
+		}, sort)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		sort, err := ParseSort("")
+
+		assert.NoError(t, err)
+		assert.Nil(t, sort)
+	})
+
+	t.Run("unsortable field", func(t *testing.T) {
+		_, err := ParseSort("title,cover_url; drop table books")
+
+		assert.ErrorIs(t, err, ErrUnsortableField)
+	})
+
+	t.Run("duplicate field", func(t *testing.T) {
+		_, err := ParseSort("title,-title")
+
+		assert.ErrorIs(t, err, ErrDuplicateSortField)
+	})
+}
+
+func TestFilterConditions(t *testing.T) {
+	createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	condition, args := filterConditions(BookFilter{
+		Search:       "code",
+		SearchMode:   SearchModeSubstring,
+		Author:       "Robert C. Martin",
+		ISBN:         "978-0-13-235088-4",
+		YearFrom:     2000,
+		YearTo:       2010,
+		CreatedAfter: &createdAfter,
+	})
+
+	assert.Equal(t, []any{"%code%", "Robert C. Martin", "9780132350884", 2000, 2010, createdAfter}, args)
+	assert.Contains(t, condition, "lower(author) = lower($2)")
+	assert.Contains(t, condition, "isbn13 = $3")
+	assert.Contains(t, condition, ">= $4")
+	assert.Contains(t, condition, "<= $5")
+	assert.Contains(t, condition, "created_at > $6")
+	assert.Contains(t, condition, "title ILIKE $1")
+
+	condition, args = filterConditions(BookFilter{ISBN: "0-13-235088-2"})
+	assert.Equal(t, []any{"9780132350884"}, args)
+	assert.Equal(t, " and isbn13 = $1", condition)
+
+	condition, args = filterConditions(BookFilter{ISBN: "978-0-13-235088-5"})
+	assert.Equal(t, []any{"9780132350885"}, args)
+	assert.Contains(t, condition, "regexp_replace(isbn")
+
+	condition, args = filterConditions(BookFilter{Search: `"clean code" -martin`, SearchMode: SearchModeFullText})
+	assert.Equal(t, []any{`"clean code" -martin`}, args)
+	assert.Equal(t, " and search_vector @@ "+textSearchQuery, condition)
+
+	condition, args = filterConditions(BookFilter{Genre: "fantasy", Tag: " Space  Opera"})
+	assert.Equal(t, []any{"fantasy", "space opera"}, args)
+	assert.Contains(t, condition, "genre_subtree($1)")
+	assert.Contains(t, condition, "tags @> array[$2::text]")
+}
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"space opera", "hugo award"}, normalizeTags([]string{"Space  Opera", " ", "Hugo Award", "space opera"}))
+	assert.Equal(t, []string{}, normalizeTags(nil))
+}
+
+func TestSelectClause(t *testing.T) {
+	fullText := BookFilter{Search: "clean", SearchMode: SearchModeFullText}
+
+	assert.Equal(t, "select "+bookColumns, selectClause(fullText, false))
+	assert.Equal(t, "select "+bookColumns, selectClause(BookFilter{Search: "clean", SearchMode: SearchModeSubstring}, true))
+	assert.Contains(t, selectClause(fullText, true), "ts_headline('english', title, "+textSearchQuery)
+}
+
+func TestMarkHighlight(t *testing.T) {
+	assert.Equal(
+		t,
+		`<mark>Clean</mark> &lt;img src=x onerror=&#34;alert(1)&#34;&gt; &amp; <mark>Code</mark>`,
+		markHighlight(highlightStart+"Clean"+highlightStop+` <img src=x onerror="alert(1)"> & `+highlightStart+"Code"+highlightStop),
+	)
+}
+
+func TestOrderClause(t *testing.T) {
+	assert.Equal(t, " order by created_at desc, id desc", orderClause(nil, BookFilter{}))
+	assert.Contains(t, orderClause(nil, BookFilter{Search: "clean"}), "ts_rank_cd(search_vector, "+textSearchQuery+") desc")
+	assert.Equal(
+		t,
+		" order by title asc nulls last, "+publicationYearExpression+" desc nulls last, id asc",
+		orderClause([]SortField{{Field: "title"}, {Field: "publicationYear", Descending: true}}, BookFilter{Search: "clean"}),
+	)
+}
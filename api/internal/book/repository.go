@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/exaring/otelpgx"
-	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,14 +17,40 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"go.uber.org/zap"
+
+	"book-api/internal/author"
+	"book-api/internal/work"
+	"book-api/migrations"
+	"book-api/pkg/isbn"
+	"book-api/pkg/migrate"
 )
 
 type Repository interface {
 	CreateBook(ctx context.Context, book *BookDTO) error
-	GetBooks(ctx context.Context, page int, pageSize int, search string) (*[]BookDTO, int, error)
+	GetBooks(ctx context.Context, query ListBooksQuery) (*[]BookDTO, int, error)
+	GetBookFacets(ctx context.Context, filter BookFilter) (*BookFacets, error)
+	GetBooksByCursor(ctx context.Context, request CursorPageRequest) (*CursorPage, error)
 	GetBookById(ctx context.Context, id string) (*BookDTO, error)
-	UpdateBookById(ctx context.Context, id string, book *BookDTO) error
-	DeleteBookById(ctx context.Context, id string) error
+	GetBookByISBN(ctx context.Context, isbn13 string) (*BookDTO, error)
+	UpdateBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO) error
+	PatchBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO, changed []string) error
+	DeleteBookById(ctx context.Context, id string, expectedVersion int) error
+	GetBookHistory(ctx context.Context, request HistoryPageRequest) (*HistoryPage, error)
+	GetDeletedBooks(ctx context.Context, request DeletedPageRequest) (*DeletedPage, error)
+	RestoreBookById(ctx context.Context, id string, expectedVersion int) (*BookDTO, error)
+	PurgeBookById(ctx context.Context, id string, expectedVersion int) ([]BookCover, error)
+	PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time, batchSize int) (int, []BookCover, error)
+	BulkWrite(ctx context.Context, writes []BulkWrite, atomic bool) ([]error, error)
+	ExportBooks(ctx context.Context, query ExportBooksQuery, yield func(*BookDTO) error) error
+	GetAuthorBooks(ctx context.Context, request AuthorBooksPageRequest) (*AuthorBooksPage, error)
+	EmbedBook(ctx context.Context, book *BookDTO, embed BookEmbed) error
+	GetBookCover(ctx context.Context, bookId string) (*BookCover, error)
+	SaveBookCover(ctx context.Context, cover *BookCover) (*BookCover, error)
+	DeleteBookCover(ctx context.Context, bookId string) (*BookCover, error)
+	GetDueCoverChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]CoverCheckTarget, error)
+	SaveCoverCheck(ctx context.Context, check *CoverCheck) error
+	GetFailingCovers(ctx context.Context, request FailingCoversPageRequest) (*FailingCoversPage, error)
+	CountFailingCovers(ctx context.Context) (map[CoverCheckOutcome]int, error)
 }
 
 type PgRepository struct {
@@ -31,7 +58,29 @@ type PgRepository struct {
 	traceProvider  *sdktrace.TracerProvider
 }
 
-func NewPgRepository(traceProvider *sdktrace.TracerProvider, host, port, username, password, database string) *PgRepository {
+type PgRepositoryOption func(*pgRepositoryOptions)
+
+type pgRepositoryOptions struct {
+	requireLatestSchema bool
+}
+
+// WithSchemaCheck makes NewPgRepository refuse to start while embedded migrations are still pending.
+func WithSchemaCheck(enabled bool) PgRepositoryOption {
+	return func(options *pgRepositoryOptions) {
+		options.requireLatestSchema = enabled
+	}
+}
+
+func NewPgRepository(
+	traceProvider *sdktrace.TracerProvider,
+	host, port, username, password, database string,
+	opts ...PgRepositoryOption,
+) *PgRepository {
+	var options pgRepositoryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	credentials := fmt.Sprintf(
 		"user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
 		username, password, host, port, database,
@@ -68,12 +117,41 @@ func NewPgRepository(traceProvider *sdktrace.TracerProvider, host, port, usernam
 		zap.L().Fatal("failed to ping database", zap.Error(err))
 	}
 
+	if options.requireLatestSchema {
+		checkSchemaVersion(pgConnectionPool)
+	}
+
 	return &PgRepository{
 		connectionPool: pgConnectionPool,
 		traceProvider:  traceProvider,
 	}
 }
 
+// ConnectionPool lets the repositories of the other catalogue entities share this pool and its schema check.
+func (r *PgRepository) ConnectionPool() *pgxpool.Pool {
+	return r.connectionPool
+}
+
+func checkSchemaVersion(connectionPool *pgxpool.Pool) {
+	migrator, err := migrate.New(connectionPool, migrations.FS)
+	if err != nil {
+		zap.L().Fatal("failed to load migrations", zap.Error(err))
+	}
+
+	version, err := migrator.Version(context.Background())
+	if err != nil {
+		zap.L().Fatal("failed to read schema version", zap.Error(err))
+	}
+
+	if version < migrator.Latest() {
+		zap.L().Fatal(
+			"database schema is behind, run `book-api migrate up` first",
+			zap.Int("version", version),
+			zap.Int("latest", migrator.Latest()),
+		)
+	}
+}
+
 func (r *PgRepository) CreateBook(ctx context.Context, book *BookDTO) error {
 	ctx, span := r.traceProvider.Tracer("bookRepository").Start(ctx, "CreateBook", trace.WithAttributes(attribute.KeyValue{
 		Key:   "newBook",
@@ -83,104 +161,241 @@ func (r *PgRepository) CreateBook(ctx context.Context, book *BookDTO) error {
 
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return fiber.ErrInternalServerError
+		return ErrRepositoryUnavailable.WithCause(err)
 	}
 	defer connection.Release()
 
-	if _, err = connection.Exec(
-		ctx,
-		"insert into books (id, cover_url, isbn, title, author, publication_year, created_at) values ($1, $2, $3, $4, $5, $6, $7)",
-		book.Id,
-		book.CoverUrl,
-		book.ISBN,
-		book.Title,
-		book.Author,
-		book.PublicationYear,
-		time.Now().UTC(),
-	); err != nil {
-		return fiber.ErrInternalServerError
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		err := createBook(ctx, tx, book)
+		if err != nil {
+			return err
+		}
+
+		book.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, tx, book.Id)
+		return err
+	})
+	if err != nil {
+		return mapPgError(err)
 	}
 
 	return nil
 }
 
-func (r *PgRepository) GetBooks(
-	ctx context.Context,
-	page,
-	pageSize int,
-	search string,
-) (*[]BookDTO, int, error) {
+func (r *PgRepository) GetBooks(ctx context.Context, query ListBooksQuery) (*[]BookDTO, int, error) {
 	ctx, span := r.traceProvider.Tracer("bookRepository").
-		Start(ctx, "GetBooks", trace.WithAttributes(attribute.KeyValue{
-			Key:   "page",
-			Value: attribute.IntValue(page),
-		}), trace.WithAttributes(attribute.KeyValue{
-			Key:   "pageSize",
-			Value: attribute.IntValue(pageSize),
-		}), trace.WithAttributes(attribute.KeyValue{
-			Key:   "search",
-			Value: attribute.StringValue(search),
-		}))
+		Start(ctx, "GetBooks", trace.WithAttributes(
+			attribute.Int("page", query.Page),
+			attribute.Int("pageSize", query.PageSize),
+			attribute.String("filter", fmt.Sprintf("%+v", query.Filter)),
+			attribute.String("sort", fmt.Sprintf("%+v", query.Sort)),
+		))
 	defer span.End()
 
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return nil, 0, fiber.ErrInternalServerError
+		return nil, 0, ErrRepositoryUnavailable.WithCause(err)
 	}
 	defer connection.Release()
 
-	baseQuery := "select * from books where deleted_at is null"
-	baseCountQuery := "select count(*) from books where deleted_at is null"
-	
-	args := make([]any, 0, 6)
-	countArgs := make([]any, 0, 4)
-
-	if search != "" {
-		searchCondition := " and (title ILIKE $1 OR author ILIKE $2 OR id::text ILIKE $3 OR publication_year ILIKE $4)"
-		baseQuery += searchCondition
-		baseCountQuery += searchCondition
-
-		searchPattern := "%" + search + "%"
-		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern)
-		countArgs = append(countArgs, searchPattern, searchPattern, searchPattern, searchPattern)
-		
-		baseQuery += " limit $5 offset $6"
-		args = append(args, pageSize, (page-1)*pageSize)
-	} else {
-		baseQuery += " limit $1 offset $2"
-		args = append(args, pageSize, (page-1)*pageSize)
-	}
+	condition, args := filterConditions(query.Filter)
+	countArgs := append([]any(nil), args...)
+	baseQuery := selectClause(query.Filter, query.Highlight) +
+		" from books where deleted_at is null" +
+		condition +
+		orderClause(query.Sort, query.Filter)
+	baseCountQuery := "select count(*) from books where deleted_at is null" + condition
+
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+	baseQuery += fmt.Sprintf(" limit $%d offset $%d", len(args)-1, len(args))
 
 	var rows pgx.Rows
 	rows, err = connection.Query(ctx, baseQuery, args...)
 	if err != nil {
-		return nil, 0, fiber.ErrInternalServerError
+		return nil, 0, mapPgError(err)
 	}
 
-	var books []BookDTO
-	books, err = pgx.CollectRows(rows, pgx.RowToStructByName[BookDTO])
+	var bookRows []bookRow
+	bookRows, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[bookRow])
 	if err != nil {
-		return nil, 0, fiber.ErrInternalServerError
+		return nil, 0, mapPgError(err)
 	}
+	books := toDTOs(bookRows)
 
 	var totalRows int
 	if err = connection.QueryRow(ctx, baseCountQuery, countArgs...).Scan(&totalRows); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, 0, fiber.ErrNotFound
-		}
-
-		return nil, 0, fiber.ErrInternalServerError
+		return nil, 0, mapPgError(err)
 	}
 
 	if totalRows == 0 {
-		return nil, 0, fiber.ErrNotFound
+		return nil, 0, ErrBookNotFound
 	}
 
-	totalPage := (totalRows + pageSize - 1) / pageSize
+	totalPage := (totalRows + query.PageSize - 1) / query.PageSize
 
 	return &books, totalPage, nil
 }
 
+// facetLimit caps the values of the genre, tag and author facets, the most frequent come first.
+const facetLimit = 20
+
+// GetBookFacets counts the live books matching the filter per genre, tag, author and decade in one query.
+// Genres roll up, a book filed under a subgenre counts once for it and once for every genre above it.
+func (r *PgRepository) GetBookFacets(ctx context.Context, filter BookFilter) (*BookFacets, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetBookFacets", trace.WithAttributes(attribute.String("filter", fmt.Sprintf("%+v", filter))))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	condition, args := filterConditions(filter)
+	args = append(args, facetLimit)
+	limit := fmt.Sprintf(" limit $%d", len(args))
+
+	query := "with recursive matched as (select id, author, tags, " + publicationYearExpression + " as year from books where deleted_at is null" + condition + ")," +
+		" filed as (select book_genres.book_id, book_genres.genre_id from book_genres join matched on matched.id = book_genres.book_id" +
+		" union select filed.book_id, genres.parent_id from filed join genres on genres.id = filed.genre_id where genres.parent_id is not null)" +
+		" (select 'genre' as facet, genres.slug as value, genres.name as label, count(*) as count from filed join genres on genres.id = filed.genre_id group by genres.id order by count(*) desc, genres.name" + limit + ")" +
+		" union all (select 'tag', tag, null, count(*) from matched cross join unnest(matched.tags) as tag group by tag order by count(*) desc, tag" + limit + ")" +
+		" union all (select 'author', author, null, count(*) from matched group by author order by count(*) desc, author" + limit + ")" +
+		" union all (select 'decade', (year / 10 * 10)::text, null, count(*) from matched where year is not null group by year / 10 * 10 order by year / 10 * 10)"
+
+	rows, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	facets := &BookFacets{Genres: []FacetCount{}, Tags: []FacetCount{}, Authors: []FacetCount{}, Decades: []FacetCount{}}
+	var facet string
+	var label *string
+	var count FacetCount
+	_, err = pgx.ForEachRow(rows, []any{&facet, &count.Value, &label, &count.Count}, func() error {
+		count.Label = ""
+		if label != nil {
+			count.Label = *label
+		}
+
+		switch facet {
+		case "genre":
+			facets.Genres = append(facets.Genres, count)
+		case "tag":
+			facets.Tags = append(facets.Tags, count)
+		case "author":
+			facets.Authors = append(facets.Authors, count)
+		case "decade":
+			facets.Decades = append(facets.Decades, count)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return facets, nil
+}
+
+func (r *PgRepository) GetBooksByCursor(ctx context.Context, request CursorPageRequest) (*CursorPage, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetBooksByCursor", trace.WithAttributes(
+			attribute.String("filter", fmt.Sprintf("%+v", request.Filter)),
+			attribute.Int("limit", request.Limit),
+			attribute.String("cursor", fmt.Sprintf("%+v", request.Cursor)),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	condition, args := filterConditions(request.Filter)
+	countArgs := append([]any(nil), args...)
+	countQuery := "select count(*) from books where deleted_at is null" + condition
+	query := selectClause(request.Filter, request.Highlight) + " from books where deleted_at is null" + condition
+
+	backward := request.Cursor != nil && request.Cursor.Backward
+	if request.Cursor != nil {
+		operator := "<"
+		if backward {
+			operator = ">"
+		}
+
+		args = append(args, time.UnixMicro(request.Cursor.CreatedAt).UTC(), request.Cursor.Id)
+		query += fmt.Sprintf(" and (created_at, id) %s ($%d, $%d)", operator, len(args)-1, len(args))
+	}
+
+	if backward {
+		query += " order by created_at asc, id asc"
+	} else {
+		query += " order by created_at desc, id desc"
+	}
+
+	// one extra row tells whether another page exists without a count query
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" limit $%d", len(args))
+
+	var rows pgx.Rows
+	rows, err = connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	var bookRows []bookRow
+	bookRows, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[bookRow])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	books := toDTOs(bookRows)
+
+	hasMore := len(books) > request.Limit
+	if hasMore {
+		books = books[:request.Limit]
+	}
+
+	if backward {
+		slices.Reverse(books)
+	}
+
+	// walking backward we always came from a later page, and the extra row now tells whether an earlier one exists
+	hasNext, hasPrev := hasMore, request.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	page := &CursorPage{Books: books}
+	if len(books) > 0 {
+		if hasNext {
+			page.Next = cursorOf(books[len(books)-1], false)
+		}
+
+		if hasPrev {
+			page.Prev = cursorOf(books[0], true)
+		}
+	}
+
+	if request.IncludeTotal {
+		var totalCount int
+		if err = connection.QueryRow(ctx, countQuery, countArgs...).Scan(&totalCount); err != nil {
+			return nil, mapPgError(err)
+		}
+		page.TotalCount = &totalCount
+	}
+
+	return page, nil
+}
+
+func cursorOf(book BookDTO, backward bool) *BookCursor {
+	return &BookCursor{
+		CreatedAt: book.CreatedAt.UnixMicro(),
+		Id:        book.Id,
+		Backward:  backward,
+	}
+}
+
 func (r *PgRepository) GetBookById(ctx context.Context, id string) (*BookDTO, error) {
 	ctx, span := r.traceProvider.Tracer("bookRepository").Start(ctx, "GetBookById", trace.WithAttributes(attribute.KeyValue{
 		Key:   "bookId",
@@ -190,30 +405,105 @@ func (r *PgRepository) GetBookById(ctx context.Context, id string) (*BookDTO, er
 
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return nil, fiber.ErrInternalServerError
+		return nil, ErrRepositoryUnavailable.WithCause(err)
 	}
 	defer connection.Release()
 
 	var row pgx.Rows
-	row, err = connection.Query(ctx, "select * from books where id = $1 and deleted_at is null", id)
+	row, err = connection.Query(ctx, "select "+bookColumns+" from books where id = $1 and deleted_at is null", id)
 	if err != nil {
-		return nil, fiber.ErrInternalServerError
+		return nil, mapPgError(err)
 	}
 
 	var book BookDTO
 	book, err = pgx.CollectOneRow(row, pgx.RowToStructByNameLax[BookDTO])
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fiber.ErrNotFound
-		}
+		return nil, mapPgError(err)
+	}
+
+	credited, err := loadAuthors(ctx, connection, book.Id)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	book.Authors = credited[book.Id]
+
+	filed, err := loadGenres(ctx, connection, book.Id)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	book.Genres = filed[book.Id]
+
+	if book.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, connection, book.Id); err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return &book, nil
+}
+
+// GetBookByISBN finds the live book stored under the canonical ISBN-13 returned by isbn.Normalize.
+func (r *PgRepository) GetBookByISBN(ctx context.Context, isbn13 string) (*BookDTO, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetBookByISBN", trace.WithAttributes(attribute.String("isbn13", isbn13)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
 
-		return nil, fiber.ErrInternalServerError
+	var row pgx.Rows
+	row, err = connection.Query(ctx, "select "+bookColumns+" from books where isbn13 = $1 and deleted_at is null", isbn13)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	var book BookDTO
+	book, err = pgx.CollectOneRow(row, pgx.RowToStructByNameLax[BookDTO])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	credited, err := loadAuthors(ctx, connection, book.Id)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	book.Authors = credited[book.Id]
+
+	filed, err := loadGenres(ctx, connection, book.Id)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	book.Genres = filed[book.Id]
+
+	if book.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, connection, book.Id); err != nil {
+		return nil, mapPgError(err)
 	}
 
 	return &book, nil
 }
 
-func (r *PgRepository) UpdateBookById(ctx context.Context, id string, book *BookDTO) error {
+// relatedUpdatedAt is the last time an author or genre of the book was changed, nil when none ever was.
+// Linking or unlinking them bumps the book version, so only their own changes need to be found here.
+func relatedUpdatedAt(ctx context.Context, q querier, bookId string) (*time.Time, error) {
+	rows, err := q.Query(
+		ctx,
+		`select greatest(
+			(select max(authors.updated_at) from book_authors join authors on authors.id = book_authors.author_id where book_authors.book_id = $1),
+			(select max(genres.updated_at) from book_genres join genres on genres.id = book_genres.genre_id where book_genres.book_id = $1)
+		)`,
+		bookId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectExactlyOneRow(rows, pgx.RowTo[*time.Time])
+}
+
+// UpdateBookById only applies when the stored version still equals expectedVersion and bumps it,
+// the new version and RelatedUpdatedAt are written back to book.
+func (r *PgRepository) UpdateBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO) error {
 	ctx, span := r.traceProvider.Tracer("bookRepository").
 		Start(ctx,
 			"UpdateBookById",
@@ -230,30 +520,61 @@ func (r *PgRepository) UpdateBookById(ctx context.Context, id string, book *Book
 
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return fiber.ErrInternalServerError
+		return ErrRepositoryUnavailable.WithCause(err)
 	}
 	defer connection.Release()
 
-	cmd, err := connection.Exec(
-		ctx,
-		"update books set title = $1, author = $2, publication_year = $3 where id = $4 and deleted_at is null",
-		book.Title,
-		book.Author,
-		book.PublicationYear,
-		id,
-	)
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		err := updateBook(ctx, tx, id, expectedVersion, book)
+		if err != nil {
+			return err
+		}
+
+		book.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+// PatchBookById writes only the fields named in changed, as JSON names them, and otherwise works like UpdateBookById.
+func (r *PgRepository) PatchBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO, changed []string) error {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx,
+			"PatchBookById",
+			trace.WithAttributes(
+				attribute.String("bookId", id),
+				attribute.StringSlice("changed", changed),
+			),
+		)
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return fiber.ErrInternalServerError
+		return ErrRepositoryUnavailable.WithCause(err)
 	}
+	defer connection.Release()
+
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		err := patchBook(ctx, tx, id, expectedVersion, book, changed)
+		if err != nil {
+			return err
+		}
 
-	if cmd.RowsAffected() == 0 {
-		return fiber.ErrNotFound
+		book.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return mapPgError(err)
 	}
 
 	return nil
 }
 
-func (r *PgRepository) DeleteBookById(ctx context.Context, id string) error {
+func (r *PgRepository) DeleteBookById(ctx context.Context, id string, expectedVersion int) error {
 	ctx, span := r.traceProvider.Tracer("bookRepository").
 		Start(ctx, "DeleteBookById", trace.WithAttributes(attribute.KeyValue{
 			Key:   "bookId",
@@ -263,19 +584,1284 @@ func (r *PgRepository) DeleteBookById(ctx context.Context, id string) error {
 
 	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return fiber.ErrInternalServerError
+		return ErrRepositoryUnavailable.WithCause(err)
 	}
 	defer connection.Release()
 
-	now := time.Now().UTC()
-	cmd, err := connection.Exec(ctx, "update books set deleted_at = $1 where id = $2 and deleted_at is null", now, id)
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		_, err := deleteBook(ctx, tx, id, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+// GetBookHistory pages through the events of a book newest first, it keeps working after the book is deleted.
+func (r *PgRepository) GetBookHistory(ctx context.Context, request HistoryPageRequest) (*HistoryPage, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetBookHistory", trace.WithAttributes(
+			attribute.String("bookId", request.BookId),
+			attribute.Int("limit", request.Limit),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
 	if err != nil {
-		return fiber.ErrInternalServerError
+		return nil, ErrRepositoryUnavailable.WithCause(err)
 	}
+	defer connection.Release()
 
-	if cmd.RowsAffected() == 0 {
-		return fiber.ErrNotFound
+	query := "select " + bookEventColumns + " from book_events where book_id = $1"
+	args := []any{request.BookId}
+	if request.Cursor != nil {
+		args = append(args, request.Cursor.Id)
+		query += fmt.Sprintf(" and id < $%d", len(args))
 	}
 
-	return nil
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" order by id desc limit $%d", len(args))
+
+	var rows pgx.Rows
+	rows, err = connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	var events []BookEvent
+	events, err = pgx.CollectRows(rows, pgx.RowToStructByName[BookEvent])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	// a book with no events yet, like one written before the events table, still has an empty history
+	if len(events) == 0 && request.Cursor == nil {
+		var exists bool
+		if err = connection.QueryRow(ctx, "select exists (select 1 from books where id = $1)", request.BookId).Scan(&exists); err != nil {
+			return nil, mapPgError(err)
+		}
+		if !exists {
+			return nil, ErrBookNotFound
+		}
+	}
+
+	page := &HistoryPage{Events: events}
+	if len(events) > request.Limit {
+		page.Events = events[:request.Limit]
+		page.Next = &HistoryCursor{BookId: request.BookId, Id: page.Events[request.Limit-1].Id}
+	}
+
+	return page, nil
+}
+
+func (r *PgRepository) GetDeletedBooks(ctx context.Context, request DeletedPageRequest) (*DeletedPage, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetDeletedBooks", trace.WithAttributes(
+			attribute.Int("limit", request.Limit),
+			attribute.String("cursor", fmt.Sprintf("%+v", request.Cursor)),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	query := "select " + bookColumns + " from books where deleted_at is not null"
+	args := make([]any, 0, 3)
+	if request.Cursor != nil {
+		args = append(args, time.UnixMicro(request.Cursor.DeletedAt).UTC(), request.Cursor.Id)
+		query += " and (deleted_at, id) < ($1, $2)"
+	}
+
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" order by deleted_at desc, id desc limit $%d", len(args))
+
+	var rows pgx.Rows
+	rows, err = connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	var books []BookDTO
+	books, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[BookDTO])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	page := &DeletedPage{Books: books}
+	if len(books) > request.Limit {
+		page.Books = books[:request.Limit]
+		last := page.Books[request.Limit-1]
+		page.Next = &DeletedBookCursor{DeletedAt: last.DeletedAt.UnixMicro(), Id: last.Id}
+	}
+
+	return page, nil
+}
+
+// GetAuthorBooks pages through the live books crediting an author newest first, optionally in one role only.
+func (r *PgRepository) GetAuthorBooks(ctx context.Context, request AuthorBooksPageRequest) (*AuthorBooksPage, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetAuthorBooks", trace.WithAttributes(
+			attribute.String("authorId", request.AuthorId),
+			attribute.String("role", string(request.Role)),
+			attribute.Int("limit", request.Limit),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	credits := "select book_id from book_authors where author_id = $1"
+	args := []any{request.AuthorId}
+	if request.Role != "" {
+		args = append(args, request.Role)
+		credits += fmt.Sprintf(" and role = $%d", len(args))
+	}
+
+	query := "select " + bookColumns + " from books where deleted_at is null and id in (" + credits + ")"
+	if request.Cursor != nil {
+		args = append(args, time.UnixMicro(request.Cursor.CreatedAt).UTC(), request.Cursor.Id)
+		query += fmt.Sprintf(" and (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" order by created_at desc, id desc limit $%d", len(args))
+
+	var rows pgx.Rows
+	rows, err = connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	var books []BookDTO
+	books, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[BookDTO])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	if len(books) == 0 && request.Cursor == nil {
+		var exists bool
+		if err = connection.QueryRow(ctx, "select exists (select 1 from authors where id = $1)", request.AuthorId).Scan(&exists); err != nil {
+			return nil, mapPgError(err)
+		}
+		if !exists {
+			return nil, author.ErrAuthorNotFound
+		}
+	}
+
+	page := &AuthorBooksPage{Books: books}
+	if len(books) > request.Limit {
+		page.Books = books[:request.Limit]
+		last := page.Books[request.Limit-1]
+		page.Next = &AuthorBooksCursor{AuthorId: request.AuthorId, CreatedAt: last.CreatedAt.UnixMicro(), Id: last.Id}
+	}
+
+	if err = withAuthors(ctx, connection, page.Books); err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return page, nil
+}
+
+// embedLimit caps the editions and series entries embedded in a single book.
+const embedLimit = 100
+
+const editionColumns = "books.id, books.isbn, books.isbn13, books.title, books.publication_year, books.publisher_id, publishers.name as publisher_name"
+
+// EmbedBook loads the records the book refers to into it, only those embed asks for. Editions are the other
+// live books of its work, series entries every live book of its series, the book itself included.
+func (r *PgRepository) EmbedBook(ctx context.Context, book *BookDTO, embed BookEmbed) error {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "EmbedBook", trace.WithAttributes(
+			attribute.String("bookId", book.Id),
+			attribute.String("embed", fmt.Sprintf("%+v", embed)),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	if embed.Publisher && book.PublisherId != nil {
+		rows, err := connection.Query(ctx, "select id, name from publishers where id = $1", *book.PublisherId)
+		if err != nil {
+			return mapPgError(err)
+		}
+		publisher, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[BookPublisher])
+		if err != nil {
+			return mapPgError(err)
+		}
+		book.Publisher = publisher
+	}
+
+	if embed.Editions {
+		rows, err := connection.Query(
+			ctx,
+			"select "+editionColumns+" from books left join publishers on publishers.id = books.publisher_id where books.work_id = $1 and books.id <> $2 and books.deleted_at is null order by books.publication_year, books.created_at, books.id limit $3",
+			book.WorkId,
+			book.Id,
+			embedLimit,
+		)
+		if err != nil {
+			return mapPgError(err)
+		}
+		if book.Editions, err = pgx.CollectRows(rows, pgx.RowToStructByName[work.EditionDTO]); err != nil {
+			return mapPgError(err)
+		}
+	}
+
+	if embed.Series && book.SeriesId != nil {
+		rows, err := connection.Query(ctx, "select id, name from series where id = $1", *book.SeriesId)
+		if err != nil {
+			return mapPgError(err)
+		}
+		series, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[BookSeries])
+		if err != nil {
+			return mapPgError(err)
+		}
+
+		rows, err = connection.Query(
+			ctx,
+			"select id, work_id, title, publication_year, series_position from books where series_id = $1 and deleted_at is null order by series_position nulls last, created_at, id limit $2",
+			series.Id,
+			embedLimit,
+		)
+		if err != nil {
+			return mapPgError(err)
+		}
+		if series.Entries, err = pgx.CollectRows(rows, pgx.RowToStructByName[SeriesEntry]); err != nil {
+			return mapPgError(err)
+		}
+		book.Series = series
+	}
+
+	return nil
+}
+
+// exportFetchSize is how many rows an export holds in memory at a time.
+const exportFetchSize = 1000
+
+// ExportBooks walks the matching books through a server side cursor and hands them to yield one by one,
+// the read only repeatable read transaction keeps the export a consistent snapshot however long it takes.
+// An error from yield stops the export and is returned as is.
+func (r *PgRepository) ExportBooks(ctx context.Context, query ExportBooksQuery, yield func(*BookDTO) error) error {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "ExportBooks", trace.WithAttributes(
+			attribute.String("filter", fmt.Sprintf("%+v", query.Filter)),
+			attribute.String("sort", fmt.Sprintf("%+v", query.Sort)),
+			attribute.Bool("includeDeleted", query.IncludeDeleted),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	condition, args := filterConditions(query.Filter)
+	from := " from books where deleted_at is null"
+	if query.IncludeDeleted {
+		from = " from books where true"
+	}
+	declare := "declare book_export no scroll cursor for select " + bookColumns + from + condition + orderClause(query.Sort, query.Filter)
+	fetch := fmt.Sprintf("fetch forward %d from book_export", exportFetchSize)
+
+	var yieldErr error
+	err = pgx.BeginTxFunc(ctx, connection, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, declare, args...); err != nil {
+			return err
+		}
+
+		for {
+			rows, err := tx.Query(ctx, fetch)
+			if err != nil {
+				return err
+			}
+
+			books, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[BookDTO])
+			if err != nil {
+				return err
+			}
+
+			for _, book := range books {
+				if yieldErr = yield(book); yieldErr != nil {
+					return yieldErr
+				}
+			}
+
+			if len(books) < exportFetchSize {
+				return nil
+			}
+		}
+	})
+	if yieldErr != nil {
+		return yieldErr
+	}
+
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+func (r *PgRepository) RestoreBookById(ctx context.Context, id string, expectedVersion int) (*BookDTO, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "RestoreBookById", trace.WithAttributes(attribute.String("bookId", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	var restored *BookDTO
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		before, err := lockBook(ctx, tx, id, expectedVersion, deletedRows)
+		if err != nil {
+			return err
+		}
+
+		restored, err = queryBook(
+			ctx,
+			tx,
+			"update books set deleted_at = null, version = version + 1 where id = $1 returning "+bookColumns,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+		restored.Authors, restored.Genres = before.Authors, before.Genres
+
+		if restored.RelatedUpdatedAt, err = relatedUpdatedAt(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return insertEvent(ctx, tx, EventRestored, before, restored)
+	})
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return restored, nil
+}
+
+// PurgeBookById removes the row for good whether or not it was soft deleted first, only its events remain.
+// The cover rows it had are returned so the caller can drop their blobs.
+func (r *PgRepository) PurgeBookById(ctx context.Context, id string, expectedVersion int) ([]BookCover, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "PurgeBookById", trace.WithAttributes(attribute.String("bookId", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	var covers []BookCover
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		before, err := lockBook(ctx, tx, id, expectedVersion, anyRows)
+		if err != nil {
+			return err
+		}
+
+		if covers, err = deleteCovers(ctx, tx, []string{id}); err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(ctx, "delete from books where id = $1", id); err != nil {
+			return err
+		}
+
+		return insertEvent(ctx, tx, EventPurged, before, nil)
+	})
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return covers, nil
+}
+
+// PurgeDeletedBooks hard deletes up to batchSize books soft deleted before deletedBefore and returns how many
+// went along with their cover rows. Rows another purger already holds are skipped, so several replicas can
+// run the retention job at once.
+func (r *PgRepository) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time, batchSize int) (int, []BookCover, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "PurgeDeletedBooks", trace.WithAttributes(
+			attribute.String("deletedBefore", deletedBefore.Format(time.RFC3339)),
+			attribute.Int("batchSize", batchSize),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return 0, nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	var (
+		purged []BookDTO
+		covers []BookCover
+	)
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		rows, err := tx.Query(
+			ctx,
+			"select id from books where deleted_at < $1 order by deleted_at limit $2 for update skip locked",
+			deletedBefore,
+			batchSize,
+		)
+		if err != nil {
+			return err
+		}
+
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+
+		// the cover rows would go with the books anyway, deleting them first is how their blobs are found
+		if covers, err = deleteCovers(ctx, tx, ids); err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx, "delete from books where id = any($1) returning "+bookColumns, ids)
+		if err != nil {
+			return err
+		}
+
+		purged, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[BookDTO])
+		if err != nil {
+			return err
+		}
+
+		for i := range purged {
+			if err = insertEvent(ctx, tx, EventPurged, &purged[i], nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, nil, mapPgError(err)
+	}
+
+	return len(purged), covers, nil
+}
+
+func deleteCovers(ctx context.Context, tx pgx.Tx, bookIds []string) ([]BookCover, error) {
+	rows, err := tx.Query(ctx, "delete from book_covers where book_id = any($1) returning "+coverColumns, bookIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[BookCover])
+}
+
+// GetBookCover returns the uploaded cover of a live book, ErrCoverNotFound also covers a missing book.
+func (r *PgRepository) GetBookCover(ctx context.Context, bookId string) (*BookCover, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetBookCover", trace.WithAttributes(attribute.String("bookId", bookId)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(
+		ctx,
+		"select "+coverColumns+" from book_covers join books on books.id = book_covers.book_id where book_covers.book_id = $1 and books.deleted_at is null",
+		bookId,
+	)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	cover, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[BookCover])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCoverNotFound.WithCause(err)
+	}
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return cover, nil
+}
+
+// SaveBookCover replaces the cover of a live book and returns the one it replaced, if any,
+// so the caller can drop its blobs. The upload time is written back to cover. A mirrored cover,
+// one with a SourceUrl, never replaces an upload and gets ErrCoverUploaded along with the upload instead.
+func (r *PgRepository) SaveBookCover(ctx context.Context, cover *BookCover) (*BookCover, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "SaveBookCover", trace.WithAttributes(
+			attribute.String("bookId", cover.BookId),
+			attribute.String("digest", cover.Digest),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	var previous *BookCover
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		// the share lock keeps the book from being deleted until the cover row is in
+		if _, err := queryBook(ctx, tx, "select "+bookColumns+" from books where id = $1 and deleted_at is null for share", cover.BookId); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "select "+coverColumns+" from book_covers where book_id = $1 for update", cover.BookId)
+		if err != nil {
+			return err
+		}
+		previous, err = pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[BookCover])
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if previous != nil && previous.SourceUrl == nil && cover.SourceUrl != nil {
+			return ErrCoverUploaded
+		}
+
+		return tx.QueryRow(
+			ctx,
+			`insert into book_covers (book_id, digest, content_type, thumbnail_type, width, height, byte_size, sizes, source_url)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			on conflict (book_id) do update set digest = excluded.digest, content_type = excluded.content_type,
+				thumbnail_type = excluded.thumbnail_type, width = excluded.width, height = excluded.height,
+				byte_size = excluded.byte_size, sizes = excluded.sizes, source_url = excluded.source_url, uploaded_at = now()
+			returning uploaded_at`,
+			cover.BookId, cover.Digest, cover.ContentType, cover.ThumbnailType, cover.Width, cover.Height, cover.ByteSize, cover.Sizes, cover.SourceUrl,
+		).Scan(&cover.UploadedAt)
+	})
+	if errors.Is(err, ErrCoverUploaded) {
+		return previous, err
+	}
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return previous, nil
+}
+
+// DeleteBookCover removes the cover row and returns it so the caller can drop its blobs.
+func (r *PgRepository) DeleteBookCover(ctx context.Context, bookId string) (*BookCover, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "DeleteBookCover", trace.WithAttributes(attribute.String("bookId", bookId)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(
+		ctx,
+		"delete from book_covers using books where books.id = book_covers.book_id and book_covers.book_id = $1 and books.deleted_at is null returning "+coverColumns,
+		bookId,
+	)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	cover, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[BookCover])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCoverNotFound.WithCause(err)
+	}
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return cover, nil
+}
+
+// GetDueCoverChecks lists live books with a cover url that was never checked, changed since its last check
+// or was last checked before checkedBefore, the longest unchecked first.
+func (r *PgRepository) GetDueCoverChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]CoverCheckTarget, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetDueCoverChecks", trace.WithAttributes(
+			attribute.String("checkedBefore", checkedBefore.Format(time.RFC3339)),
+			attribute.Int("limit", limit),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(
+		ctx,
+		`select books.id as book_id, books.cover_url, book_covers.book_id is not null as has_cover, book_covers.source_url as mirrored_url
+		from books
+		left join cover_checks on cover_checks.book_id = books.id
+		left join book_covers on book_covers.book_id = books.id
+		where books.deleted_at is null and books.cover_url <> ''
+			and (cover_checks.book_id is null or cover_checks.cover_url <> books.cover_url or cover_checks.checked_at < $1)
+		order by cover_checks.checked_at nulls first, books.id
+		limit $2`,
+		checkedBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	targets, err := pgx.CollectRows(rows, pgx.RowToStructByName[CoverCheckTarget])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return targets, nil
+}
+
+// SaveCoverCheck records the outcome of a check and keeps count of the failures in a row, a check of
+// another url than the one checked before starts counting over. Checks of purged books are dropped.
+func (r *PgRepository) SaveCoverCheck(ctx context.Context, check *CoverCheck) error {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "SaveCoverCheck", trace.WithAttributes(
+			attribute.String("bookId", check.BookId),
+			attribute.String("outcome", string(check.Outcome)),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(
+		ctx,
+		`insert into cover_checks (book_id, cover_url, outcome, status_code, content_type, error, failures, failing_since, checked_at)
+		select id, $2::varchar, $3::varchar, $4::integer, $5::varchar, $6::text,
+			case when $3 = 'ok' then 0 else 1 end, case when $3 = 'ok' then null else $7::timestamptz end, $7::timestamptz
+		from books where id = $1
+		on conflict (book_id) do update set cover_url = excluded.cover_url, outcome = excluded.outcome,
+			status_code = excluded.status_code, content_type = excluded.content_type, error = excluded.error,
+			failures = case
+				when excluded.outcome = 'ok' then 0
+				when cover_checks.cover_url <> excluded.cover_url then 1
+				else cover_checks.failures + 1
+			end,
+			failing_since = case
+				when excluded.outcome = 'ok' then null
+				when cover_checks.cover_url <> excluded.cover_url or cover_checks.failing_since is null then excluded.checked_at
+				else cover_checks.failing_since
+			end,
+			checked_at = excluded.checked_at
+		returning failures, failing_since`,
+		check.BookId, check.CoverUrl, check.Outcome, check.StatusCode, check.ContentType, check.Error, check.CheckedAt,
+	)
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&check.Failures, &check.FailingSince}, func() error { return nil })
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+// GetFailingCovers pages through the live books whose current cover url failed its last check, longest failing first.
+func (r *PgRepository) GetFailingCovers(ctx context.Context, request FailingCoversPageRequest) (*FailingCoversPage, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "GetFailingCovers", trace.WithAttributes(
+			attribute.Int("limit", request.Limit),
+			attribute.String("cursor", fmt.Sprintf("%+v", request.Cursor)),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	query := `select cover_checks.book_id, books.title, cover_checks.cover_url, outcome, status_code, content_type, error,
+		failures, failing_since, checked_at
+		from cover_checks join books on books.id = cover_checks.book_id
+		where ` + failingCoverCondition
+	args := make([]any, 0, 3)
+	if request.Cursor != nil {
+		args = append(args, time.UnixMicro(request.Cursor.FailingSince).UTC(), request.Cursor.Id)
+		query += " and (failing_since, cover_checks.book_id) > ($1, $2)"
+	}
+
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" order by failing_since, cover_checks.book_id limit $%d", len(args))
+
+	rows, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	covers, err := pgx.CollectRows(rows, pgx.RowToStructByName[CoverCheck])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	page := &FailingCoversPage{Covers: covers}
+	if len(covers) > request.Limit {
+		page.Covers = covers[:request.Limit]
+		last := page.Covers[request.Limit-1]
+		page.Next = &FailingCoverCursor{FailingSince: last.FailingSince.UnixMicro(), Id: last.BookId}
+	}
+
+	return page, nil
+}
+
+// CountFailingCovers counts the books GetFailingCovers lists by the outcome of their last check.
+func (r *PgRepository) CountFailingCovers(ctx context.Context) (map[CoverCheckOutcome]int, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").Start(ctx, "CountFailingCovers")
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(
+		ctx,
+		"select outcome, count(*) from cover_checks join books on books.id = cover_checks.book_id where "+failingCoverCondition+" group by outcome",
+	)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	counts := make(map[CoverCheckOutcome]int)
+	var outcome CoverCheckOutcome
+	var count int
+	_, err = pgx.ForEachRow(rows, []any{&outcome, &count}, func() error {
+		counts[outcome] = count
+		return nil
+	})
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return counts, nil
+}
+
+// BulkWrite applies the writes in order on a single connection and reports an error per write. Atomic runs them
+// in one transaction that stops at the first failure, otherwise every write gets its own savepoint so a failing
+// one leaves the others in place. The resulting rows are written back to each write's Book.
+func (r *PgRepository) BulkWrite(ctx context.Context, writes []BulkWrite, atomic bool) ([]error, error) {
+	ctx, span := r.traceProvider.Tracer("bookRepository").
+		Start(ctx, "BulkWrite", trace.WithAttributes(
+			attribute.Int("writes", len(writes)),
+			attribute.Bool("atomic", atomic),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	results := make([]error, len(writes))
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		if atomic && onlyCreates(writes) {
+			index, err := copyBooks(ctx, tx, writes)
+			if index >= 0 {
+				results[index] = mapPgError(err)
+				return results[index]
+			}
+			return err
+		}
+
+		for i := range writes {
+			if atomic {
+				if err := applyWrite(ctx, tx, &writes[i]); err != nil {
+					results[i] = mapPgError(err)
+					return results[i]
+				}
+				continue
+			}
+
+			if err := pgx.BeginFunc(ctx, tx, func(savepoint pgx.Tx) error {
+				return applyWrite(ctx, savepoint, &writes[i])
+			}); err != nil {
+				results[i] = mapPgError(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return results, mapPgError(err)
+	}
+
+	return results, nil
+}
+
+func onlyCreates(writes []BulkWrite) bool {
+	for _, write := range writes {
+		if write.Op != BulkCreate {
+			return false
+		}
+	}
+
+	return true
+}
+
+func applyWrite(ctx context.Context, tx pgx.Tx, write *BulkWrite) error {
+	switch write.Op {
+	case BulkCreate:
+		return createBook(ctx, tx, write.Book)
+	case BulkUpdate:
+		return updateBook(ctx, tx, write.Book.Id, write.ExpectedVersion, write.Book)
+	case BulkDelete:
+		deleted, err := deleteBook(ctx, tx, write.Book.Id, write.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		*write.Book = *deleted
+		return nil
+	}
+
+	return fmt.Errorf("unsupported bulk operation %q", write.Op)
+}
+
+// copyBooks streams a batch of new books and their created events with COPY, the fast path for catalogue loads.
+// An error that belongs to one of the writes comes with its index, other errors with -1.
+func copyBooks(ctx context.Context, tx pgx.Tx, writes []BulkWrite) (int, error) {
+	now := time.Now().UTC()
+	credits := make([][]BookAuthor, len(writes))
+	for i, write := range writes {
+		var err error
+		if credits[i], err = prepareCredits(ctx, tx, write.Book); err != nil {
+			return i, err
+		}
+
+		write.Book.Version = 1
+		write.Book.CreatedAt = &now
+		// COPY returns nothing, the trigger sets the same value in the row
+		if isbn13, err := isbn.Normalize(write.Book.ISBN); err == nil {
+			write.Book.ISBN13 = &isbn13
+		}
+		if write.Book.Tags == nil {
+			write.Book.Tags = []string{}
+		}
+	}
+
+	if index, err := checkCopiedISBNs(ctx, tx, writes); err != nil {
+		return index, err
+	}
+
+	if _, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"books"},
+		[]string{"id", "cover_url", "isbn", "title", "author", "publication_year", "version", "created_at", "work_id", "publisher_id", "series_id", "series_position", "tags"},
+		pgx.CopyFromSlice(len(writes), func(i int) ([]any, error) {
+			book := writes[i].Book
+			var workId *string
+			if book.WorkId != "" {
+				workId = &book.WorkId
+			}
+			return []any{book.Id, book.CoverUrl, book.ISBN, book.Title, book.Author, book.PublicationYear, book.Version, now, workId, book.PublisherId, book.SeriesId, book.SeriesPosition, book.Tags}, nil
+		}),
+	); err != nil {
+		return -1, err
+	}
+
+	if err := copyWorks(ctx, tx, writes); err != nil {
+		return -1, err
+	}
+
+	if err := copyCredits(ctx, tx, writes, credits); err != nil {
+		return -1, err
+	}
+
+	if err := copyGenres(ctx, tx, writes); err != nil {
+		return -1, err
+	}
+
+	audit := auditOf(ctx)
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"book_events"},
+		[]string{"book_id", "event_type", "version", "actor", "trace_id", "after"},
+		pgx.CopyFromSlice(len(writes), func(i int) ([]any, error) {
+			book := writes[i].Book
+			return []any{book.Id, EventCreated, book.Version, audit.Actor, audit.TraceId, book}, nil
+		}),
+	)
+
+	return -1, err
+}
+
+// checkCopiedISBNs finds the first write whose ISBN a live book or an earlier write already holds, COPY would
+// only fail the batch as a whole. A book taken by a live one names it in meta.bookId like a single create does.
+func checkCopiedISBNs(ctx context.Context, tx pgx.Tx, writes []BulkWrite) (int, error) {
+	isbns := make([]string, 0, len(writes))
+	for _, write := range writes {
+		if write.Book.ISBN13 != nil {
+			isbns = append(isbns, *write.Book.ISBN13)
+		}
+	}
+	if len(isbns) == 0 {
+		return -1, nil
+	}
+
+	rows, err := tx.Query(ctx, "select isbn13, id from books where isbn13 = any($1) and deleted_at is null", isbns)
+	if err != nil {
+		return -1, err
+	}
+
+	taken := make(map[string]string, len(isbns))
+	var isbn13, bookId string
+	if _, err = pgx.ForEachRow(rows, []any{&isbn13, &bookId}, func() error {
+		taken[isbn13] = bookId
+		return nil
+	}); err != nil {
+		return -1, err
+	}
+
+	seen := make(map[string]struct{}, len(isbns))
+	for i, write := range writes {
+		if write.Book.ISBN13 == nil {
+			continue
+		}
+
+		if existingId, ok := taken[*write.Book.ISBN13]; ok {
+			return i, ErrDuplicateISBN.WithMeta("bookId", existingId)
+		}
+		if _, ok := seen[*write.Book.ISBN13]; ok {
+			return i, ErrDuplicateISBN
+		}
+		seen[*write.Book.ISBN13] = struct{}{}
+	}
+
+	return -1, nil
+}
+
+// copyWorks reads back the works the books_work trigger gave the books of a batch copied without one.
+func copyWorks(ctx context.Context, tx pgx.Tx, writes []BulkWrite) error {
+	byId := make(map[string]*BookDTO, len(writes))
+	bookIds := make([]string, 0, len(writes))
+	for _, write := range writes {
+		if write.Book.WorkId == "" {
+			byId[write.Book.Id] = write.Book
+			bookIds = append(bookIds, write.Book.Id)
+		}
+	}
+
+	if len(bookIds) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, "select id, work_id from books where id = any($1::uuid[])", bookIds)
+	if err != nil {
+		return err
+	}
+
+	var bookId, workId string
+	_, err = pgx.ForEachRow(rows, []any{&bookId, &workId}, func() error {
+		byId[bookId].WorkId = workId
+		return nil
+	})
+
+	return err
+}
+
+// copyCredits credits a batch copied by copyBooks, the explicit credits with COPY and the rest by splitting
+// their author text, then reads them back for the created events.
+func copyCredits(ctx context.Context, tx pgx.Tx, writes []BulkWrite, credits [][]BookAuthor) error {
+	bookIds := make([]string, 0, len(writes))
+	var split []string
+	var rows [][]any
+	for i, write := range writes {
+		bookIds = append(bookIds, write.Book.Id)
+		if credits[i] == nil {
+			split = append(split, write.Book.Id)
+			continue
+		}
+		for position, credit := range credits[i] {
+			rows = append(rows, []any{write.Book.Id, credit.Id, string(credit.Role), int32(position + 1)})
+		}
+	}
+
+	if len(split) > 0 {
+		if _, err := tx.Exec(ctx, "select book_credit_authors(id) from unnest($1::uuid[]) as books(id)", split); err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 {
+		if _, err := tx.CopyFrom(
+			ctx,
+			pgx.Identifier{"book_authors"},
+			[]string{"book_id", "author_id", "role", "position"},
+			pgx.CopyFromRows(rows),
+		); err != nil {
+			return err
+		}
+	}
+
+	credited, err := loadAuthors(ctx, tx, bookIds...)
+	if err != nil {
+		return err
+	}
+
+	for _, write := range writes {
+		write.Book.Authors = credited[write.Book.Id]
+	}
+
+	return nil
+}
+
+// createBook, updateBook and deleteBook are the writes shared by the single and bulk endpoints,
+// they always run inside the caller's transaction next to the event they record.
+func createBook(ctx context.Context, tx pgx.Tx, book *BookDTO) error {
+	credits, err := prepareCredits(ctx, tx, book)
+	if err != nil {
+		return err
+	}
+
+	// isbn13 is filled in by the books_isbn13 trigger, a live book with the same ISBN skips the insert
+	// instead of failing it so the transaction is still usable to look that book up
+	created, err := queryBook(
+		ctx,
+		tx,
+		"insert into books (id, cover_url, isbn, title, author, publication_year, created_at, work_id, publisher_id, series_id, series_position, tags) values ($1, $2, $3, $4, $5, $6, $7, nullif($8, '')::uuid, $9, $10, $11, coalesce($12::text[], '{}')) on conflict (isbn13) where deleted_at is null do nothing returning "+bookColumns,
+		book.Id,
+		book.CoverUrl,
+		book.ISBN,
+		book.Title,
+		book.Author,
+		book.PublicationYear,
+		time.Now().UTC(),
+		book.WorkId,
+		book.PublisherId,
+		book.SeriesId,
+		book.SeriesPosition,
+		book.Tags,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var existingId string
+		if err = tx.QueryRow(ctx, "select id from books where isbn13 = book_isbn13($1) and deleted_at is null", book.ISBN).Scan(&existingId); err != nil {
+			return err
+		}
+		return ErrDuplicateISBN.WithMeta("bookId", existingId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if created.Authors, err = creditAuthors(ctx, tx, created.Id, credits); err != nil {
+		return err
+	}
+
+	if len(book.Genres) > 0 {
+		if created.Genres, err = fileGenres(ctx, tx, created.Id, book.Genres); err != nil {
+			return err
+		}
+	}
+
+	*book = *created
+	return insertEvent(ctx, tx, EventCreated, nil, created)
+}
+
+func updateBook(ctx context.Context, tx pgx.Tx, id string, expectedVersion int, book *BookDTO) error {
+	before, err := lockBook(ctx, tx, id, expectedVersion, liveRows)
+	if err != nil {
+		return err
+	}
+
+	credits, err := prepareCredits(ctx, tx, book)
+	if err != nil {
+		return err
+	}
+
+	updated, err := queryBook(
+		ctx,
+		tx,
+		"update books set cover_url = $1, isbn = $2, title = $3, author = $4, publication_year = $5, work_id = coalesce(nullif($6, '')::uuid, work_id), publisher_id = $7, series_id = $8, series_position = $9, tags = coalesce($10::text[], '{}'), updated_at = coalesce($11::timestamptz, now()), version = version + 1 where id = $12 returning "+bookColumns,
+		book.CoverUrl,
+		book.ISBN,
+		book.Title,
+		book.Author,
+		book.PublicationYear,
+		book.WorkId,
+		book.PublisherId,
+		book.SeriesId,
+		book.SeriesPosition,
+		book.Tags,
+		book.UpdatedAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	// credits stay as they are unless the update credits explicitly or changes the author text
+	updated.Authors = before.Authors
+	if credits != nil || updated.Author != before.Author {
+		if updated.Authors, err = creditAuthors(ctx, tx, id, credits); err != nil {
+			return err
+		}
+	}
+
+	if updated.Genres, err = fileGenres(ctx, tx, id, book.Genres); err != nil {
+		return err
+	}
+
+	*book = *updated
+	return insertEvent(ctx, tx, EventUpdated, before, updated)
+}
+
+// patchBook credits the book anew only when its credits or author text changed and refiles it only when
+// its genres changed, the update itself sets nothing but the changed columns.
+func patchBook(ctx context.Context, tx pgx.Tx, id string, expectedVersion int, book *BookDTO, changed []string) error {
+	before, err := lockBook(ctx, tx, id, expectedVersion, liveRows)
+	if err != nil {
+		return err
+	}
+
+	var credits []BookAuthor
+	if slices.Contains(changed, "authors") {
+		if credits, err = prepareCredits(ctx, tx, book); err != nil {
+			return err
+		}
+		// a blank author text is filled in from the new credits
+		if book.Author != before.Author && !slices.Contains(changed, "author") {
+			changed = append(slices.Clip(changed), "author")
+		}
+	}
+
+	sets := []string{"updated_at = coalesce($1::timestamptz, now())", "version = version + 1"}
+	args := []any{book.UpdatedAt}
+	for _, field := range changed {
+		column, ok := patchColumns[field]
+		if !ok {
+			continue
+		}
+
+		args = append(args, column.value(book))
+		sets = append(sets, fmt.Sprintf(column.set, fmt.Sprintf("$%d", len(args))))
+	}
+	args = append(args, id)
+
+	updated, err := queryBook(
+		ctx,
+		tx,
+		fmt.Sprintf("update books set %s where id = $%d returning %s", strings.Join(sets, ", "), len(args), bookColumns),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated.Authors, updated.Genres = before.Authors, before.Genres
+	if slices.Contains(changed, "authors") || slices.Contains(changed, "author") {
+		if updated.Authors, err = creditAuthors(ctx, tx, id, credits); err != nil {
+			return err
+		}
+	}
+
+	if slices.Contains(changed, "genreIds") {
+		if updated.Genres, err = fileGenres(ctx, tx, id, book.Genres); err != nil {
+			return err
+		}
+	}
+
+	*book = *updated
+	return insertEvent(ctx, tx, EventUpdated, before, updated)
+}
+
+func deleteBook(ctx context.Context, tx pgx.Tx, id string, expectedVersion int) (*BookDTO, error) {
+	before, err := lockBook(ctx, tx, id, expectedVersion, liveRows)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := queryBook(
+		ctx,
+		tx,
+		"update books set deleted_at = $1, version = version + 1 where id = $2 returning "+bookColumns,
+		time.Now().UTC(),
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deleted.Authors, deleted.Genres = before.Authors, before.Genres
+
+	return deleted, insertEvent(ctx, tx, EventDeleted, before, deleted)
+}
+
+// rowState narrows lockBook to live books, the trash, or both.
+type rowState string
+
+const (
+	liveRows    rowState = "deleted_at is null"
+	deletedRows rowState = "deleted_at is not null"
+	anyRows     rowState = "true"
+)
+
+// lockBook holds the row until the transaction ends and tells apart a write that missed because
+// the book is gone from one that lost the race against another writer. With anyVersion only the
+// book has to exist.
+func lockBook(ctx context.Context, tx pgx.Tx, id string, expectedVersion int, state rowState) (*BookDTO, error) {
+	book, err := queryBook(ctx, tx, "select "+bookColumns+" from books where id = $1 and "+string(state)+" for update", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != anyVersion && book.Version != expectedVersion {
+		return nil, ErrVersionMismatch.WithMeta("currentVersion", book.Version)
+	}
+
+	credited, err := loadAuthors(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	book.Authors = credited[id]
+
+	filed, err := loadGenres(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	book.Genres = filed[id]
+
+	return book, nil
+}
+
+func queryBook(ctx context.Context, tx pgx.Tx, sql string, args ...any) (*BookDTO, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[BookDTO])
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
 }
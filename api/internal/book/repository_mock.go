@@ -12,6 +12,7 @@ package book
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,36 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// BulkWrite mocks base method.
+func (m *MockRepository) BulkWrite(ctx context.Context, writes []BulkWrite, atomic bool) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkWrite", ctx, writes, atomic)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkWrite indicates an expected call of BulkWrite.
+func (mr *MockRepositoryMockRecorder) BulkWrite(ctx, writes, atomic any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkWrite", reflect.TypeOf((*MockRepository)(nil).BulkWrite), ctx, writes, atomic)
+}
+
+// CountFailingCovers mocks base method.
+func (m *MockRepository) CountFailingCovers(ctx context.Context) (map[CoverCheckOutcome]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFailingCovers", ctx)
+	ret0, _ := ret[0].(map[CoverCheckOutcome]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFailingCovers indicates an expected call of CountFailingCovers.
+func (mr *MockRepositoryMockRecorder) CountFailingCovers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFailingCovers", reflect.TypeOf((*MockRepository)(nil).CountFailingCovers), ctx)
+}
+
 // CreateBook mocks base method.
 func (m *MockRepository) CreateBook(ctx context.Context, book *BookDTO) error {
 	m.ctrl.T.Helper()
@@ -55,17 +86,90 @@ func (mr *MockRepositoryMockRecorder) CreateBook(ctx, book any) *gomock.Call {
 }
 
 // DeleteBookById mocks base method.
-func (m *MockRepository) DeleteBookById(ctx context.Context, id string) error {
+func (m *MockRepository) DeleteBookById(ctx context.Context, id string, expectedVersion int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteBookById", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteBookById", ctx, id, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteBookById indicates an expected call of DeleteBookById.
-func (mr *MockRepositoryMockRecorder) DeleteBookById(ctx, id any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DeleteBookById(ctx, id, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBookById", reflect.TypeOf((*MockRepository)(nil).DeleteBookById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBookById", reflect.TypeOf((*MockRepository)(nil).DeleteBookById), ctx, id, expectedVersion)
+}
+
+// DeleteBookCover mocks base method.
+func (m *MockRepository) DeleteBookCover(ctx context.Context, bookId string) (*BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBookCover", ctx, bookId)
+	ret0, _ := ret[0].(*BookCover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBookCover indicates an expected call of DeleteBookCover.
+func (mr *MockRepositoryMockRecorder) DeleteBookCover(ctx, bookId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBookCover", reflect.TypeOf((*MockRepository)(nil).DeleteBookCover), ctx, bookId)
+}
+
+// EmbedBook mocks base method.
+func (m *MockRepository) EmbedBook(ctx context.Context, book *BookDTO, embed BookEmbed) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmbedBook", ctx, book, embed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EmbedBook indicates an expected call of EmbedBook.
+func (mr *MockRepositoryMockRecorder) EmbedBook(ctx, book, embed any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmbedBook", reflect.TypeOf((*MockRepository)(nil).EmbedBook), ctx, book, embed)
+}
+
+// ExportBooks mocks base method.
+func (m *MockRepository) ExportBooks(ctx context.Context, query ExportBooksQuery, yield func(*BookDTO) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBooks", ctx, query, yield)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportBooks indicates an expected call of ExportBooks.
+func (mr *MockRepositoryMockRecorder) ExportBooks(ctx, query, yield any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBooks", reflect.TypeOf((*MockRepository)(nil).ExportBooks), ctx, query, yield)
+}
+
+// GetAuthorBooks mocks base method.
+func (m *MockRepository) GetAuthorBooks(ctx context.Context, request AuthorBooksPageRequest) (*AuthorBooksPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorBooks", ctx, request)
+	ret0, _ := ret[0].(*AuthorBooksPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthorBooks indicates an expected call of GetAuthorBooks.
+func (mr *MockRepositoryMockRecorder) GetAuthorBooks(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorBooks", reflect.TypeOf((*MockRepository)(nil).GetAuthorBooks), ctx, request)
+}
+
+// GetBookByISBN mocks base method.
+func (m *MockRepository) GetBookByISBN(ctx context.Context, isbn13 string) (*BookDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByISBN", ctx, isbn13)
+	ret0, _ := ret[0].(*BookDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByISBN indicates an expected call of GetBookByISBN.
+func (mr *MockRepositoryMockRecorder) GetBookByISBN(ctx, isbn13 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByISBN", reflect.TypeOf((*MockRepository)(nil).GetBookByISBN), ctx, isbn13)
 }
 
 // GetBookById mocks base method.
@@ -83,10 +187,55 @@ func (mr *MockRepositoryMockRecorder) GetBookById(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookById", reflect.TypeOf((*MockRepository)(nil).GetBookById), ctx, id)
 }
 
+// GetBookCover mocks base method.
+func (m *MockRepository) GetBookCover(ctx context.Context, bookId string) (*BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookCover", ctx, bookId)
+	ret0, _ := ret[0].(*BookCover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookCover indicates an expected call of GetBookCover.
+func (mr *MockRepositoryMockRecorder) GetBookCover(ctx, bookId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookCover", reflect.TypeOf((*MockRepository)(nil).GetBookCover), ctx, bookId)
+}
+
+// GetBookFacets mocks base method.
+func (m *MockRepository) GetBookFacets(ctx context.Context, filter BookFilter) (*BookFacets, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookFacets", ctx, filter)
+	ret0, _ := ret[0].(*BookFacets)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookFacets indicates an expected call of GetBookFacets.
+func (mr *MockRepositoryMockRecorder) GetBookFacets(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookFacets", reflect.TypeOf((*MockRepository)(nil).GetBookFacets), ctx, filter)
+}
+
+// GetBookHistory mocks base method.
+func (m *MockRepository) GetBookHistory(ctx context.Context, request HistoryPageRequest) (*HistoryPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookHistory", ctx, request)
+	ret0, _ := ret[0].(*HistoryPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookHistory indicates an expected call of GetBookHistory.
+func (mr *MockRepositoryMockRecorder) GetBookHistory(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookHistory", reflect.TypeOf((*MockRepository)(nil).GetBookHistory), ctx, request)
+}
+
 // GetBooks mocks base method.
-func (m *MockRepository) GetBooks(ctx context.Context, page, pageSize int, search string) (*[]BookDTO, int, error) {
+func (m *MockRepository) GetBooks(ctx context.Context, query ListBooksQuery) (*[]BookDTO, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBooks", ctx, page, pageSize, search)
+	ret := m.ctrl.Call(m, "GetBooks", ctx, query)
 	ret0, _ := ret[0].(*[]BookDTO)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -94,21 +243,170 @@ func (m *MockRepository) GetBooks(ctx context.Context, page, pageSize int, searc
 }
 
 // GetBooks indicates an expected call of GetBooks.
-func (mr *MockRepositoryMockRecorder) GetBooks(ctx, page, pageSize, search any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetBooks(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockRepository)(nil).GetBooks), ctx, query)
+}
+
+// GetBooksByCursor mocks base method.
+func (m *MockRepository) GetBooksByCursor(ctx context.Context, request CursorPageRequest) (*CursorPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBooksByCursor", ctx, request)
+	ret0, _ := ret[0].(*CursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBooksByCursor indicates an expected call of GetBooksByCursor.
+func (mr *MockRepositoryMockRecorder) GetBooksByCursor(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooksByCursor", reflect.TypeOf((*MockRepository)(nil).GetBooksByCursor), ctx, request)
+}
+
+// GetDeletedBooks mocks base method.
+func (m *MockRepository) GetDeletedBooks(ctx context.Context, request DeletedPageRequest) (*DeletedPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedBooks", ctx, request)
+	ret0, _ := ret[0].(*DeletedPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedBooks indicates an expected call of GetDeletedBooks.
+func (mr *MockRepositoryMockRecorder) GetDeletedBooks(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedBooks", reflect.TypeOf((*MockRepository)(nil).GetDeletedBooks), ctx, request)
+}
+
+// GetDueCoverChecks mocks base method.
+func (m *MockRepository) GetDueCoverChecks(ctx context.Context, checkedBefore time.Time, limit int) ([]CoverCheckTarget, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueCoverChecks", ctx, checkedBefore, limit)
+	ret0, _ := ret[0].([]CoverCheckTarget)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueCoverChecks indicates an expected call of GetDueCoverChecks.
+func (mr *MockRepositoryMockRecorder) GetDueCoverChecks(ctx, checkedBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueCoverChecks", reflect.TypeOf((*MockRepository)(nil).GetDueCoverChecks), ctx, checkedBefore, limit)
+}
+
+// GetFailingCovers mocks base method.
+func (m *MockRepository) GetFailingCovers(ctx context.Context, request FailingCoversPageRequest) (*FailingCoversPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailingCovers", ctx, request)
+	ret0, _ := ret[0].(*FailingCoversPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailingCovers indicates an expected call of GetFailingCovers.
+func (mr *MockRepositoryMockRecorder) GetFailingCovers(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailingCovers", reflect.TypeOf((*MockRepository)(nil).GetFailingCovers), ctx, request)
+}
+
+// PatchBookById mocks base method.
+func (m *MockRepository) PatchBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO, changed []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchBookById", ctx, id, expectedVersion, book, changed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchBookById indicates an expected call of PatchBookById.
+func (mr *MockRepositoryMockRecorder) PatchBookById(ctx, id, expectedVersion, book, changed any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchBookById", reflect.TypeOf((*MockRepository)(nil).PatchBookById), ctx, id, expectedVersion, book, changed)
+}
+
+// PurgeBookById mocks base method.
+func (m *MockRepository) PurgeBookById(ctx context.Context, id string, expectedVersion int) ([]BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeBookById", ctx, id, expectedVersion)
+	ret0, _ := ret[0].([]BookCover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeBookById indicates an expected call of PurgeBookById.
+func (mr *MockRepositoryMockRecorder) PurgeBookById(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeBookById", reflect.TypeOf((*MockRepository)(nil).PurgeBookById), ctx, id, expectedVersion)
+}
+
+// PurgeDeletedBooks mocks base method.
+func (m *MockRepository) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time, batchSize int) (int, []BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedBooks", ctx, deletedBefore, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].([]BookCover)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PurgeDeletedBooks indicates an expected call of PurgeDeletedBooks.
+func (mr *MockRepositoryMockRecorder) PurgeDeletedBooks(ctx, deletedBefore, batchSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedBooks", reflect.TypeOf((*MockRepository)(nil).PurgeDeletedBooks), ctx, deletedBefore, batchSize)
+}
+
+// RestoreBookById mocks base method.
+func (m *MockRepository) RestoreBookById(ctx context.Context, id string, expectedVersion int) (*BookDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBookById", ctx, id, expectedVersion)
+	ret0, _ := ret[0].(*BookDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBookById indicates an expected call of RestoreBookById.
+func (mr *MockRepositoryMockRecorder) RestoreBookById(ctx, id, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBookById", reflect.TypeOf((*MockRepository)(nil).RestoreBookById), ctx, id, expectedVersion)
+}
+
+// SaveBookCover mocks base method.
+func (m *MockRepository) SaveBookCover(ctx context.Context, cover *BookCover) (*BookCover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBookCover", ctx, cover)
+	ret0, _ := ret[0].(*BookCover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveBookCover indicates an expected call of SaveBookCover.
+func (mr *MockRepositoryMockRecorder) SaveBookCover(ctx, cover any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBookCover", reflect.TypeOf((*MockRepository)(nil).SaveBookCover), ctx, cover)
+}
+
+// SaveCoverCheck mocks base method.
+func (m *MockRepository) SaveCoverCheck(ctx context.Context, check *CoverCheck) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCoverCheck", ctx, check)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCoverCheck indicates an expected call of SaveCoverCheck.
+func (mr *MockRepositoryMockRecorder) SaveCoverCheck(ctx, check any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockRepository)(nil).GetBooks), ctx, page, pageSize, search)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCoverCheck", reflect.TypeOf((*MockRepository)(nil).SaveCoverCheck), ctx, check)
 }
 
 // UpdateBookById mocks base method.
-func (m *MockRepository) UpdateBookById(ctx context.Context, id string, book *BookDTO) error {
+func (m *MockRepository) UpdateBookById(ctx context.Context, id string, expectedVersion int, book *BookDTO) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBookById", ctx, id, book)
+	ret := m.ctrl.Call(m, "UpdateBookById", ctx, id, expectedVersion, book)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateBookById indicates an expected call of UpdateBookById.
-func (mr *MockRepositoryMockRecorder) UpdateBookById(ctx, id, book any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) UpdateBookById(ctx, id, expectedVersion, book any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBookById", reflect.TypeOf((*MockRepository)(nil).UpdateBookById), ctx, id, book)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBookById", reflect.TypeOf((*MockRepository)(nil).UpdateBookById), ctx, id, expectedVersion, book)
 }
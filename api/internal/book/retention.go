@@ -0,0 +1,81 @@
+package book
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"book-api/pkg/storage"
+)
+
+const (
+	retentionActor       = "system:retention"
+	retentionBatchSize   = 500
+	defaultPurgeInterval = time.Hour
+)
+
+// RetentionJob permanently removes books that have been in the trash for longer than the retention period,
+// along with the cover images they left in the store.
+type RetentionJob struct {
+	repository Repository
+	covers     storage.BlobStore
+	retention  time.Duration
+	interval   time.Duration
+	now        func() time.Time
+}
+
+func NewRetentionJob(repository Repository, covers storage.BlobStore, retention, interval time.Duration) *RetentionJob {
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	return &RetentionJob{
+		repository: repository,
+		covers:     covers,
+		retention:  retention,
+		interval:   interval,
+		now:        time.Now,
+	}
+}
+
+// Run purges once right away and then on every interval until ctx is cancelled.
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := j.Purge(ctx)
+		if err != nil {
+			zap.L().Error("failed to purge deleted books", zap.Error(err))
+		} else if purged > 0 {
+			zap.L().Info("purged deleted books", zap.Int("count", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge works through the expired books batch by batch so no single transaction holds too many rows.
+func (j *RetentionJob) Purge(ctx context.Context) (int, error) {
+	ctx = WithAudit(ctx, Audit{Actor: retentionActor})
+	deletedBefore := j.now().UTC().Add(-j.retention)
+
+	total := 0
+	for {
+		purged, covers, err := j.repository.PurgeDeletedBooks(ctx, deletedBefore, retentionBatchSize)
+		deletePurgedCoverBlobs(j.covers, covers)
+		total += purged
+		if err != nil || purged < retentionBatchSize {
+			return total, err
+		}
+
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+}
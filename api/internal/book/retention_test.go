@@ -0,0 +1,65 @@
+package book
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"book-api/pkg/storage"
+)
+
+func TestRetentionJob_Purge(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	deletedBefore := now.Add(-30 * 24 * time.Hour)
+
+	t.Run("happy path", func(t *testing.T) {
+		cover := BookCover{BookId: "purged", Digest: "digest"}
+		store, err := storage.NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.TODO(), coverKey(cover.BookId, cover.Digest, originalCover), strings.NewReader("image"), 5, "image/png"))
+
+		mockRepository := NewMockRepository(mockController)
+		gomock.InOrder(
+			mockRepository.
+				EXPECT().
+				PurgeDeletedBooks(gomock.Any(), deletedBefore, retentionBatchSize).
+				DoAndReturn(func(ctx context.Context, _ time.Time, _ int) (int, []BookCover, error) {
+					assert.Equal(t, retentionActor, auditOf(ctx).Actor)
+					return retentionBatchSize, []BookCover{cover}, nil
+				}),
+			mockRepository.
+				EXPECT().
+				PurgeDeletedBooks(gomock.Any(), deletedBefore, retentionBatchSize).
+				Return(12, nil, nil),
+		)
+
+		job := NewRetentionJob(mockRepository, store, 30*24*time.Hour, time.Hour)
+		job.now = func() time.Time { return now }
+
+		purged, err := job.Purge(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, retentionBatchSize+12, purged)
+
+		_, err = store.Get(context.TODO(), coverKey(cover.BookId, cover.Digest, originalCover))
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().PurgeDeletedBooks(gomock.Any(), gomock.Any(), gomock.Any()).Return(0, nil, ErrRepositoryUnavailable)
+
+		job := NewRetentionJob(mockRepository, nil, time.Hour, 0)
+
+		_, err := job.Purge(context.Background())
+		assert.ErrorIs(t, err, ErrRepositoryUnavailable)
+		assert.Equal(t, defaultPurgeInterval, job.interval)
+	})
+}
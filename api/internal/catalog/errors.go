@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"book-api/pkg/apperror"
+)
+
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+func (e Errors) mapPgError(err error) error {
+	var appError *apperror.Error
+	if errors.As(err, &appError) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return e.NotFound.WithCause(err)
+	}
+
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		switch {
+		case pgError.Code == pgUniqueViolation && e.AlreadyExists != nil:
+			return e.AlreadyExists.WithCause(err)
+		case pgError.Code == pgForeignKeyViolation:
+			return e.InUse.WithCause(err)
+		case pgError.Code == pgCheckViolation:
+			return e.InvalidLabel.WithCause(err)
+		}
+	}
+
+	return e.Failure.WithCause(err)
+}
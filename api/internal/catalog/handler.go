@@ -0,0 +1,244 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+	"book-api/pkg/cursor"
+)
+
+const defaultLimit = 20
+
+type Handler[T Record[T]] struct {
+	kind       Kind
+	server     *fiber.App
+	validator  *validator.Validate
+	tracer     trace.Tracer
+	repository Repository[T]
+	options    handlerOptions
+}
+
+type handlerOptions struct {
+	cursorSigner *cursor.Signer
+	authorizer   *auth.Authorizer
+}
+
+type HandlerOption func(*handlerOptions)
+
+func WithCursorSigner(cursorSigner *cursor.Signer) HandlerOption {
+	return func(o *handlerOptions) {
+		o.cursorSigner = cursorSigner
+	}
+}
+
+// WithAuthorizer lets readers list and get records, editors create and update them and only admins delete
+// them. Without it every route stays open.
+func WithAuthorizer(authorizer *auth.Authorizer) HandlerOption {
+	return func(o *handlerOptions) {
+		o.authorizer = authorizer
+	}
+}
+
+func NewHandler[T Record[T]](
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	kind Kind,
+	repository Repository[T],
+	opts ...HandlerOption,
+) *Handler[T] {
+	h := &Handler[T]{
+		kind:       kind,
+		server:     server,
+		validator:  validator,
+		tracer:     tracer,
+		repository: repository,
+	}
+	for _, opt := range opts {
+		opt(&h.options)
+	}
+
+	if h.options.cursorSigner == nil {
+		h.options.cursorSigner = cursor.NewSigner("")
+	}
+
+	return h
+}
+
+func (h *Handler[T]) RegisterHandlers() {
+	reader := h.options.authorizer.Require(auth.RoleReader)
+	editor := h.options.authorizer.Require(auth.RoleEditor)
+	admin := h.options.authorizer.Require(auth.RoleAdmin)
+
+	h.server.Post("/"+h.kind.Name, editor, h.Create)
+	h.server.Get("/"+h.kind.Plural, reader, h.List)
+	h.server.Get("/"+h.kind.Name+"/:id", reader, h.GetById)
+	h.server.Put("/"+h.kind.Name+"/:id", editor, h.UpdateById)
+	h.server.Delete("/"+h.kind.Name+"/:id", admin, h.DeleteById)
+}
+
+// Create answers a record refused as AlreadyExists with the existing one in Location.
+func (h *Handler[T]) Create(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "Create")
+	defer span.End()
+
+	var record T
+	if err := ctx.BodyParser(&record); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	span.SetAttributes(attribute.String("new", fmt.Sprintf("%+v", record)))
+
+	if err := h.validator.StructCtx(ctx.Context(), &record); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	if record.Position().Id == "" {
+		record = record.WithId(uuid.NewString())
+	}
+
+	if err := h.repository.Create(ctx.Context(), &record); err != nil {
+		var appError *apperror.Error
+		if h.kind.Errors.AlreadyExists != nil && errors.Is(err, h.kind.Errors.AlreadyExists) &&
+			errors.As(err, &appError) && appError.Meta[h.kind.Name+"Id"] != nil {
+			ctx.Location(fmt.Sprintf("/%s/%s", h.kind.Name, appError.Meta[h.kind.Name+"Id"]))
+		}
+		return err
+	}
+
+	ctx.Location(fmt.Sprintf("/%s/%s", h.kind.Name, record.Position().Id))
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		h.kind.Name: record,
+	})
+}
+
+func (h *Handler[T]) List(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "List")
+	defer span.End()
+
+	var queries ListRequest
+	if err := ctx.QueryParser(&queries); err != nil {
+		return apperror.ErrInvalidQuery.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &queries); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	request := PageRequest{Search: queries.Search, Limit: queries.Limit}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+
+	// a cursor only continues the search it was issued for
+	scope := cursor.Scope{Kind: h.kind.Plural, Query: request.Search}
+	if queries.Cursor != "" {
+		var position Cursor
+		if err := h.options.cursorSigner.Decode(queries.Cursor, scope, &position); err != nil {
+			return apperror.ErrInvalidCursor.WithCause(err)
+		}
+		request.Cursor = &position
+	}
+
+	page, err := h.repository.List(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	records := page.Records
+	if records == nil {
+		records = []T{}
+	}
+
+	response := fiber.Map{h.kind.Plural: records}
+	if page.Next != nil {
+		if response["nextCursor"], err = h.options.cursorSigner.Encode(scope, page.Next); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *Handler[T]) GetById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetById")
+	defer span.End()
+
+	id, err := h.idParam(ctx, span)
+	if err != nil {
+		return err
+	}
+
+	record, err := h.repository.GetById(ctx.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		h.kind.Name: record,
+	})
+}
+
+// UpdateById only takes the label from the body, the id is the one in the path.
+func (h *Handler[T]) UpdateById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "UpdateById")
+	defer span.End()
+
+	id, err := h.idParam(ctx, span)
+	if err != nil {
+		return err
+	}
+
+	var record T
+	if err := ctx.BodyParser(&record); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &record); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	record = record.WithId(id)
+	if err := h.repository.UpdateById(ctx.Context(), id, &record); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		h.kind.Name: record,
+	})
+}
+
+func (h *Handler[T]) DeleteById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "DeleteById")
+	defer span.End()
+
+	id, err := h.idParam(ctx, span)
+	if err != nil {
+		return err
+	}
+
+	if err := h.repository.DeleteById(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler[T]) idParam(ctx *fiber.Ctx, span trace.Span) (string, error) {
+	id := ctx.Params("id")
+	span.SetAttributes(attribute.String(h.kind.Name+"Id", id))
+
+	if err := h.validator.VarCtx(ctx.Context(), id, "required,uuid4"); err != nil {
+		return "", apperror.FromParamValidation("id", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,306 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+	"book-api/pkg/cursor"
+)
+
+// shelf is a made up Kind, the handler only knows records through Record.
+type shelf struct {
+	Id    string `json:"id" db:"id" validate:"omitempty,uuid"`
+	Label string `json:"label" db:"label" validate:"required,max=255"`
+}
+
+func (s shelf) Position() Cursor {
+	return Cursor{Label: s.Label, Id: s.Id}
+}
+
+func (s shelf) WithId(id string) shelf {
+	s.Id = id
+	return s
+}
+
+var (
+	errShelfNotFound      = apperror.NotFound("SHELF_NOT_FOUND", "shelf not found")
+	errShelfAlreadyExists = apperror.Conflict("SHELF_ALREADY_EXISTS", "a shelf with this label already exists")
+	errShelfInUse         = apperror.Conflict("SHELF_IN_USE", "shelf still has books")
+
+	shelfKind = Kind{
+		Name:         "shelf",
+		Plural:       "shelves",
+		Table:        "shelves",
+		Label:        "label",
+		FoldedLabels: true,
+		Errors:       Errors{NotFound: errShelfNotFound, AlreadyExists: errShelfAlreadyExists, InUse: errShelfInUse},
+	}
+)
+
+func TestHandler_RegisterHandlers(t *testing.T) {
+	h := NewHandler[shelf](fiber.New(), nil, nil, shelfKind, nil)
+
+	assert.NotPanics(t, h.RegisterHandlers)
+}
+
+func TestHandler_Create(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, record *shelf) error {
+			assert.NotEmpty(t, record.Id)
+			assert.Equal(t, "Fantasy", record.Label)
+			return nil
+		})
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/shelf", `{"label":"Fantasy"}`)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get(fiber.HeaderLocation), "/shelf/"))
+
+		var body struct {
+			Shelf shelf `json:"shelf"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, "Fantasy", body.Shelf.Label)
+	})
+
+	t.Run("keeps the id sent", func(t *testing.T) {
+		id := uuid.NewString()
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), &shelf{Id: id, Label: "Fantasy"}).Return(nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/shelf", `{"id":"`+id+`","label":"Fantasy"}`)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.Equal(t, "/shelf/"+id, res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("label taken", func(t *testing.T) {
+		existingId := uuid.NewString()
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errShelfAlreadyExists.WithMeta("shelfId", existingId))
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/shelf", `{"label":"fantasy"}`)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+		assert.Equal(t, "/shelf/"+existingId, res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("missing label", func(t *testing.T) {
+		server := setupHandler(nil)
+
+		res := send(t, server, http.MethodPost, "/shelf", `{}`)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, []apperror.FieldError{{Field: "label", Rule: "required"}}, problem.Errors)
+	})
+}
+
+func TestHandler_List(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("pages with a cursor", func(t *testing.T) {
+		first := shelf{Id: uuid.NewString(), Label: "Fantasy"}
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().List(gomock.Any(), PageRequest{Search: "fan", Limit: 1}).
+			Return(&Page[shelf]{Records: []shelf{first}, Next: &Cursor{Label: first.Label, Id: first.Id}}, nil)
+		mockRepository.EXPECT().List(gomock.Any(), PageRequest{Search: "fan", Limit: 1, Cursor: &Cursor{Label: first.Label, Id: first.Id}}).
+			Return(&Page[shelf]{}, nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodGet, "/shelves?search=fan&limit=1", "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var page struct {
+			Shelves    []shelf `json:"shelves"`
+			NextCursor string  `json:"nextCursor"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+		assert.Equal(t, []shelf{first}, page.Shelves)
+		require.NotEmpty(t, page.NextCursor)
+		next := page.NextCursor
+
+		res = send(t, server, http.MethodGet, "/shelves?search=fan&limit=1&cursor="+next, "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		page.Shelves, page.NextCursor = nil, ""
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+		assert.Equal(t, []shelf{}, page.Shelves)
+		assert.Empty(t, page.NextCursor)
+
+		// the cursor belongs to the search for "fan"
+		res = send(t, server, http.MethodGet, "/shelves?search=sci&limit=1&cursor="+next, "")
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("cursor of another kind", func(t *testing.T) {
+		other, err := cursor.NewSigner("").Encode(cursor.Scope{Kind: "authors"}, Cursor{Label: "Fantasy", Id: uuid.NewString()})
+		require.NoError(t, err)
+
+		server := setupHandler(nil)
+
+		res := send(t, server, http.MethodGet, "/shelves?cursor="+other, "")
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, apperror.ErrInvalidCursor.Code, problem.Code)
+	})
+
+	t.Run("forged cursor", func(t *testing.T) {
+		server := setupHandler(nil)
+
+		res := send(t, server, http.MethodGet, "/shelves?cursor=forged", "")
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_GetById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		id := uuid.NewString()
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().GetById(gomock.Any(), id).Return(&shelf{Id: id, Label: "Fantasy"}, nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodGet, "/shelf/"+id, "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		server := setupHandler(nil)
+
+		res := send(t, server, http.MethodGet, "/shelf/not-a-uuid", "")
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().GetById(gomock.Any(), gomock.Any()).Return(nil, errShelfNotFound)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodGet, "/shelf/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_UpdateById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	id := uuid.NewString()
+	mockRepository := NewMockRepository[shelf](mockController)
+	mockRepository.EXPECT().UpdateById(gomock.Any(), id, &shelf{Id: id, Label: "Science Fiction"}).Return(nil)
+
+	server := setupHandler(mockRepository)
+
+	// an id in the body doesn't move the record
+	res := send(t, server, http.MethodPut, "/shelf/"+id, `{"id":"`+uuid.NewString()+`","label":"Science Fiction"}`)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var body struct {
+		Shelf shelf `json:"shelf"`
+	}
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, id, body.Shelf.Id)
+}
+
+func TestHandler_DeleteById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().DeleteById(gomock.Any(), gomock.Any()).Return(nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodDelete, "/shelf/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("still in use", func(t *testing.T) {
+		mockRepository := NewMockRepository[shelf](mockController)
+		mockRepository.EXPECT().DeleteById(gomock.Any(), gomock.Any()).Return(errShelfInUse)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodDelete, "/shelf/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("editor cannot delete", func(t *testing.T) {
+		authorizer := auth.NewAuthorizer(auth.NewAPIKeyAuthenticator([]auth.APIKey{
+			{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+		}))
+
+		server := setupHandler(nil, WithAuthorizer(authorizer))
+
+		req := httptest.NewRequest(http.MethodDelete, "/shelf/"+uuid.NewString(), nil)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupHandler(repository Repository[shelf], opts ...HandlerOption) *fiber.App {
+	server, validate, tracer := setupServer()
+	NewHandler(server, validate, tracer, shelfKind, repository, opts...).RegisterHandlers()
+
+	return server
+}
+
+func setupServer() (*fiber.App, *validator.Validate, trace.Tracer) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	return server, validate, otel.Tracer("catalog")
+}
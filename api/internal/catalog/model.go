@@ -0,0 +1,67 @@
+// Package catalog serves the records books refer to by id and that are otherwise just a label, like authors,
+// publishers, series and works. Each of them is a Kind, the package holds what they share: the CRUD routes,
+// the list paged by label and the Postgres repository.
+package catalog
+
+import "book-api/pkg/apperror"
+
+// Kind describes one entity to the shared handler and repository.
+type Kind struct {
+	// Name is the singular, it names the routes below /<Name>/:id, the field a record is answered in and
+	// the meta key of the existing record in AlreadyExists.
+	Name string
+	// Plural is the route of the list and the field its page is answered in.
+	Plural string
+	// Table holds the records, Label is the text column they are known, ordered and searched by.
+	Table string
+	Label string
+	// FoldedLabels is set for tables with a unique name_key, the label folded by author_name_key.
+	// Search then matches the folded label and a second record under the same key is refused.
+	FoldedLabels bool
+	Errors       Errors
+}
+
+// Errors are the Kind's own errors for what the shared code runs into.
+type Errors struct {
+	NotFound *apperror.Error
+	// AlreadyExists is only needed with FoldedLabels.
+	AlreadyExists *apperror.Error
+	// InUse is a delete of a record books still refer to.
+	InUse *apperror.Error
+	// InvalidLabel is a label the table's check refuses.
+	InvalidLabel *apperror.Error
+	Unavailable  *apperror.Error
+	Failure      *apperror.Error
+}
+
+// Record is the DTO of a Kind. It is read from the request body as is, so it carries the validation of
+// its id and label.
+type Record[T any] interface {
+	// Position is the record's place in the list, its label and id.
+	Position() Cursor
+	// WithId is a copy of the record under id.
+	WithId(id string) T
+}
+
+// Cursor is the keyset position in a list, which is ordered by label.
+type Cursor struct {
+	Label string `json:"l"`
+	Id    string `json:"i"`
+}
+
+type ListRequest struct {
+	Search string `query:"search,omitempty"`
+	Limit  int    `query:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor,omitempty"`
+}
+
+type PageRequest struct {
+	Search string
+	Cursor *Cursor
+	Limit  int
+}
+
+type Page[T any] struct {
+	Records []T
+	Next    *Cursor
+}
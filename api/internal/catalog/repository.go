@@ -0,0 +1,240 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Repository[T Record[T]] interface {
+	Create(ctx context.Context, record *T) error
+	List(ctx context.Context, request PageRequest) (*Page[T], error)
+	GetById(ctx context.Context, id string) (*T, error)
+	UpdateById(ctx context.Context, id string, record *T) error
+	DeleteById(ctx context.Context, id string) error
+}
+
+type PgRepository[T Record[T]] struct {
+	kind           Kind
+	columns        string
+	connectionPool *pgxpool.Pool
+	traceProvider  *sdktrace.TracerProvider
+}
+
+// NewPgRepository keeps the records of kind in the database of the book repository, sharing its pool.
+// The book repository checks the schema for both.
+func NewPgRepository[T Record[T]](kind Kind, traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *PgRepository[T] {
+	return &PgRepository[T]{
+		kind:           kind,
+		columns:        "id, " + kind.Label + ", created_at, updated_at",
+		connectionPool: connectionPool,
+		traceProvider:  traceProvider,
+	}
+}
+
+// ConnectionPool lets a Kind with more to read than its table query it itself.
+func (r *PgRepository[T]) ConnectionPool() *pgxpool.Pool {
+	return r.connectionPool
+}
+
+// Create does not insert a second record under a folded label that is taken, it returns AlreadyExists
+// with the existing record in meta <name>Id instead.
+func (r *PgRepository[T]) Create(ctx context.Context, record *T) error {
+	position := (*record).Position()
+	ctx, span := r.tracer().Start(ctx, "Create", trace.WithAttributes(attribute.String(r.kind.Label, position.Label)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return r.kind.Errors.Unavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	insert := fmt.Sprintf("insert into %s (id, %s) values ($1, $2)", r.kind.Table, r.kind.Label)
+	if r.kind.FoldedLabels {
+		insert += " on conflict (name_key) do nothing"
+	}
+
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		created, err := r.queryRecord(ctx, tx, insert+" returning "+r.columns, position.Id, position.Label)
+		if errors.Is(err, pgx.ErrNoRows) && r.kind.FoldedLabels {
+			var existingId string
+			if err = tx.QueryRow(ctx, "select id from "+r.kind.Table+" where name_key = author_name_key($1)", position.Label).Scan(&existingId); err != nil {
+				return err
+			}
+			return r.kind.Errors.AlreadyExists.WithMeta(r.kind.Name+"Id", existingId)
+		}
+		if err != nil {
+			return err
+		}
+
+		*record = *created
+		return nil
+	})
+	if err != nil {
+		return r.kind.Errors.mapPgError(err)
+	}
+
+	return nil
+}
+
+// List pages through the records by label, search matches anywhere in the label ignoring case, or in the
+// folded label for FoldedLabels.
+func (r *PgRepository[T]) List(ctx context.Context, request PageRequest) (*Page[T], error) {
+	ctx, span := r.tracer().Start(ctx, "List", trace.WithAttributes(
+		attribute.String("search", request.Search),
+		attribute.Int("limit", request.Limit),
+	))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, r.kind.Errors.Unavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	query := "select " + r.columns + " from " + r.kind.Table + " where true"
+	args := make([]any, 0, 4)
+	if request.Search != "" {
+		args = append(args, request.Search)
+		if r.kind.FoldedLabels {
+			query += fmt.Sprintf(" and strpos(name_key, author_name_key($%d)) > 0", len(args))
+		} else {
+			query += fmt.Sprintf(" and strpos(lower(%s), lower($%d)) > 0", r.kind.Label, len(args))
+		}
+	}
+	if request.Cursor != nil {
+		args = append(args, request.Cursor.Label, request.Cursor.Id)
+		query += fmt.Sprintf(" and (%s, id) > ($%d, $%d)", r.kind.Label, len(args)-1, len(args))
+	}
+
+	args = append(args, request.Limit+1)
+	query += fmt.Sprintf(" order by %s, id limit $%d", r.kind.Label, len(args))
+
+	rows, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.kind.Errors.mapPgError(err)
+	}
+
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, r.kind.Errors.mapPgError(err)
+	}
+
+	page := &Page[T]{Records: records}
+	if len(records) > request.Limit {
+		page.Records = records[:request.Limit]
+		next := page.Records[request.Limit-1].Position()
+		page.Next = &next
+	}
+
+	return page, nil
+}
+
+func (r *PgRepository[T]) GetById(ctx context.Context, id string) (*T, error) {
+	ctx, span := r.tracer().Start(ctx, "GetById", trace.WithAttributes(attribute.String(r.kind.Name+"Id", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, r.kind.Errors.Unavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(ctx, "select "+r.columns+" from "+r.kind.Table+" where id = $1", id)
+	if err != nil {
+		return nil, r.kind.Errors.mapPgError(err)
+	}
+
+	record, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, r.kind.Errors.mapPgError(err)
+	}
+
+	return &record, nil
+}
+
+func (r *PgRepository[T]) UpdateById(ctx context.Context, id string, record *T) error {
+	label := (*record).Position().Label
+	ctx, span := r.tracer().Start(ctx, "UpdateById", trace.WithAttributes(
+		attribute.String(r.kind.Name+"Id", id),
+		attribute.String(r.kind.Label, label),
+	))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return r.kind.Errors.Unavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	updated, err := r.queryRecord(
+		ctx,
+		connection,
+		fmt.Sprintf("update %s set %s = $1, updated_at = now() where id = $2 returning %s", r.kind.Table, r.kind.Label, r.columns),
+		label,
+		id,
+	)
+	if err != nil {
+		return r.kind.Errors.mapPgError(err)
+	}
+
+	*record = *updated
+	return nil
+}
+
+// DeleteById only removes records no book refers to, books in the trash included.
+func (r *PgRepository[T]) DeleteById(ctx context.Context, id string) error {
+	ctx, span := r.tracer().Start(ctx, "DeleteById", trace.WithAttributes(attribute.String(r.kind.Name+"Id", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return r.kind.Errors.Unavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	tag, err := connection.Exec(ctx, "delete from "+r.kind.Table+" where id = $1", id)
+	if err != nil {
+		return r.kind.Errors.mapPgError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return r.kind.Errors.NotFound
+	}
+
+	return nil
+}
+
+// MapPgError turns an error of a query on the Kind's table into one of its Errors.
+func (r *PgRepository[T]) MapPgError(err error) error {
+	return r.kind.Errors.mapPgError(err)
+}
+
+func (r *PgRepository[T]) tracer() trace.Tracer {
+	return r.traceProvider.Tracer(r.kind.Name + "Repository")
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (r *PgRepository[T]) queryRecord(ctx context.Context, q querier, sql string, args ...any) (*T, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[T])
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/catalog/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/catalog/repository.go -destination=internal/catalog/repository_mock.go -package=catalog -exclude_interfaces=querier
+//
+
+// Package catalog is a generated GoMock package.
+package catalog
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository[T Record[T]] struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder[T]
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder[T Record[T]] struct {
+	mock *MockRepository[T]
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository[T Record[T]](ctrl *gomock.Controller) *MockRepository[T] {
+	mock := &MockRepository[T]{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder[T]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository[T]) EXPECT() *MockRepositoryMockRecorder[T] {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRepository[T]) Create(ctx context.Context, record *T) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRepositoryMockRecorder[T]) Create(ctx, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository[T])(nil).Create), ctx, record)
+}
+
+// DeleteById mocks base method.
+func (m *MockRepository[T]) DeleteById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteById indicates an expected call of DeleteById.
+func (mr *MockRepositoryMockRecorder[T]) DeleteById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockRepository[T])(nil).DeleteById), ctx, id)
+}
+
+// GetById mocks base method.
+func (m *MockRepository[T]) GetById(ctx context.Context, id string) (*T, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	ret0, _ := ret[0].(*T)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockRepositoryMockRecorder[T]) GetById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockRepository[T])(nil).GetById), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockRepository[T]) List(ctx context.Context, request PageRequest) (*Page[T], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, request)
+	ret0, _ := ret[0].(*Page[T])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder[T]) List(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository[T])(nil).List), ctx, request)
+}
+
+// UpdateById mocks base method.
+func (m *MockRepository[T]) UpdateById(ctx context.Context, id string, record *T) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateById", ctx, id, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateById indicates an expected call of UpdateById.
+func (mr *MockRepositoryMockRecorder[T]) UpdateById(ctx, id, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockRepository[T])(nil).UpdateById), ctx, id, record)
+}
@@ -0,0 +1,67 @@
+package genre
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"book-api/pkg/apperror"
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+)
+
+var (
+	ErrGenreNotFound         = apperror.NotFound("GENRE_NOT_FOUND", "genre not found")
+	ErrGenreAlreadyExists    = apperror.Conflict("GENRE_ALREADY_EXISTS", "a genre with this slug already exists")
+	ErrGenreInUse            = apperror.Conflict("GENRE_IN_USE", "genre still has books or subgenres")
+	ErrGenreCycle            = apperror.Invalid("GENRE_CYCLE", "a genre cannot be placed below itself")
+	ErrInvalidGenreName      = apperror.Invalid("INVALID_GENRE_NAME", "genre name must not be blank")
+	ErrInvalidGenreSlug      = apperror.Invalid("INVALID_GENRE_SLUG", "genre slug must be lowercase letters and digits separated by single dashes")
+	ErrInvalidDewey          = apperror.Invalid("INVALID_DEWEY", "dewey must be a three digit class with optional decimals, like 823.912")
+	ErrInvalidBisac          = apperror.Invalid("INVALID_BISAC", "bisac must be three capital letters and six digits, like FIC028000")
+	ErrUnknownGenre          = apperror.Invalid("UNKNOWN_GENRE", "genre does not exist")
+	ErrRepositoryUnavailable = apperror.Unavailable("GENRE_REPOSITORY_UNAVAILABLE", "genre repository is unavailable")
+	ErrRepositoryFailure     = apperror.Internal("GENRE_REPOSITORY_FAILURE", "genre repository failed")
+)
+
+// checkErrors tells which rule of a genre a check violation broke.
+var checkErrors = map[string]*apperror.Error{
+	"genres_name_check":   ErrInvalidGenreName,
+	"genres_slug_check":   ErrInvalidGenreSlug,
+	"genres_parent_check": ErrGenreCycle,
+	"genres_dewey_check":  ErrInvalidDewey,
+	"genres_bisac_check":  ErrInvalidBisac,
+}
+
+func mapPgError(err error) error {
+	var appError *apperror.Error
+	if errors.As(err, &appError) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrGenreNotFound.WithCause(err)
+	}
+
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		switch pgError.Code {
+		case pgUniqueViolation:
+			return ErrGenreAlreadyExists.WithCause(err)
+		case pgForeignKeyViolation:
+			// deletes check their references themselves, a write only references its parent
+			return ErrUnknownGenre.WithMeta("field", "parentId").WithCause(err)
+		case pgCheckViolation:
+			if checkError, ok := checkErrors[pgError.ConstraintName]; ok {
+				return checkError.WithCause(err)
+			}
+		}
+	}
+
+	return ErrRepositoryFailure.WithCause(err)
+}
@@ -0,0 +1,181 @@
+package genre
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+)
+
+type Handler struct {
+	server     *fiber.App
+	validator  *validator.Validate
+	tracer     trace.Tracer
+	repository Repository
+	authorizer *auth.Authorizer
+}
+
+type HandlerOption func(*Handler)
+
+// WithAuthorizer lets readers browse the taxonomy, editors change it and only admins delete a genre.
+func WithAuthorizer(authorizer *auth.Authorizer) HandlerOption {
+	return func(h *Handler) {
+		h.authorizer = authorizer
+	}
+}
+
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	repository Repository,
+	opts ...HandlerOption,
+) *Handler {
+	h := &Handler{
+		server:     server,
+		validator:  validator,
+		tracer:     tracer,
+		repository: repository,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *Handler) RegisterHandlers() {
+	reader := h.authorizer.Require(auth.RoleReader)
+	editor := h.authorizer.Require(auth.RoleEditor)
+	admin := h.authorizer.Require(auth.RoleAdmin)
+
+	h.server.Post("/genre", editor, h.CreateGenre)
+	h.server.Get("/genres", reader, h.GetGenres)
+	h.server.Get("/genre/:id", reader, h.GetGenreById)
+	h.server.Put("/genre/:id", editor, h.UpdateGenreById)
+	h.server.Delete("/genre/:id", admin, h.DeleteGenreById)
+}
+
+func (h *Handler) CreateGenre(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "CreateGenre")
+	defer span.End()
+
+	var reqBody CreateGenreRequest
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	span.SetAttributes(attribute.String("newGenre", fmt.Sprintf("%+v", reqBody)))
+
+	if err := h.validator.StructCtx(ctx.Context(), &reqBody); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	if reqBody.Id == "" {
+		reqBody.Id = uuid.NewString()
+	}
+
+	genre := reqBody.toDTO()
+	genre.Id = reqBody.Id
+	if err := h.repository.CreateGenre(ctx.Context(), genre); err != nil {
+		var appError *apperror.Error
+		if errors.Is(err, ErrGenreAlreadyExists) && errors.As(err, &appError) && appError.Meta["genreId"] != nil {
+			ctx.Location(fmt.Sprintf("/genre/%s", appError.Meta["genreId"]))
+		}
+		return err
+	}
+
+	ctx.Location(fmt.Sprintf("/genre/%s", genre.Id))
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"genre": genre,
+	})
+}
+
+// GetGenres returns the taxonomy as a tree, top level genres first.
+func (h *Handler) GetGenres(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetGenres")
+	defer span.End()
+
+	genres, err := h.repository.GetGenres(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(GetGenresResponse{Genres: tree(genres)})
+}
+
+func (h *Handler) GetGenreById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetGenreById")
+	defer span.End()
+
+	genreId := ctx.Params("id")
+	span.SetAttributes(attribute.String("genreId", genreId))
+
+	if err := h.validator.VarCtx(ctx.Context(), genreId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	genre, err := h.repository.GetGenreById(ctx.Context(), genreId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"genre": genre,
+	})
+}
+
+func (h *Handler) UpdateGenreById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "UpdateGenreById")
+	defer span.End()
+
+	genreId := ctx.Params("id")
+	span.SetAttributes(attribute.String("genreId", genreId))
+
+	if err := h.validator.VarCtx(ctx.Context(), genreId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	var reqBody UpdateGenreRequest
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	if err := h.validator.StructCtx(ctx.Context(), &reqBody); err != nil {
+		return apperror.FromValidation(err)
+	}
+
+	genre := reqBody.toDTO()
+	if err := h.repository.UpdateGenreById(ctx.Context(), genreId, genre); err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{
+		"genre": genre,
+	})
+}
+
+func (h *Handler) DeleteGenreById(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "DeleteGenreById")
+	defer span.End()
+
+	genreId := ctx.Params("id")
+	span.SetAttributes(attribute.String("genreId", genreId))
+
+	if err := h.validator.VarCtx(ctx.Context(), genreId, "required,uuid4"); err != nil {
+		return apperror.FromParamValidation("id", err)
+	}
+
+	if err := h.repository.DeleteGenreById(ctx.Context(), genreId); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,254 @@
+package genre
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+)
+
+func TestHandler_RegisterHandlers(t *testing.T) {
+	h := NewHandler(fiber.New(), nil, nil, nil)
+
+	assert.NotPanics(t, h.RegisterHandlers)
+}
+
+func TestHandler_CreateGenre(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		parentId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateGenre(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, genre *GenreDTO) error {
+			assert.NotEmpty(t, genre.Id)
+			assert.Equal(t, "Science Fiction & Fantasy", genre.Name)
+			assert.Equal(t, "science-fiction-fantasy", genre.Slug)
+			assert.Equal(t, &parentId, genre.ParentId)
+			assert.Equal(t, "FIC028000", *genre.Bisac)
+			assert.Nil(t, genre.Dewey)
+			return nil
+		})
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodPost, "/genre", `{"name":"Science Fiction & Fantasy","parentId":"`+parentId+`","bisac":"FIC028000"}`)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get(fiber.HeaderLocation), "/genre/"))
+
+		var body struct {
+			Genre GenreDTO `json:"genre"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, "science-fiction-fantasy", body.Genre.Slug)
+	})
+
+	t.Run("slug taken", func(t *testing.T) {
+		existingId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().CreateGenre(gomock.Any(), gomock.Any()).Return(ErrGenreAlreadyExists.WithMeta("genreId", existingId))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodPost, "/genre", `{"name":"Fantasy"}`)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+		assert.Equal(t, "/genre/"+existingId, res.Header.Get(fiber.HeaderLocation))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil).RegisterHandlers()
+
+		res := send(t, server, http.MethodPost, "/genre", `{"parentId":"fiction","bisac":"FIC"}`)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, []apperror.FieldError{
+			{Field: "name", Rule: "required"},
+			{Field: "parentId", Rule: "uuid"},
+			{Field: "bisac", Rule: "len", Param: "9"},
+		}, problem.Errors)
+	})
+}
+
+func TestHandler_GetGenres(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	fiction := GenreDTO{Id: uuid.NewString(), Name: "Fiction", Slug: "fiction"}
+	fantasy := GenreDTO{Id: uuid.NewString(), ParentId: &fiction.Id, Name: "Fantasy", Slug: "fantasy"}
+	history := GenreDTO{Id: uuid.NewString(), Name: "History", Slug: "history"}
+
+	mockRepository := NewMockRepository(mockController)
+	mockRepository.EXPECT().GetGenres(gomock.Any()).Return([]GenreDTO{fantasy, fiction, history}, nil)
+
+	server, validate, tracer := setupServer()
+	NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+	res := send(t, server, http.MethodGet, "/genres", "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var body GetGenresResponse
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, []GenreNode{
+		{GenreDTO: fiction, Children: []GenreNode{{GenreDTO: fantasy}}},
+		{GenreDTO: history},
+	}, body.Genres)
+}
+
+func TestHandler_GetGenreById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		genreId := uuid.NewString()
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetGenreById(gomock.Any(), genreId).Return(&GenreDTO{Id: genreId, Name: "Fantasy", Slug: "fantasy"}, nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodGet, "/genre/"+genreId, "")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().GetGenreById(gomock.Any(), gomock.Any()).Return(nil, ErrGenreNotFound)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodGet, "/genre/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestHandler_UpdateGenreById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		genreId := uuid.NewString()
+		dewey := "823.912"
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().UpdateGenreById(gomock.Any(), genreId, &GenreDTO{Name: "English Fiction", Slug: "english-fiction", Dewey: &dewey}).
+			DoAndReturn(func(_ any, id string, genre *GenreDTO) error {
+				genre.Id = id
+				return nil
+			})
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodPut, "/genre/"+genreId, `{"name":"English Fiction","dewey":"823.912"}`)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var body struct {
+			Genre GenreDTO `json:"genre"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, genreId, body.Genre.Id)
+	})
+
+	t.Run("below its own subgenre", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().UpdateGenreById(gomock.Any(), gomock.Any(), gomock.Any()).Return(ErrGenreCycle)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodPut, "/genre/"+uuid.NewString(), `{"name":"Fiction","parentId":"`+uuid.NewString()+`"}`)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestHandler_DeleteGenreById(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("happy path", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeleteGenreById(gomock.Any(), gomock.Any()).Return(nil)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodDelete, "/genre/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("still in use", func(t *testing.T) {
+		mockRepository := NewMockRepository(mockController)
+		mockRepository.EXPECT().DeleteGenreById(gomock.Any(), gomock.Any()).Return(ErrGenreInUse)
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, mockRepository).RegisterHandlers()
+
+		res := send(t, server, http.MethodDelete, "/genre/"+uuid.NewString(), "")
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("editor cannot delete", func(t *testing.T) {
+		authorizer := auth.NewAuthorizer(auth.NewAPIKeyAuthenticator([]auth.APIKey{
+			{Name: "editor", Key: "editor-key", Roles: []string{"editor"}},
+		}))
+
+		server, validate, tracer := setupServer()
+		NewHandler(server, validate, tracer, nil, WithAuthorizer(authorizer)).RegisterHandlers()
+
+		req := httptest.NewRequest(http.MethodDelete, "/genre/"+uuid.NewString(), nil)
+		req.Header.Set(auth.HeaderAPIKey, "editor-key")
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "science-fiction-fantasy", slugify("Science Fiction & Fantasy"))
+	assert.Equal(t, "ciencia-ficción", slugify("  Ciencia  Ficción "))
+	assert.Equal(t, "", slugify("..."))
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupServer() (*fiber.App, *validator.Validate, trace.Tracer) {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	return server, validate, otel.Tracer("genre")
+}
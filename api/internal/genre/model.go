@@ -0,0 +1,93 @@
+package genre
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+type CreateGenreRequest struct {
+	Id string `json:"id,omitempty" validate:"omitempty,uuid"`
+	UpdateGenreRequest
+}
+
+type UpdateGenreRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// Slug names the genre in filters, without it the slug is derived from the name
+	Slug     string `json:"slug,omitempty" validate:"omitempty,max=100"`
+	ParentId string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+	Dewey    string `json:"dewey,omitempty" validate:"omitempty,max=16"`
+	Bisac    string `json:"bisac,omitempty" validate:"omitempty,len=9"`
+}
+
+func (r UpdateGenreRequest) toDTO() *GenreDTO {
+	genre := &GenreDTO{Name: r.Name, Slug: r.Slug}
+	if genre.Slug == "" {
+		genre.Slug = slugify(r.Name)
+	}
+	if r.ParentId != "" {
+		genre.ParentId = &r.ParentId
+	}
+	if r.Dewey != "" {
+		genre.Dewey = &r.Dewey
+	}
+	if r.Bisac != "" {
+		genre.Bisac = &r.Bisac
+	}
+
+	return genre
+}
+
+type GetGenresResponse struct {
+	Genres []GenreNode `json:"genres"`
+}
+
+type GenreDTO struct {
+	Id        string     `json:"id" db:"id"`
+	ParentId  *string    `json:"parentId,omitempty" db:"parent_id"`
+	Name      string     `json:"name" db:"name"`
+	Slug      string     `json:"slug" db:"slug"`
+	Dewey     *string    `json:"dewey,omitempty" db:"dewey"`
+	Bisac     *string    `json:"bisac,omitempty" db:"bisac"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+// GenreNode is a genre with the genres directly below it.
+type GenreNode struct {
+	GenreDTO
+	Children []GenreNode `json:"children,omitempty"`
+}
+
+// slugify lowercases the name and joins its runs of letters and digits with dashes, "Science Fiction & Fantasy"
+// becomes "science-fiction-fantasy".
+func slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, "-")
+}
+
+// tree nests the genres below their parents, keeping their order within each level.
+func tree(genres []GenreDTO) []GenreNode {
+	children := make(map[string][]GenreDTO, len(genres))
+	for _, genre := range genres {
+		parentId := ""
+		if genre.ParentId != nil {
+			parentId = *genre.ParentId
+		}
+		children[parentId] = append(children[parentId], genre)
+	}
+
+	var nest func(parentId string) []GenreNode
+	nest = func(parentId string) []GenreNode {
+		nodes := make([]GenreNode, 0, len(children[parentId]))
+		for _, genre := range children[parentId] {
+			nodes = append(nodes, GenreNode{GenreDTO: genre, Children: nest(genre.Id)})
+		}
+		return nodes
+	}
+
+	return nest("")
+}
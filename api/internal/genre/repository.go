@@ -0,0 +1,222 @@
+package genre
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const genreColumns = "id, parent_id, name, slug, dewey, bisac, created_at, updated_at"
+
+type Repository interface {
+	CreateGenre(ctx context.Context, genre *GenreDTO) error
+	GetGenres(ctx context.Context) ([]GenreDTO, error)
+	GetGenreById(ctx context.Context, id string) (*GenreDTO, error)
+	UpdateGenreById(ctx context.Context, id string, genre *GenreDTO) error
+	DeleteGenreById(ctx context.Context, id string) error
+}
+
+type PgRepository struct {
+	connectionPool *pgxpool.Pool
+	traceProvider  *sdktrace.TracerProvider
+}
+
+// NewPgRepository takes the pool of the book repository, book filters join the genre tree in the same
+// database.
+func NewPgRepository(traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *PgRepository {
+	return &PgRepository{
+		connectionPool: connectionPool,
+		traceProvider:  traceProvider,
+	}
+}
+
+func (r *PgRepository) CreateGenre(ctx context.Context, genre *GenreDTO) error {
+	ctx, span := r.traceProvider.Tracer("genreRepository").
+		Start(ctx, "CreateGenre", trace.WithAttributes(attribute.String("slug", genre.Slug)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		created, err := queryGenre(
+			ctx,
+			tx,
+			"insert into genres (id, parent_id, name, slug, dewey, bisac) values ($1, $2, $3, $4, $5, $6) on conflict (slug) do nothing returning "+genreColumns,
+			genre.Id,
+			genre.ParentId,
+			genre.Name,
+			genre.Slug,
+			genre.Dewey,
+			genre.Bisac,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			var existingId string
+			if err = tx.QueryRow(ctx, "select id from genres where slug = $1", genre.Slug).Scan(&existingId); err != nil {
+				return err
+			}
+			return ErrGenreAlreadyExists.WithMeta("genreId", existingId)
+		}
+		if err != nil {
+			return err
+		}
+
+		*genre = *created
+		return nil
+	})
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+// GetGenres returns the whole taxonomy ordered by name, genres are curated and few enough to not need paging.
+func (r *PgRepository) GetGenres(ctx context.Context) ([]GenreDTO, error) {
+	ctx, span := r.traceProvider.Tracer("genreRepository").Start(ctx, "GetGenres")
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(ctx, "select "+genreColumns+" from genres order by name, id")
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	genres, err := pgx.CollectRows(rows, pgx.RowToStructByName[GenreDTO])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return genres, nil
+}
+
+func (r *PgRepository) GetGenreById(ctx context.Context, id string) (*GenreDTO, error) {
+	ctx, span := r.traceProvider.Tracer("genreRepository").
+		Start(ctx, "GetGenreById", trace.WithAttributes(attribute.String("genreId", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	rows, err := connection.Query(ctx, "select "+genreColumns+" from genres where id = $1", id)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	genre, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[GenreDTO])
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+
+	return genre, nil
+}
+
+// UpdateGenreById refuses to move a genre below one of its own subgenres. Moves take a lock on the
+// taxonomy so two of them cannot build a cycle together.
+func (r *PgRepository) UpdateGenreById(ctx context.Context, id string, genre *GenreDTO) error {
+	ctx, span := r.traceProvider.Tracer("genreRepository").
+		Start(ctx, "UpdateGenreById", trace.WithAttributes(
+			attribute.String("genreId", id),
+			attribute.String("slug", genre.Slug),
+		))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	err = pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		if genre.ParentId != nil {
+			if _, err := tx.Exec(ctx, "lock table genres in share row exclusive mode"); err != nil {
+				return err
+			}
+
+			var cycle bool
+			if err := tx.QueryRow(ctx, "select $2::uuid in (select genre_subtree($1))", id, *genre.ParentId).Scan(&cycle); err != nil {
+				return err
+			}
+			if cycle {
+				return ErrGenreCycle
+			}
+		}
+
+		updated, err := queryGenre(
+			ctx,
+			tx,
+			"update genres set parent_id = $1, name = $2, slug = $3, dewey = $4, bisac = $5, updated_at = now() where id = $6 returning "+genreColumns,
+			genre.ParentId,
+			genre.Name,
+			genre.Slug,
+			genre.Dewey,
+			genre.Bisac,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		*genre = *updated
+		return nil
+	})
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	return nil
+}
+
+// DeleteGenreById only removes genres without books or subgenres, books in the trash included.
+func (r *PgRepository) DeleteGenreById(ctx context.Context, id string) error {
+	ctx, span := r.traceProvider.Tracer("genreRepository").
+		Start(ctx, "DeleteGenreById", trace.WithAttributes(attribute.String("genreId", id)))
+	defer span.End()
+
+	connection, err := r.connectionPool.Acquire(ctx)
+	if err != nil {
+		return ErrRepositoryUnavailable.WithCause(err)
+	}
+	defer connection.Release()
+
+	tag, err := connection.Exec(ctx, "delete from genres where id = $1", id)
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) && pgError.Code == pgForeignKeyViolation {
+		return ErrGenreInUse.WithCause(err)
+	}
+	if err != nil {
+		return mapPgError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrGenreNotFound
+	}
+
+	return nil
+}
+
+func queryGenre(ctx context.Context, tx pgx.Tx, sql string, args ...any) (*GenreDTO, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[GenreDTO])
+}
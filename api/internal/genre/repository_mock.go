@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/genre/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/genre/repository.go -destination=internal/genre/repository_mock.go -package=genre
+//
+
+// Package genre is a generated GoMock package.
+package genre
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateGenre mocks base method.
+func (m *MockRepository) CreateGenre(ctx context.Context, genre *GenreDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGenre", ctx, genre)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGenre indicates an expected call of CreateGenre.
+func (mr *MockRepositoryMockRecorder) CreateGenre(ctx, genre any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGenre", reflect.TypeOf((*MockRepository)(nil).CreateGenre), ctx, genre)
+}
+
+// DeleteGenreById mocks base method.
+func (m *MockRepository) DeleteGenreById(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGenreById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGenreById indicates an expected call of DeleteGenreById.
+func (mr *MockRepositoryMockRecorder) DeleteGenreById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGenreById", reflect.TypeOf((*MockRepository)(nil).DeleteGenreById), ctx, id)
+}
+
+// GetGenreById mocks base method.
+func (m *MockRepository) GetGenreById(ctx context.Context, id string) (*GenreDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenreById", ctx, id)
+	ret0, _ := ret[0].(*GenreDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenreById indicates an expected call of GetGenreById.
+func (mr *MockRepositoryMockRecorder) GetGenreById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenreById", reflect.TypeOf((*MockRepository)(nil).GetGenreById), ctx, id)
+}
+
+// GetGenres mocks base method.
+func (m *MockRepository) GetGenres(ctx context.Context) ([]GenreDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenres", ctx)
+	ret0, _ := ret[0].([]GenreDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenres indicates an expected call of GetGenres.
+func (mr *MockRepositoryMockRecorder) GetGenres(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenres", reflect.TypeOf((*MockRepository)(nil).GetGenres), ctx)
+}
+
+// UpdateGenreById mocks base method.
+func (m *MockRepository) UpdateGenreById(ctx context.Context, id string, genre *GenreDTO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenreById", ctx, id, genre)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGenreById indicates an expected call of UpdateGenreById.
+func (mr *MockRepositoryMockRecorder) UpdateGenreById(ctx, id, genre any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenreById", reflect.TypeOf((*MockRepository)(nil).UpdateGenreById), ctx, id, genre)
+}
@@ -0,0 +1,13 @@
+package publisher
+
+import "book-api/pkg/apperror"
+
+var (
+	ErrPublisherNotFound      = apperror.NotFound("PUBLISHER_NOT_FOUND", "publisher not found")
+	ErrPublisherAlreadyExists = apperror.Conflict("PUBLISHER_ALREADY_EXISTS", "a publisher with this name already exists")
+	ErrPublisherHasBooks      = apperror.Conflict("PUBLISHER_HAS_BOOKS", "publisher still has books")
+	ErrInvalidPublisherName   = apperror.Invalid("INVALID_PUBLISHER_NAME", "publisher name must contain a letter or digit")
+	ErrUnknownPublisher       = apperror.Invalid("UNKNOWN_PUBLISHER", "publisher does not exist")
+	ErrRepositoryUnavailable  = apperror.Unavailable("PUBLISHER_REPOSITORY_UNAVAILABLE", "publisher repository is unavailable")
+	ErrRepositoryFailure      = apperror.Internal("PUBLISHER_REPOSITORY_FAILURE", "publisher repository failed")
+)
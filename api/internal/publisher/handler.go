@@ -0,0 +1,20 @@
+package publisher
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/internal/catalog"
+)
+
+// NewHandler serves /publisher and the list at /publishers.
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	repository Repository,
+	opts ...catalog.HandlerOption,
+) *catalog.Handler[PublisherDTO] {
+	return catalog.NewHandler(server, validator, tracer, kind, repository, opts...)
+}
@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+
+	"book-api/internal/catalog"
+	"book-api/pkg/apperror"
+)
+
+func TestNewHandler(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("create and list", func(t *testing.T) {
+		mockRepository := catalog.NewMockRepository[PublisherDTO](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockRepository.EXPECT().List(gomock.Any(), catalog.PageRequest{Limit: 20}).
+			Return(&catalog.Page[PublisherDTO]{Records: []PublisherDTO{{Id: uuid.NewString(), Name: "Allen & Unwin"}}}, nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/publisher", `{"name":"O'Reilly Media"}`)
+		assert.Equal(t, fiber.StatusCreated, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get(fiber.HeaderLocation), "/publisher/"))
+
+		var created struct {
+			Publisher PublisherDTO `json:"publisher"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&created))
+		assert.Equal(t, "O'Reilly Media", created.Publisher.Name)
+
+		res = send(t, server, http.MethodGet, "/publishers", "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var page struct {
+			Publishers []PublisherDTO `json:"publishers"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+		assert.Len(t, page.Publishers, 1)
+	})
+
+	t.Run("spelling variant of an existing publisher", func(t *testing.T) {
+		existingId := uuid.NewString()
+		mockRepository := catalog.NewMockRepository[PublisherDTO](mockController)
+		mockRepository.EXPECT().Create(gomock.Any(), gomock.Any()).Return(ErrPublisherAlreadyExists.WithMeta("publisherId", existingId))
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodPost, "/publisher", `{"name":"OReilly Media"}`)
+		assert.Equal(t, fiber.StatusConflict, res.StatusCode)
+		assert.Equal(t, "/publisher/"+existingId, res.Header.Get(fiber.HeaderLocation))
+	})
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupHandler(repository Repository) *fiber.App {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	NewHandler(server, validate, otel.Tracer("publisher"), repository).RegisterHandlers()
+
+	return server
+}
@@ -0,0 +1,23 @@
+package publisher
+
+import (
+	"time"
+
+	"book-api/internal/catalog"
+)
+
+type PublisherDTO struct {
+	Id        string     `json:"id" db:"id" validate:"omitempty,uuid"`
+	Name      string     `json:"name" db:"name" validate:"required,max=255"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+func (p PublisherDTO) Position() catalog.Cursor {
+	return catalog.Cursor{Label: p.Name, Id: p.Id}
+}
+
+func (p PublisherDTO) WithId(id string) PublisherDTO {
+	p.Id = id
+	return p
+}
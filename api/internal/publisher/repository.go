@@ -0,0 +1,31 @@
+package publisher
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+)
+
+// kind folds publisher names like author names, so "O'Reilly Media" and "OReilly media" are one publisher.
+var kind = catalog.Kind{
+	Name:         "publisher",
+	Plural:       "publishers",
+	Table:        "publishers",
+	Label:        "name",
+	FoldedLabels: true,
+	Errors: catalog.Errors{
+		NotFound:      ErrPublisherNotFound,
+		AlreadyExists: ErrPublisherAlreadyExists,
+		InUse:         ErrPublisherHasBooks,
+		InvalidLabel:  ErrInvalidPublisherName,
+		Unavailable:   ErrRepositoryUnavailable,
+		Failure:       ErrRepositoryFailure,
+	},
+}
+
+type Repository = catalog.Repository[PublisherDTO]
+
+func NewPgRepository(traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *catalog.PgRepository[PublisherDTO] {
+	return catalog.NewPgRepository[PublisherDTO](kind, traceProvider, connectionPool)
+}
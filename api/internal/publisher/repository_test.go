@@ -0,0 +1,95 @@
+//go:build !pact
+// +build !pact
+
+package publisher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+	"book-api/migrations"
+	"book-api/pkg/migrate"
+)
+
+func TestPgRepository_Publishers(t *testing.T) {
+	t.Run("spelling variants are one publisher", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		oreilly := &PublisherDTO{Id: uuid.NewString(), Name: "O'Reilly Media"}
+		require.NoError(t, pgRepository.Create(ctx, oreilly))
+
+		err := pgRepository.Create(ctx, &PublisherDTO{Id: uuid.NewString(), Name: "oreilly  media"})
+		assert.ErrorIs(t, err, ErrPublisherAlreadyExists)
+
+		page, err := pgRepository.List(ctx, catalog.PageRequest{Search: "reilly m", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Records, 1)
+		assert.Equal(t, oreilly.Id, page.Records[0].Id)
+	})
+
+	t.Run("publishers with books cannot be deleted", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		publisher := &PublisherDTO{Id: uuid.NewString(), Name: "Allen & Unwin"}
+		require.NoError(t, pgRepository.Create(ctx, publisher))
+
+		_, err := pgRepository.ConnectionPool().Exec(
+			ctx,
+			"insert into books (id, cover_url, isbn, title, author, publication_year, publisher_id) values ($1, '', '', 'The Hobbit', 'J.R.R. Tolkien', '1937', $2)",
+			uuid.NewString(),
+			publisher.Id,
+		)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, pgRepository.DeleteById(ctx, publisher.Id), ErrPublisherHasBooks)
+	})
+
+	t.Run("name without letters", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+
+		err := pgRepository.Create(context.TODO(), &PublisherDTO{Id: uuid.NewString(), Name: "..."})
+		assert.ErrorIs(t, err, ErrInvalidPublisherName)
+	})
+}
+
+func setupRepository(t *testing.T) *catalog.PgRepository[PublisherDTO] {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres:alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connectionString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
+
+	return NewPgRepository(trace.NewTracerProvider(), pool)
+}
@@ -0,0 +1,12 @@
+package series
+
+import "book-api/pkg/apperror"
+
+var (
+	ErrSeriesNotFound        = apperror.NotFound("SERIES_NOT_FOUND", "series not found")
+	ErrSeriesHasBooks        = apperror.Conflict("SERIES_HAS_BOOKS", "series still has books")
+	ErrInvalidSeriesName     = apperror.Invalid("INVALID_SERIES_NAME", "series name must not be blank")
+	ErrUnknownSeries         = apperror.Invalid("UNKNOWN_SERIES", "series does not exist")
+	ErrRepositoryUnavailable = apperror.Unavailable("SERIES_REPOSITORY_UNAVAILABLE", "series repository is unavailable")
+	ErrRepositoryFailure     = apperror.Internal("SERIES_REPOSITORY_FAILURE", "series repository failed")
+)
@@ -0,0 +1,20 @@
+package series
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/internal/catalog"
+)
+
+// NewHandler serves /series, which is both the list and the prefix of a single series.
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	repository Repository,
+	opts ...catalog.HandlerOption,
+) *catalog.Handler[SeriesDTO] {
+	return catalog.NewHandler(server, validator, tracer, kind, repository, opts...)
+}
@@ -0,0 +1,80 @@
+package series
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+
+	"book-api/internal/catalog"
+	"book-api/pkg/apperror"
+)
+
+func TestNewHandler(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	// the list and a single series share the /series prefix
+	seriesId := uuid.NewString()
+	mockRepository := catalog.NewMockRepository[SeriesDTO](mockController)
+	mockRepository.EXPECT().List(gomock.Any(), catalog.PageRequest{Limit: 20}).
+		Return(&catalog.Page[SeriesDTO]{Records: []SeriesDTO{{Id: seriesId, Name: "Discworld"}}}, nil)
+	mockRepository.EXPECT().GetById(gomock.Any(), seriesId).Return(&SeriesDTO{Id: seriesId, Name: "Discworld"}, nil)
+
+	server := setupHandler(mockRepository)
+
+	res := send(t, server, http.MethodGet, "/series", "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var page struct {
+		Series []SeriesDTO `json:"series"`
+	}
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&page))
+	assert.Len(t, page.Series, 1)
+
+	res = send(t, server, http.MethodGet, "/series/"+seriesId, "")
+	require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	var body struct {
+		Series SeriesDTO `json:"series"`
+	}
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+	assert.Equal(t, "Discworld", body.Series.Name)
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupHandler(repository Repository) *fiber.App {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	NewHandler(server, validate, otel.Tracer("series"), repository).RegisterHandlers()
+
+	return server
+}
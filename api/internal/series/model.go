@@ -0,0 +1,23 @@
+package series
+
+import (
+	"time"
+
+	"book-api/internal/catalog"
+)
+
+type SeriesDTO struct {
+	Id        string     `json:"id" db:"id" validate:"omitempty,uuid"`
+	Name      string     `json:"name" db:"name" validate:"required,max=255"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+func (s SeriesDTO) Position() catalog.Cursor {
+	return catalog.Cursor{Label: s.Name, Id: s.Id}
+}
+
+func (s SeriesDTO) WithId(id string) SeriesDTO {
+	s.Id = id
+	return s
+}
@@ -0,0 +1,30 @@
+package series
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+)
+
+// kind lets series names repeat, two publishers may well run a "Collected Works". The singular and the
+// plural are the same word, so the list and the single series share the /series prefix.
+var kind = catalog.Kind{
+	Name:   "series",
+	Plural: "series",
+	Table:  "series",
+	Label:  "name",
+	Errors: catalog.Errors{
+		NotFound:     ErrSeriesNotFound,
+		InUse:        ErrSeriesHasBooks,
+		InvalidLabel: ErrInvalidSeriesName,
+		Unavailable:  ErrRepositoryUnavailable,
+		Failure:      ErrRepositoryFailure,
+	},
+}
+
+type Repository = catalog.Repository[SeriesDTO]
+
+func NewPgRepository(traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *catalog.PgRepository[SeriesDTO] {
+	return catalog.NewPgRepository[SeriesDTO](kind, traceProvider, connectionPool)
+}
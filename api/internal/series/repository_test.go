@@ -0,0 +1,91 @@
+//go:build !pact
+// +build !pact
+
+package series
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+	"book-api/migrations"
+	"book-api/pkg/migrate"
+)
+
+func TestPgRepository_Series(t *testing.T) {
+	t.Run("names may repeat", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		require.NoError(t, pgRepository.Create(ctx, &SeriesDTO{Id: uuid.NewString(), Name: "Collected Works"}))
+		require.NoError(t, pgRepository.Create(ctx, &SeriesDTO{Id: uuid.NewString(), Name: "collected works"}))
+
+		page, err := pgRepository.List(ctx, catalog.PageRequest{Search: "COLLECTED", Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page.Records, 2)
+	})
+
+	t.Run("series with books cannot be deleted", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		series := &SeriesDTO{Id: uuid.NewString(), Name: "Discworld"}
+		require.NoError(t, pgRepository.Create(ctx, series))
+
+		_, err := pgRepository.ConnectionPool().Exec(
+			ctx,
+			"insert into books (id, cover_url, isbn, title, author, publication_year, series_id, series_position) values ($1, '', '', 'Mort', 'Terry Pratchett', '1987', $2, 4)",
+			uuid.NewString(),
+			series.Id,
+		)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, pgRepository.DeleteById(ctx, series.Id), ErrSeriesHasBooks)
+	})
+
+	t.Run("blank name", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+
+		err := pgRepository.Create(context.TODO(), &SeriesDTO{Id: uuid.NewString(), Name: "  "})
+		assert.ErrorIs(t, err, ErrInvalidSeriesName)
+	})
+}
+
+func setupRepository(t *testing.T) *catalog.PgRepository[SeriesDTO] {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres:alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connectionString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
+
+	return NewPgRepository(trace.NewTracerProvider(), pool)
+}
@@ -0,0 +1,165 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+type DomainRuleType string
+
+const (
+	// DomainExact allows the domain itself and nothing else
+	DomainExact DomainRuleType = "exact"
+	// DomainWildcard is written *.example.com and allows every subdomain of example.com but not example.com
+	DomainWildcard DomainRuleType = "wildcard"
+	// DomainRegistrable allows every host whose registrable domain, one label below its public suffix, is the domain
+	DomainRegistrable DomainRuleType = "registrable"
+)
+
+type DomainRule struct {
+	Name   string
+	Type   DomainRuleType
+	Domain string
+}
+
+// DomainRuleFailure tells why a host did not pass one rule of an allowlist.
+type DomainRuleFailure struct {
+	Rule   string         `json:"rule"`
+	Type   DomainRuleType `json:"type"`
+	Domain string         `json:"domain"`
+	Reason string         `json:"reason"`
+}
+
+var (
+	ErrInvalidDomainRule = errors.New("domain rule type must be exact, wildcard or registrable")
+	ErrPublicSuffixRule  = errors.New("domain rule would allow every domain under a public suffix")
+)
+
+// Allowlist accepts a host when any of its rules does. Hosts and rule domains are compared in their
+// lowercase ASCII form, so bücher.example and xn--bcher-kva.example are the same host.
+type Allowlist struct {
+	rules []DomainRule
+}
+
+func NewAllowlist(rules []DomainRule) (*Allowlist, error) {
+	allowlist := &Allowlist{rules: make([]DomainRule, 0, len(rules))}
+	for i, rule := range rules {
+		compiled, err := compileDomainRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("domain rule %d %q: %w", i, rule.Name, err)
+		}
+		allowlist.rules = append(allowlist.rules, compiled)
+	}
+
+	return allowlist, nil
+}
+
+func compileDomainRule(rule DomainRule) (DomainRule, error) {
+	domain := rule.Domain
+	if rule.Type == DomainWildcard {
+		var found bool
+		if domain, found = strings.CutPrefix(domain, "*."); !found {
+			return rule, fmt.Errorf("wildcard domain must start with *., got %q", rule.Domain)
+		}
+	}
+
+	domain, err := asciiHost(domain)
+	if err != nil {
+		return rule, err
+	}
+
+	switch rule.Type {
+	case DomainExact:
+	case DomainWildcard:
+		if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+			return rule, fmt.Errorf("%w: %s", ErrPublicSuffixRule, rule.Domain)
+		}
+	case DomainRegistrable:
+		registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+		if err != nil {
+			return rule, fmt.Errorf("%w: %s", ErrPublicSuffixRule, rule.Domain)
+		}
+		if registrable != domain {
+			return rule, fmt.Errorf("registrable rule must name a registrable domain, %s is below %s", domain, registrable)
+		}
+	default:
+		return rule, fmt.Errorf("%w, got %q", ErrInvalidDomainRule, rule.Type)
+	}
+
+	rule.Domain = domain
+	return rule, nil
+}
+
+// Check returns nil when a rule allows host, or else ErrHostNotAllowed listing why each rule refused it.
+func (a *Allowlist) Check(host string) error {
+	normalized, err := asciiHost(host)
+	if err != nil {
+		return ErrInvalidHost.WithCause(err).WithMeta("host", host)
+	}
+
+	failures := make([]DomainRuleFailure, 0, len(a.rules))
+	for _, rule := range a.rules {
+		reason := rule.refuse(normalized)
+		if reason == "" {
+			return nil
+		}
+
+		failures = append(failures, DomainRuleFailure{Rule: rule.Name, Type: rule.Type, Domain: rule.Domain, Reason: reason})
+	}
+
+	return ErrHostNotAllowed.
+		WithMeta("host", host).
+		WithMeta("normalizedHost", normalized).
+		WithMeta("rules", failures)
+}
+
+// refuse says why the rule does not allow host, an empty reason allows it.
+func (r DomainRule) refuse(host string) string {
+	_, ipErr := netip.ParseAddr(host)
+	isIP := ipErr == nil
+
+	switch r.Type {
+	case DomainExact:
+		if host != r.Domain {
+			return fmt.Sprintf("host is not %s", r.Domain)
+		}
+	case DomainWildcard:
+		if isIP || !strings.HasSuffix(host, "."+r.Domain) {
+			return fmt.Sprintf("host is not a subdomain of %s", r.Domain)
+		}
+	case DomainRegistrable:
+		if isIP {
+			return "host is an ip address"
+		}
+
+		registrable, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			return "host is a public suffix"
+		}
+		if registrable != r.Domain {
+			return fmt.Sprintf("registrable domain of host is %s, not %s", registrable, r.Domain)
+		}
+	}
+
+	return ""
+}
+
+// asciiHost lowercases host and converts international labels to punycode, a trailing dot is dropped
+// and ip addresses are kept as they are.
+func asciiHost(host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", errors.New("host is empty")
+	}
+
+	if address, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return address.String(), nil
+	}
+
+	return idna.Lookup.ToASCII(host)
+}
@@ -0,0 +1,96 @@
+package url
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"book-api/pkg/apperror"
+)
+
+func TestAllowlist_Check(t *testing.T) {
+	allowlist, err := NewAllowlist([]DomainRule{
+		{Name: "site", Type: DomainExact, Domain: "byfood.com"},
+		{Name: "regions", Type: DomainWildcard, Domain: "*.byfood.jp"},
+		{Name: "uk", Type: DomainRegistrable, Domain: "byfood.co.uk"},
+		{Name: "japanese", Type: DomainRegistrable, Domain: "食べ物.jp"},
+		{Name: "local", Type: DomainExact, Domain: "127.0.0.1"},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		host    string
+		allowed bool
+	}{
+		{host: "byfood.com", allowed: true},
+		{host: "BYFOOD.com.", allowed: true},
+		{host: "www.byfood.com"},
+		{host: "tokyo.byfood.jp", allowed: true},
+		{host: "a.b.byfood.jp", allowed: true},
+		{host: "byfood.jp"},
+		{host: "evilbyfood.jp"},
+		{host: "byfood.co.uk", allowed: true},
+		{host: "www.byfood.co.uk", allowed: true},
+		{host: "byfood.co.uk.evil.com"},
+		{host: "co.uk"},
+		{host: "www.食べ物.jp", allowed: true},
+		{host: "www.xn--59j689q5dya.jp", allowed: true},
+		{host: "127.0.0.1", allowed: true},
+		{host: "10.0.0.1"},
+		{host: "facebook.com"},
+	}
+	for _, c := range cases {
+		t.Run(c.host, func(t *testing.T) {
+			err := allowlist.Check(c.host)
+			if c.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrHostNotAllowed)
+			}
+		})
+	}
+
+	t.Run("failures name every rule", func(t *testing.T) {
+		err := allowlist.Check("byfood.co.uk.evil.com")
+
+		var appError *apperror.Error
+		require.ErrorAs(t, err, &appError)
+		assert.Equal(t, "byfood.co.uk.evil.com", appError.Meta["normalizedHost"])
+
+		failures := appError.Meta["rules"].([]DomainRuleFailure)
+		require.Len(t, failures, 5)
+		assert.Equal(t, DomainRuleFailure{Rule: "site", Type: DomainExact, Domain: "byfood.com", Reason: "host is not byfood.com"}, failures[0])
+		assert.Equal(t, "registrable domain of host is evil.com, not byfood.co.uk", failures[2].Reason)
+		assert.Equal(t, "xn--59j689q5dya.jp", failures[3].Domain)
+	})
+
+	t.Run("invalid host", func(t *testing.T) {
+		assert.ErrorIs(t, allowlist.Check("bad_host.com"), ErrInvalidHost)
+		assert.ErrorIs(t, allowlist.Check(""), ErrInvalidHost)
+	})
+}
+
+func TestNewAllowlist(t *testing.T) {
+	cases := []struct {
+		name string
+		rule DomainRule
+		err  error
+	}{
+		{name: "unknown type", rule: DomainRule{Type: "suffix", Domain: "byfood.com"}, err: ErrInvalidDomainRule},
+		{name: "wildcard over a public suffix", rule: DomainRule{Type: DomainWildcard, Domain: "*.co.uk"}, err: ErrPublicSuffixRule},
+		{name: "registrable public suffix", rule: DomainRule{Type: DomainRegistrable, Domain: "com"}, err: ErrPublicSuffixRule},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewAllowlist([]DomainRule{c.rule})
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+
+	_, err := NewAllowlist([]DomainRule{{Type: DomainWildcard, Domain: "byfood.com"}})
+	assert.Error(t, err)
+
+	_, err = NewAllowlist([]DomainRule{{Type: DomainRegistrable, Domain: "www.byfood.com"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,266 @@
+package url
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"runtime"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/bodylimit"
+)
+
+const (
+	DefaultMaxBatchItems = 50000
+	DefaultMaxBatchBytes = 16 << 20
+	// batchFlushItems is how often a batch response flushes to the client and pushes its write deadline forward
+	batchFlushItems   = 200
+	batchWriteTimeout = 30 * time.Second
+	maxBatchLineBytes = 64 << 10
+)
+
+// BatchOptions bounds POST /urls/batch, zero values fall back to the defaults above and one worker per CPU.
+type BatchOptions struct {
+	MaxItems    int
+	MaxBytes    int64
+	Concurrency int
+}
+
+// WithBatchOptions overrides the limits and the worker count of POST /urls/batch.
+func WithBatchOptions(options BatchOptions) HandlerOption {
+	return func(h *Handler) {
+		h.batch = options
+	}
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.MaxItems <= 0 {
+		o.MaxItems = DefaultMaxBatchItems
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = DefaultMaxBatchBytes
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	return o
+}
+
+// batchItem is an item as read from the body, err is set when the item itself could not be decoded.
+type batchItem struct {
+	BatchUrlItem
+	err error
+}
+
+// GetUrlBatch processes a JSON array or NDJSON stream of urls on a bounded worker pool. The whole body is read
+// and checked against the limits first, then one NDJSON result per item is streamed back in input order.
+func (h *Handler) GetUrlBatch(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "GetUrlBatch")
+	defer span.End()
+
+	body := bodylimit.Body(ctx, h.batch.MaxBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	var (
+		items []batchItem
+		err   error
+	)
+	switch mediaType {
+	case fiber.MIMEApplicationJSON:
+		items, err = readJSONBatch(body, h.batch.MaxItems)
+	case "application/x-ndjson", "application/ndjson":
+		items, err = readNDJSONBatch(body, h.batch.MaxItems)
+	default:
+		return ErrUnsupportedBatchType.WithMeta("contentType", mediaType)
+	}
+	if errors.Is(err, bodylimit.ErrTooLarge) {
+		ctx.Context().SetConnectionClose()
+		return ErrBatchTooLarge.WithMeta("maxBytes", h.batch.MaxBytes)
+	}
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return ErrEmptyBatch
+	}
+
+	span.SetAttributes(attribute.String("format", mediaType), attribute.Int("items", len(items)))
+
+	// results are only known once the workers get to them, so they go out while the batch runs: the
+	// writer waits for each item in input order and flushes every batchFlushItems results, pushing the
+	// connection's write deadline forward each time so a long batch outlives the server write timeout.
+	// It runs after GetUrlBatch returned, hence the request context and connection are taken here.
+	batchCtx := ctx.UserContext()
+	conn := ctx.Context().Conn()
+
+	ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		written, err := h.writeBatch(batchCtx, items, w, func() {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(batchWriteTimeout))
+			}
+		})
+		if err != nil {
+			zap.L().Error("url batch failed", zap.Int("written", written), zap.Int("items", len(items)), zap.Error(err))
+		}
+	})
+
+	return nil
+}
+
+// writeBatch hands the items to the workers and writes their results in input order, every item has a
+// buffered result channel of its own so a worker never waits for the writer.
+func (h *Handler) writeBatch(ctx context.Context, items []batchItem, w *bufio.Writer, extendDeadline func()) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan BatchUrlResult, len(items))
+	for i := range results {
+		results[i] = make(chan BatchUrlResult, 1)
+	}
+
+	queue := make(chan int)
+	go func() {
+		defer close(queue)
+		for i := range items {
+			select {
+			case <-ctx.Done():
+				return
+			case queue <- i:
+			}
+		}
+	}()
+
+	for range min(h.batch.Concurrency, len(items)) {
+		go func() {
+			for i := range queue {
+				results[i] <- h.processItem(ctx, i, items[i])
+			}
+		}()
+	}
+
+	encoder := json.ConfigDefault.NewEncoder(w)
+	for i, result := range results {
+		if err := encoder.Encode(<-result); err != nil {
+			return i, err
+		}
+
+		if (i+1)%batchFlushItems == 0 {
+			extendDeadline()
+			if err := w.Flush(); err != nil {
+				return i + 1, err
+			}
+		}
+	}
+
+	return len(results), w.Flush()
+}
+
+func (h *Handler) processItem(ctx context.Context, index int, item batchItem) BatchUrlResult {
+	result := BatchUrlResult{Index: index, Url: item.Url, Operation: item.Operation}
+
+	err := item.err
+	if err == nil && item.Url == "" {
+		err = ErrUrlRequired
+	}
+
+	var u *url.URL
+	if err == nil {
+		if u, err = url.Parse(item.Url); err != nil {
+			err = ErrInvalidUrl.WithCause(err)
+		}
+	}
+
+	if err == nil {
+		result.ProcessedUrl, err = h.process(ctx, GetUrlRequest{Operation: item.Operation, Url: u, Normalize: item.Normalize})
+	}
+
+	if err != nil {
+		result.Error = apperror.NewProblem(err)
+	}
+
+	return result
+}
+
+// readJSONBatch splits the array before decoding its items, an item of the wrong shape fails on its own
+// while broken JSON fails the whole batch.
+func readJSONBatch(body io.Reader, maxItems int) ([]batchItem, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, batchReadError(err, "")
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, batchReadError(nil, "body must be a JSON array")
+	}
+
+	var raw []json.NoCopyRawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, batchReadError(err, "")
+	}
+	if len(raw) > maxItems {
+		return nil, ErrTooManyBatchItems.WithMeta("maxItems", maxItems)
+	}
+
+	items := make([]batchItem, len(raw))
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], &items[i].BatchUrlItem); err != nil {
+			items[i].err = apperror.ErrInvalidBody.WithCause(err)
+		}
+	}
+
+	return items, nil
+}
+
+// readNDJSONBatch skips blank lines, a line that is not a JSON object fails on its own.
+func readNDJSONBatch(body io.Reader, maxItems int) ([]batchItem, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxBatchLineBytes)
+
+	var items []batchItem
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == maxItems {
+			return nil, ErrTooManyBatchItems.WithMeta("maxItems", maxItems)
+		}
+
+		var item batchItem
+		if err := json.Unmarshal(line, &item.BatchUrlItem); err != nil {
+			item.err = apperror.ErrInvalidBody.WithCause(err)
+		}
+		items = append(items, item)
+	}
+
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return nil, ErrBatchLineTooLong.WithMeta("line", len(items)+1).WithMeta("maxBytes", maxBatchLineBytes)
+	}
+
+	return items, batchReadError(scanner.Err(), "")
+}
+
+func batchReadError(err error, detail string) error {
+	switch {
+	case errors.Is(err, bodylimit.ErrTooLarge):
+		return err
+	case err == nil && detail == "":
+		return nil
+	case err == nil:
+		return apperror.ErrInvalidBody.WithCause(errors.New(detail))
+	}
+
+	return apperror.ErrInvalidBody.WithCause(err)
+}
@@ -0,0 +1,220 @@
+package url
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"book-api/pkg/apperror"
+)
+
+func Test_GetUrlBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json array",
+			contentType: fiber.MIMEApplicationJSON,
+			body: `[
+				{"url": "https://BYFOOD.com/food-EXPeriences?query=abc/", "operation": "all"},
+				{"url": "https://byfood.com/tours/", "operation": "canonical"},
+				{"url": "https://example.org/", "operation": "canonical"},
+				{"url": "https://byfood.com/", "operation": "shorten"},
+				{"url": "", "operation": "all"},
+				{"url": "http://byfood.com/%zz", "operation": "all"},
+				{"url": 42, "operation": "all"}
+			]`,
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			body: `{"url": "https://BYFOOD.com/food-EXPeriences?query=abc/", "operation": "all"}
+{"url": "https://byfood.com/tours/", "operation": "canonical"}
+
+{"url": "https://example.org/", "operation": "canonical"}
+{"url": "https://byfood.com/", "operation": "shorten"}
+{"url": "", "operation": "all"}
+{"url": "http://byfood.com/%zz", "operation": "all"}
+{"url": 42
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupBatchServer(t, BatchOptions{Concurrency: 3})
+
+			req, err := http.NewRequest(http.MethodPost, "/urls/batch", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderContentType, tc.contentType)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "application/x-ndjson", res.Header.Get(fiber.HeaderContentType))
+
+			results := readBatchResults(t, res)
+			require.Len(t, results, 7)
+			for i, result := range results {
+				assert.Equal(t, i, result.Index)
+			}
+
+			assert.Equal(t, "https://www.byfood.com/food-EXPeriences", results[0].ProcessedUrl)
+			assert.Nil(t, results[0].Error)
+			assert.Equal(t, "https://byfood.com/tours", results[1].ProcessedUrl)
+			assert.Equal(t, "URL_HOST_NOT_ALLOWED", results[2].Error.Code)
+			assert.Equal(t, "URL_UNSUPPORTED_OPERATION", results[3].Error.Code)
+			assert.Equal(t, "URL_REQUIRED", results[4].Error.Code)
+			assert.Equal(t, "URL_INVALID", results[5].Error.Code)
+			assert.Equal(t, "http://byfood.com/%zz", results[5].Url)
+			assert.Equal(t, "INVALID_BODY", results[6].Error.Code)
+			assert.Empty(t, results[6].ProcessedUrl)
+		})
+	}
+}
+
+func Test_GetUrlBatch_KeepsInputOrder(t *testing.T) {
+	server := setupBatchServer(t, BatchOptions{Concurrency: 8})
+
+	var body strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&body, "{\"url\": \"https://byfood.com/tours/%d/\", \"operation\": \"canonical\"}\n", i)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/urls/batch", strings.NewReader(body.String()))
+	require.NoError(t, err)
+	req.Header.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	results := readBatchResults(t, res)
+	require.Len(t, results, 1000)
+	for i, result := range results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, fmt.Sprintf("https://byfood.com/tours/%d", i), result.ProcessedUrl)
+	}
+}
+
+func Test_GetUrlBatch_Rejected(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        BatchOptions
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "too many items",
+			options:        BatchOptions{MaxItems: 2},
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `[{"url": "https://byfood.com/a"}, {"url": "https://byfood.com/b"}, {"url": "https://byfood.com/c"}]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_BATCH_TOO_MANY_ITEMS",
+		},
+		{
+			name:           "too many ndjson lines",
+			options:        BatchOptions{MaxItems: 1},
+			contentType:    "application/x-ndjson",
+			body:           "{\"url\": \"https://byfood.com/a\"}\n{\"url\": \"https://byfood.com/b\"}\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_BATCH_TOO_MANY_ITEMS",
+		},
+		{
+			name:           "body too large",
+			options:        BatchOptions{MaxBytes: 32},
+			contentType:    "application/x-ndjson",
+			body:           "{\"url\": \"https://byfood.com/food-experiences\", \"operation\": \"all\"}\n",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "URL_BATCH_TOO_LARGE",
+		},
+		{
+			name:           "empty",
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_BATCH_EMPTY",
+		},
+		{
+			name:           "not an array",
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `{"url": "https://byfood.com/a", "operation": "all"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_BODY",
+		},
+		{
+			name:           "broken json",
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `[{"url": "https://byfood.com/a", "operation": "all"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_BODY",
+		},
+		{
+			name:           "unsupported type",
+			contentType:    "text/csv",
+			body:           "url,operation\n",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   "URL_BATCH_UNSUPPORTED_TYPE",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupBatchServer(t, tc.options)
+
+			req, err := http.NewRequest(http.MethodPost, "/urls/batch", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderContentType, tc.contentType)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			var problem apperror.Problem
+			require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+			assert.Equal(t, tc.expectedCode, problem.Code)
+		})
+	}
+}
+
+func setupBatchServer(t *testing.T, options BatchOptions) *fiber.App {
+	allowlist, err := NewAllowlist([]DomainRule{{Name: "byfood", Type: DomainRegistrable, Domain: "byfood.com"}})
+	require.NoError(t, err)
+
+	server, validate := setupServer()
+	NewHandler(
+		server,
+		validate,
+		otel.Tracer("url"),
+		WithRuleSets(byfoodRuleSets(t)),
+		WithAllowlists(map[string]*Allowlist{"canonical": allowlist, "all": allowlist}),
+		WithBatchOptions(options),
+	).RegisterHandlers()
+
+	return server
+}
+
+func readBatchResults(t *testing.T, res *http.Response) []BatchUrlResult {
+	var results []BatchUrlResult
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var result BatchUrlResult
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		results = append(results, result)
+	}
+	require.NoError(t, scanner.Err())
+
+	return results
+}
@@ -0,0 +1,22 @@
+package url
+
+import "book-api/pkg/apperror"
+
+var (
+	ErrUrlRequired          = apperror.Invalid("URL_REQUIRED", "url is required")
+	ErrInvalidUrl           = apperror.Invalid("URL_INVALID", "url could not be parsed")
+	ErrHostNotAllowed       = apperror.Invalid("URL_HOST_NOT_ALLOWED", "url host is not allowed for this operation")
+	ErrInvalidHost          = apperror.Invalid("URL_INVALID_HOST", "url host is not a valid domain name or ip address")
+	ErrUnsupportedOperation = apperror.Invalid("URL_UNSUPPORTED_OPERATION", "url operation is not supported")
+
+	ErrEmptyBatch           = apperror.Invalid("URL_BATCH_EMPTY", "url batch has no urls")
+	ErrTooManyBatchItems    = apperror.Invalid("URL_BATCH_TOO_MANY_ITEMS", "url batch has more urls than allowed")
+	ErrBatchLineTooLong     = apperror.Invalid("URL_BATCH_LINE_TOO_LONG", "url batch line is longer than allowed")
+	ErrBatchTooLarge        = apperror.TooLarge("URL_BATCH_TOO_LARGE", "url batch body is larger than allowed")
+	ErrUnsupportedBatchType = apperror.UnsupportedMediaType("URL_BATCH_UNSUPPORTED_TYPE", "url batch must be a JSON array or NDJSON")
+
+	ErrInvalidSitemap         = apperror.Invalid("SITEMAP_INVALID", "sitemap must be a well-formed urlset or sitemapindex")
+	ErrTooManySitemapEntries  = apperror.Invalid("SITEMAP_TOO_MANY_ENTRIES", "sitemap has more entries than allowed")
+	ErrSitemapTooLarge        = apperror.TooLarge("SITEMAP_TOO_LARGE", "sitemap is larger than allowed")
+	ErrUnsupportedSitemapType = apperror.UnsupportedMediaType("SITEMAP_UNSUPPORTED_TYPE", "sitemap must be sent as application/xml or text/xml")
+)
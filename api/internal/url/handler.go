@@ -1,31 +1,61 @@
 package url
 
 import (
-	"net/url"
-	"strings"
+	"context"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+
+	"book-api/pkg/apperror"
 )
 
 type Handler struct {
-	server    *fiber.App
-	validator *validator.Validate
-	tracer    trace.Tracer
+	server     *fiber.App
+	validator  *validator.Validate
+	tracer     trace.Tracer
+	ruleSets   map[string]*RuleSet
+	allowlists map[string]*Allowlist
+	batch      BatchOptions
+	sitemap    SitemapOptions
+}
+
+type HandlerOption func(*Handler)
+
+// WithRuleSets names the rule sets GetUrlRequest.Operation selects, without it every operation is unsupported.
+func WithRuleSets(ruleSets map[string]*RuleSet) HandlerOption {
+	return func(h *Handler) {
+		h.ruleSets = ruleSets
+	}
+}
+
+// WithAllowlists restricts the hosts each operation accepts, an operation without an allowlist accepts any host.
+func WithAllowlists(allowlists map[string]*Allowlist) HandlerOption {
+	return func(h *Handler) {
+		h.allowlists = allowlists
+	}
 }
 
-func NewHandler(server *fiber.App, validator *validator.Validate, tracer trace.Tracer) *Handler {
-	return &Handler{
+func NewHandler(server *fiber.App, validator *validator.Validate, tracer trace.Tracer, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		server:    server,
 		validator: validator,
 		tracer:    tracer,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.batch = h.batch.withDefaults()
+	h.sitemap = h.sitemap.withDefaults()
+
+	return h
 }
 
 func (h *Handler) RegisterHandlers() {
 	h.server.Post("/url", h.GetUrl)
+	h.server.Post("/url/sitemap", h.RewriteSitemap)
+	h.server.Post("/urls/batch", h.GetUrlBatch)
 }
 
 func (h *Handler) GetUrl(ctx *fiber.Ctx) error {
@@ -34,7 +64,11 @@ func (h *Handler) GetUrl(ctx *fiber.Ctx) error {
 
 	var reqBody GetUrlRequest
 	if err := ctx.BodyParser(&reqBody); err != nil {
-		return err
+		return apperror.ErrInvalidBody.WithCause(err)
+	}
+
+	if reqBody.Url == nil {
+		return ErrUrlRequired
 	}
 
 	span.SetAttributes(
@@ -42,43 +76,34 @@ func (h *Handler) GetUrl(ctx *fiber.Ctx) error {
 		attribute.String("operation", string(reqBody.Operation)),
 	)
 
-	// validator library cannot handle net/url types so should check it in handler layer
-	if !strings.ContainsAny(reqBody.Url.Hostname(), "byfood.com") {
-		return fiber.ErrBadRequest
+	processed, err := h.process(ctx.Context(), reqBody)
+	if err != nil {
+		return err
 	}
 
-	if err := h.validator.StructCtx(ctx.Context(), reqBody); err != nil {
-		return fiber.ErrBadRequest
+	span.SetAttributes(attribute.String("processed_url", processed))
+	return ctx.JSON(fiber.Map{"processed_url": processed})
+}
+
+// process normalizes the url of request and rewrites it with the rule set of its operation once the host passed the allowlist.
+func (h *Handler) process(ctx context.Context, request GetUrlRequest) (string, error) {
+	if err := h.validator.StructCtx(ctx, request); err != nil {
+		return "", apperror.FromValidation(err)
 	}
 
-	var processed string
-
-	// TECH DEBT: Possible refactoring with Strategy Pattern for Open/Closed principle
-	switch reqBody.Operation {
-	case "canonical":
-		processed = canonicalURL(reqBody.Url).String()
-	case "redirection":
-		processed = strings.ToLower(redirectionUrl(reqBody.Url).String())
-	case "all":
-		processed = strings.ToLower(canonicalURL(redirectionUrl(reqBody.Url)).String())
-	default:
-		return fiber.ErrBadRequest
+	ruleSet, ok := h.ruleSets[string(request.Operation)]
+	if !ok {
+		return "", ErrUnsupportedOperation.WithMeta("operation", request.Operation)
 	}
 
-	span.SetAttributes(attribute.String("processed_url", processed))
-	return ctx.JSON(fiber.Map{"processed_url": processed})
-}
+	u := request.Normalize.Apply(request.Url)
 
-func canonicalURL(u *url.URL) *url.URL {
-	u.RawQuery = ""
-	u.Fragment = ""
-	if u.Path != "/" {
-		u.Path = strings.TrimSuffix(u.Path, "/")
+	// validator library cannot handle net/url types so should check it in handler layer
+	if allowlist, ok := h.allowlists[string(request.Operation)]; ok {
+		if err := allowlist.Check(u.Hostname()); err != nil {
+			return "", err
+		}
 	}
-	return u
-}
 
-func redirectionUrl(u *url.URL) *url.URL {
-	u.Host = "www.byfood.com"
-	return u
+	return ruleSet.Apply(u).String(), nil
 }
@@ -13,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
+
+	"book-api/pkg/apperror"
 )
 
 func Test_NewHandler(t *testing.T) {
@@ -45,6 +47,7 @@ func Test_GetUrl(t *testing.T) {
 	tests := []struct {
 		name      string
 		operation UrlOperation
+		normalize Normalization
 		inputURL  string
 		expected  string
 	}{
@@ -64,14 +67,21 @@ func Test_GetUrl(t *testing.T) {
 			name:      "all",
 			operation: UrlOperationAll,
 			inputURL:  "https://BYFOOD.com/food-EXPeriences?query=abc/",
-			expected:  "https://www.byfood.com/food-experiences",
+			expected:  "https://www.byfood.com/food-EXPeriences",
+		},
+		{
+			name:      "canonical after normalization",
+			operation: UrlOperationCanonical,
+			normalize: Normalization{LowercaseHost: true, RemoveDefaultPort: true, RemoveDotSegments: true, CollapseSlashes: true},
+			inputURL:  "https://BYFOOD.com:443/Tours//./Tokyo/?utm_source=x",
+			expected:  "https://byfood.com/Tours/Tokyo",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			server, validate := setupServer()
-			h := NewHandler(server, validate, otel.Tracer("url"))
+			h := NewHandler(server, validate, otel.Tracer("url"), WithRuleSets(byfoodRuleSets(t)))
 			h.RegisterHandlers()
 
 			u, err := url.Parse(tc.inputURL)
@@ -80,6 +90,7 @@ func Test_GetUrl(t *testing.T) {
 			payload, err := json.Marshal(GetUrlRequest{
 				Operation: tc.operation,
 				Url:       u,
+				Normalize: tc.normalize,
 			})
 			require.NoError(t, err)
 
@@ -100,11 +111,94 @@ func Test_GetUrl(t *testing.T) {
 	}
 }
 
+func Test_GetUrl_UnknownOperation(t *testing.T) {
+	server, validate := setupServer()
+	NewHandler(server, validate, otel.Tracer("url"), WithRuleSets(byfoodRuleSets(t))).RegisterHandlers()
+
+	u, err := url.Parse("https://byfood.com/food-experiences")
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(GetUrlRequest{Operation: "shorten", Url: u})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/url", strings.NewReader(string(payload)))
+	require.NoError(t, err)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	var problem apperror.Problem
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+	assert.Equal(t, "URL_UNSUPPORTED_OPERATION", problem.Code)
+}
+
+func Test_GetUrl_HostNotAllowed(t *testing.T) {
+	allowlist, err := NewAllowlist([]DomainRule{{Name: "byfood", Type: DomainRegistrable, Domain: "byfood.com"}})
+	require.NoError(t, err)
+
+	server, validate := setupServer()
+	NewHandler(
+		server,
+		validate,
+		otel.Tracer("url"),
+		WithRuleSets(byfoodRuleSets(t)),
+		WithAllowlists(map[string]*Allowlist{"canonical": allowlist}),
+	).RegisterHandlers()
+
+	// every host containing one of the letters of byfood.com used to pass
+	u, err := url.Parse("https://example.org/food-experiences")
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(GetUrlRequest{Operation: UrlOperationCanonical, Url: u})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/url", strings.NewReader(string(payload)))
+	require.NoError(t, err)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	var problem apperror.Problem
+	require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+	assert.Equal(t, "URL_HOST_NOT_ALLOWED", problem.Code)
+	assert.Equal(t, "example.org", problem.Meta["host"])
+	assert.Equal(t, []any{map[string]any{
+		"rule":   "byfood",
+		"type":   "registrable",
+		"domain": "byfood.com",
+		"reason": "registrable domain of host is example.org, not byfood.com",
+	}}, problem.Meta["rules"])
+}
+
+// byfoodRuleSets are the rule sets of the shipped config.
+func byfoodRuleSets(t *testing.T) map[string]*RuleSet {
+	ruleSets := make(map[string]*RuleSet)
+	for name, rules := range map[string][]Rule{
+		"canonical":   {{Name: "canonical", Query: QueryAllow, DropFragment: true, TrailingSlash: PolicyRemove}},
+		"redirection": {{Name: "byfood host", SetHost: "www.byfood.com", Lowercase: LowercaseAll}},
+		"all": {
+			{Name: "byfood host", SetHost: "www.byfood.com"},
+			{Name: "canonical", Query: QueryAllow, DropFragment: true, TrailingSlash: PolicyRemove, Lowercase: LowercaseHost},
+		},
+	} {
+		ruleSet, err := NewRuleSet(rules)
+		require.NoError(t, err)
+		ruleSets[name] = ruleSet
+	}
+
+	return ruleSets
+}
+
 func setupServer() (*fiber.App, *validator.Validate) {
 	server := fiber.New(fiber.Config{
 		JSONDecoder:           json.Unmarshal,
 		JSONEncoder:           json.Marshal,
 		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
 	})
 
 	return server, validator.New(validator.WithRequiredStructEnabled())
@@ -1,7 +1,12 @@
 package url
 
-import "net/url"
+import (
+	"net/url"
 
+	"book-api/pkg/apperror"
+)
+
+// UrlOperation names the rule set a url is rewritten with, the shipped config defines the three below.
 type UrlOperation string
 
 const (
@@ -11,6 +16,24 @@ const (
 )
 
 type GetUrlRequest struct {
-	Operation UrlOperation `json:"operation" validate:"oneof=canonical redirection all"`
+	Operation UrlOperation `json:"operation" validate:"required,max=100"`
 	Url       *url.URL     `json:"url"`
+	// Normalize is applied before the rule set of the operation
+	Normalize Normalization `json:"normalize"`
+}
+
+// BatchUrlItem is one url of POST /urls/batch. The url is kept as sent so an unparsable one fails on its own.
+type BatchUrlItem struct {
+	Url       string        `json:"url"`
+	Operation UrlOperation  `json:"operation"`
+	Normalize Normalization `json:"normalize"`
+}
+
+// BatchUrlResult is written for every item in input order, with either the processed url or the error of that item.
+type BatchUrlResult struct {
+	Index        int               `json:"index"`
+	Url          string            `json:"url"`
+	Operation    UrlOperation      `json:"operation,omitempty"`
+	ProcessedUrl string            `json:"processed_url,omitempty"`
+	Error        *apperror.Problem `json:"error,omitempty"`
 }
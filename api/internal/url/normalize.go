@@ -0,0 +1,203 @@
+package url
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TrackingParams are the query params StripTracking drops, as globs like Rule.QueryParams.
+var TrackingParams = []string{"utm_*", "gclid", "fbclid"}
+
+var duplicateSlashes = regexp.MustCompile(`//+`)
+
+// defaultPorts are the ports RemoveDefaultPort drops, by scheme.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// Normalization picks the RFC 3986 normalizations a url goes through before its rule set. Every step is off
+// unless asked for and they run in the order of the fields below.
+type Normalization struct {
+	LowercaseHost     bool `json:"lowercaseHost"`
+	RemoveDefaultPort bool `json:"removeDefaultPort"`
+	// PercentEncodingCase uppercases the hex digits of percent-encoded octets in the path, query and fragment
+	PercentEncodingCase bool `json:"percentEncodingCase"`
+	// DecodeUnreserved decodes percent-encoded letters, digits and -._~ in the path, query and fragment
+	DecodeUnreserved  bool `json:"decodeUnreserved"`
+	RemoveDotSegments bool `json:"removeDotSegments"`
+	CollapseSlashes   bool `json:"collapseSlashes"`
+	StripTracking     bool `json:"stripTracking"`
+	// SortQuery orders params by name, params of the same name keep their order
+	SortQuery bool `json:"sortQuery"`
+}
+
+// Apply returns the normalized url, u itself is left as it is.
+func (n Normalization) Apply(u *url.URL) *url.URL {
+	normalized := *u
+
+	if n.LowercaseHost {
+		normalized.Host = strings.ToLower(normalized.Host)
+	}
+
+	if n.RemoveDefaultPort {
+		if port := normalized.Port(); port == "" || port == defaultPorts[normalized.Scheme] {
+			normalized.Host = joinHostPort(normalized.Hostname(), "")
+		}
+	}
+
+	if normalized.Opaque == "" {
+		escapedPath := n.percentEncoding(normalized.EscapedPath())
+		if n.RemoveDotSegments {
+			escapedPath = removeDotSegments(escapedPath)
+		}
+		if n.CollapseSlashes {
+			escapedPath = duplicateSlashes.ReplaceAllString(escapedPath, "/")
+		}
+		if unescaped, err := url.PathUnescape(escapedPath); err == nil {
+			normalized.Path, normalized.RawPath = unescaped, escapedPath
+		}
+	}
+
+	normalized.RawQuery = n.percentEncoding(normalized.RawQuery)
+	if n.StripTracking || n.SortQuery {
+		normalized.RawQuery = n.rewriteQuery(normalized.RawQuery)
+	}
+
+	if normalized.Fragment != "" {
+		escapedFragment := n.percentEncoding(normalized.EscapedFragment())
+		if unescaped, err := url.PathUnescape(escapedFragment); err == nil {
+			normalized.Fragment, normalized.RawFragment = unescaped, escapedFragment
+		}
+	}
+
+	return &normalized
+}
+
+// percentEncoding applies PercentEncodingCase and DecodeUnreserved to an escaped component.
+func (n Normalization) percentEncoding(escaped string) string {
+	if !n.PercentEncodingCase && !n.DecodeUnreserved || !strings.Contains(escaped, "%") {
+		return escaped
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] != '%' || i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+			builder.WriteByte(escaped[i])
+			continue
+		}
+
+		octet := unhex(escaped[i+1])<<4 | unhex(escaped[i+2])
+		switch {
+		case n.DecodeUnreserved && isUnreserved(octet):
+			builder.WriteByte(octet)
+		case n.PercentEncodingCase:
+			builder.WriteString(strings.ToUpper(escaped[i : i+3]))
+		default:
+			builder.WriteString(escaped[i : i+3])
+		}
+		i += 2
+	}
+
+	return builder.String()
+}
+
+// rewriteQuery applies StripTracking and SortQuery to a raw query, params are kept as they were encoded.
+func (n Normalization) rewriteQuery(rawQuery string) string {
+	type param struct {
+		key  string
+		pair string
+	}
+
+	params := make([]param, 0)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if n.StripTracking && slices.ContainsFunc(TrackingParams, func(tracking string) bool {
+			matched, _ := path.Match(tracking, key)
+			return matched
+		}) {
+			continue
+		}
+		params = append(params, param{key: key, pair: pair})
+	}
+
+	if n.SortQuery {
+		slices.SortStableFunc(params, func(a, b param) int { return strings.Compare(a.key, b.key) })
+	}
+
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		pairs = append(pairs, p.pair)
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// removeDotSegments is remove_dot_segments of RFC 3986 section 5.2.4.
+func removeDotSegments(input string) string {
+	var output []string
+	for input != "" {
+		switch {
+		case strings.HasPrefix(input, "../"):
+			input = input[len("../"):]
+		case strings.HasPrefix(input, "./"):
+			input = input[len("./"):]
+		case strings.HasPrefix(input, "/./"):
+			input = input[len("/."):]
+		case input == "/.":
+			input = "/"
+		case strings.HasPrefix(input, "/../"):
+			input = input[len("/.."):]
+			output = output[:max(len(output)-1, 0)]
+		case input == "/..":
+			input = "/"
+			output = output[:max(len(output)-1, 0)]
+		case input == "." || input == "..":
+			input = ""
+		default:
+			end := strings.IndexByte(input[1:], '/') + 1
+			if end == 0 {
+				end = len(input)
+			}
+			output = append(output, input[:end])
+			input = input[end:]
+		}
+	}
+
+	return strings.Join(output, "")
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+
+	return c - 'A' + 10
+}
@@ -0,0 +1,129 @@
+package url
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalization_Apply(t *testing.T) {
+	cases := []struct {
+		name          string
+		normalization Normalization
+		input         string
+		expected      string
+	}{
+		{
+			name:     "nothing asked keeps the url",
+			input:    "HTTP://Byfood.COM:443/a/./B/../%7ec//d?utm_source=x&b=2&a=1#%7e",
+			expected: "http://Byfood.COM:443/a/./B/../%7ec//d?utm_source=x&b=2&a=1#%7e",
+		},
+		{
+			name:          "lowercase host keeps the path case",
+			normalization: Normalization{LowercaseHost: true},
+			input:         "https://BYFOOD.com/Food-EXPeriences",
+			expected:      "https://byfood.com/Food-EXPeriences",
+		},
+		{
+			name:          "default port",
+			normalization: Normalization{RemoveDefaultPort: true},
+			input:         "https://byfood.com:443/a",
+			expected:      "https://byfood.com/a",
+		},
+		{
+			name:          "other port is kept",
+			normalization: Normalization{RemoveDefaultPort: true},
+			input:         "http://byfood.com:443/a",
+			expected:      "http://byfood.com:443/a",
+		},
+		{
+			name:          "empty port",
+			normalization: Normalization{RemoveDefaultPort: true},
+			input:         "http://[::1]:/a",
+			expected:      "http://[::1]/a",
+		},
+		{
+			name:          "percent-encoding case",
+			normalization: Normalization{PercentEncodingCase: true},
+			input:         "https://byfood.com/caf%c3%a9?q=%e2%82%ac#%7e",
+			expected:      "https://byfood.com/caf%C3%A9?q=%E2%82%AC#%7E",
+		},
+		{
+			name:          "decode unreserved",
+			normalization: Normalization{DecodeUnreserved: true},
+			input:         "https://byfood.com/%7Euser/%41%2Fb?q=%2D%26#%5F",
+			expected:      "https://byfood.com/~user/A%2Fb?q=-%26#_",
+		},
+		{
+			name:          "dot segments",
+			normalization: Normalization{RemoveDotSegments: true},
+			input:         "https://byfood.com/a/b/c/./../../g",
+			expected:      "https://byfood.com/a/g",
+		},
+		{
+			name:          "dot segments above the root",
+			normalization: Normalization{RemoveDotSegments: true},
+			input:         "https://byfood.com/../../a/..",
+			expected:      "https://byfood.com/",
+		},
+		{
+			name:          "encoded dots are removed once decoded",
+			normalization: Normalization{DecodeUnreserved: true, RemoveDotSegments: true},
+			input:         "https://byfood.com/a/%2E%2E/b",
+			expected:      "https://byfood.com/b",
+		},
+		{
+			name:          "duplicate slashes",
+			normalization: Normalization{CollapseSlashes: true},
+			input:         "https://byfood.com//a///b/%2F/c",
+			expected:      "https://byfood.com/a/b/%2F/c",
+		},
+		{
+			name:          "tracking params",
+			normalization: Normalization{StripTracking: true},
+			input:         "https://byfood.com/a?utm_source=x&id=1&gclid=y&fbclid=z&utm_medium=w",
+			expected:      "https://byfood.com/a?id=1",
+		},
+		{
+			name:          "only tracking params",
+			normalization: Normalization{StripTracking: true},
+			input:         "https://byfood.com/a?utm_source=x",
+			expected:      "https://byfood.com/a",
+		},
+		{
+			name:          "sorted query keeps repeated params in order",
+			normalization: Normalization{SortQuery: true},
+			input:         "https://byfood.com/a?b=2&a=3&c&a=1",
+			expected:      "https://byfood.com/a?a=3&a=1&b=2&c",
+		},
+		{
+			name: "every step",
+			normalization: Normalization{
+				LowercaseHost:       true,
+				RemoveDefaultPort:   true,
+				PercentEncodingCase: true,
+				DecodeUnreserved:    true,
+				RemoveDotSegments:   true,
+				CollapseSlashes:     true,
+				StripTracking:       true,
+				SortQuery:           true,
+			},
+			input:    "HTTP://Byfood.COM:80/Tours//%7eTokyo/./Ramen/../Sushi%c3%a9?utm_source=x&z=1&a=2",
+			expected: "http://byfood.com/Tours/~Tokyo/Sushi%C3%A9?a=2&z=1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			input, err := url.Parse(c.input)
+			require.NoError(t, err)
+			before := input.String()
+
+			normalized := c.normalization.Apply(input)
+
+			assert.Equal(t, c.expected, normalized.String())
+			assert.Equal(t, before, input.String(), "input url must not change")
+		})
+	}
+}
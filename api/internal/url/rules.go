@@ -0,0 +1,268 @@
+package url
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type MatchType string
+
+const (
+	MatchExact MatchType = "exact"
+	MatchGlob  MatchType = "glob"
+	MatchRegex MatchType = "regex"
+)
+
+// Match selects urls by host or path. An empty pattern matches every url, an empty type is exact.
+// Hosts are compared lowercased and without the port, globs follow path.Match and regexes are not anchored.
+type Match struct {
+	Type    MatchType
+	Pattern string
+}
+
+type QueryPolicy string
+
+const (
+	QueryAllow QueryPolicy = "allow"
+	QueryDeny  QueryPolicy = "deny"
+)
+
+type LowercaseScope string
+
+const (
+	LowercaseHost LowercaseScope = "host"
+	LowercasePath LowercaseScope = "path"
+	LowercaseAll  LowercaseScope = "all"
+)
+
+type Policy string
+
+const (
+	PolicyAdd    Policy = "add"
+	PolicyRemove Policy = "remove"
+)
+
+// Rule rewrites the urls its Host and Path both match. Its actions run in the order of the fields below,
+// zero values leave that part of the url alone.
+type Rule struct {
+	Name string
+	Host Match
+	Path Match
+
+	ForceHTTPS bool
+	SetHost    string
+	WWW        Policy
+	// Query keeps only the QueryParams when allow and drops them when deny, params are globs like utm_*
+	Query         QueryPolicy
+	QueryParams   []string
+	DropFragment  bool
+	Lowercase     LowercaseScope
+	TrailingSlash Policy
+}
+
+var (
+	ErrInvalidMatch  = errors.New("match type must be exact, glob or regex")
+	ErrInvalidAction = errors.New("rule action has an unknown value")
+)
+
+// RuleSet is an ordered list of rules, every rule sees the url as the rules before it left it.
+type RuleSet struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	host matcher
+	path matcher
+}
+
+type matcher func(value string) bool
+
+func NewRuleSet(rules []Rule) (*RuleSet, error) {
+	set := &RuleSet{rules: make([]compiledRule, 0, len(rules))}
+	for i, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d %q: %w", i, rule.Name, err)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+
+	return set, nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule}
+
+	host := rule.Host
+	if host.Type != MatchRegex {
+		host.Pattern = strings.ToLower(host.Pattern)
+	}
+
+	var err error
+	if compiled.host, err = compileMatch(host); err != nil {
+		return compiled, fmt.Errorf("host: %w", err)
+	}
+	if compiled.path, err = compileMatch(rule.Path); err != nil {
+		return compiled, fmt.Errorf("path: %w", err)
+	}
+
+	for _, param := range rule.QueryParams {
+		if _, err = path.Match(param, ""); err != nil {
+			return compiled, fmt.Errorf("query param %q: %w", param, err)
+		}
+	}
+
+	switch {
+	case !slices.Contains([]Policy{"", PolicyAdd, PolicyRemove}, rule.WWW):
+		return compiled, fmt.Errorf("%w: www %q", ErrInvalidAction, rule.WWW)
+	case !slices.Contains([]Policy{"", PolicyAdd, PolicyRemove}, rule.TrailingSlash):
+		return compiled, fmt.Errorf("%w: trailingSlash %q", ErrInvalidAction, rule.TrailingSlash)
+	case !slices.Contains([]QueryPolicy{"", QueryAllow, QueryDeny}, rule.Query):
+		return compiled, fmt.Errorf("%w: query %q", ErrInvalidAction, rule.Query)
+	case !slices.Contains([]LowercaseScope{"", LowercaseHost, LowercasePath, LowercaseAll}, rule.Lowercase):
+		return compiled, fmt.Errorf("%w: lowercase %q", ErrInvalidAction, rule.Lowercase)
+	}
+
+	return compiled, nil
+}
+
+func compileMatch(match Match) (matcher, error) {
+	if match.Pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	switch match.Type {
+	case MatchExact, "":
+		return func(value string) bool { return value == match.Pattern }, nil
+	case MatchGlob:
+		if _, err := path.Match(match.Pattern, ""); err != nil {
+			return nil, err
+		}
+		return func(value string) bool {
+			matched, _ := path.Match(match.Pattern, value)
+			return matched
+		}, nil
+	case MatchRegex:
+		expression, err := regexp.Compile(match.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return expression.MatchString, nil
+	}
+
+	return nil, fmt.Errorf("%w, got %q", ErrInvalidMatch, match.Type)
+}
+
+// Apply returns the url rewritten by every matching rule, u itself is left as it is.
+func (s *RuleSet) Apply(u *url.URL) *url.URL {
+	rewritten := *u
+	for _, rule := range s.rules {
+		if rule.host(strings.ToLower(rewritten.Hostname())) && rule.path(rewritten.Path) {
+			rule.apply(&rewritten)
+		}
+	}
+
+	return &rewritten
+}
+
+func (r compiledRule) apply(u *url.URL) {
+	if r.ForceHTTPS && u.Scheme == "http" {
+		u.Scheme = "https"
+		if u.Port() == "80" {
+			u.Host = u.Hostname()
+		}
+	}
+
+	if r.SetHost != "" {
+		u.Host = r.SetHost
+	}
+
+	if r.WWW != "" {
+		host, port := u.Hostname(), u.Port()
+		hasWWW := strings.HasPrefix(strings.ToLower(host), "www.")
+		if r.WWW == PolicyAdd && !hasWWW {
+			host = "www." + host
+		} else if r.WWW == PolicyRemove && hasWWW {
+			host = host[len("www."):]
+		}
+		u.Host = joinHostPort(host, port)
+	}
+
+	if r.Query != "" {
+		u.RawQuery = r.filterQuery(u.RawQuery)
+		u.ForceQuery = false
+	}
+
+	if r.DropFragment {
+		u.Fragment, u.RawFragment = "", ""
+	}
+
+	switch r.Lowercase {
+	case LowercaseHost:
+		u.Host = strings.ToLower(u.Host)
+	case LowercasePath:
+		u.Path, u.RawPath = strings.ToLower(u.Path), strings.ToLower(u.RawPath)
+	case LowercaseAll:
+		u.Host = strings.ToLower(u.Host)
+		u.Path, u.RawPath = strings.ToLower(u.Path), strings.ToLower(u.RawPath)
+		u.RawQuery = strings.ToLower(u.RawQuery)
+		u.Fragment, u.RawFragment = strings.ToLower(u.Fragment), strings.ToLower(u.RawFragment)
+	}
+
+	switch r.TrailingSlash {
+	case PolicyAdd:
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+			if u.RawPath != "" {
+				u.RawPath += "/"
+			}
+		}
+	case PolicyRemove:
+		if u.Path != "/" {
+			u.Path, u.RawPath = strings.TrimSuffix(u.Path, "/"), strings.TrimSuffix(u.RawPath, "/")
+		}
+	}
+}
+
+// filterQuery keeps the params of the raw query in their order and as they were encoded.
+func (r compiledRule) filterQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	kept := make([]string, 0)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		listed := slices.ContainsFunc(r.QueryParams, func(param string) bool {
+			matched, _ := path.Match(param, key)
+			return matched
+		})
+		if listed == (r.Query == QueryAllow) {
+			kept = append(kept, pair)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func joinHostPort(host, port string) string {
+	if port == "" {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,129 @@
+package url
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleSet_Apply(t *testing.T) {
+	cases := []struct {
+		name     string
+		rules    []Rule
+		input    string
+		expected string
+	}{
+		{
+			name:     "exact host match",
+			rules:    []Rule{{Host: Match{Pattern: "Byfood.com"}, SetHost: "www.byfood.com"}, {Host: Match{Pattern: "other.com"}, SetHost: "never.com"}},
+			input:    "https://BYFOOD.com/a",
+			expected: "https://www.byfood.com/a",
+		},
+		{
+			name:     "glob path match",
+			rules:    []Rule{{Path: Match{Type: MatchGlob, Pattern: "/blog/*"}, TrailingSlash: PolicyAdd}},
+			input:    "https://byfood.com/blog/ramen",
+			expected: "https://byfood.com/blog/ramen/",
+		},
+		{
+			name:     "glob does not cross path segments",
+			rules:    []Rule{{Path: Match{Type: MatchGlob, Pattern: "/blog/*"}, TrailingSlash: PolicyAdd}},
+			input:    "https://byfood.com/blog/ramen/tokyo",
+			expected: "https://byfood.com/blog/ramen/tokyo",
+		},
+		{
+			name:     "regex host match",
+			rules:    []Rule{{Host: Match{Type: MatchRegex, Pattern: `^(m|amp)\.byfood\.com$`}, SetHost: "byfood.com"}},
+			input:    "http://amp.byfood.com/a",
+			expected: "http://byfood.com/a",
+		},
+		{
+			name:     "force https drops the default port",
+			rules:    []Rule{{ForceHTTPS: true}},
+			input:    "http://byfood.com:80/a",
+			expected: "https://byfood.com/a",
+		},
+		{
+			name:     "add www keeps the port",
+			rules:    []Rule{{WWW: PolicyAdd}},
+			input:    "https://byfood.com:8443/a",
+			expected: "https://www.byfood.com:8443/a",
+		},
+		{
+			name:     "remove www",
+			rules:    []Rule{{WWW: PolicyRemove}},
+			input:    "https://www.byfood.com/a",
+			expected: "https://byfood.com/a",
+		},
+		{
+			name:     "query allowlist",
+			rules:    []Rule{{Query: QueryAllow, QueryParams: []string{"page", "lang"}}},
+			input:    "https://byfood.com/a?utm_source=x&page=2&ref=y&lang=ja",
+			expected: "https://byfood.com/a?page=2&lang=ja",
+		},
+		{
+			name:     "query denylist with glob",
+			rules:    []Rule{{Query: QueryDeny, QueryParams: []string{"utm_*", "fbclid"}}},
+			input:    "https://byfood.com/a?utm_source=x&page=2&utm_medium=y&fbclid=z",
+			expected: "https://byfood.com/a?page=2",
+		},
+		{
+			name:     "lowercase path only",
+			rules:    []Rule{{Lowercase: LowercasePath}},
+			input:    "https://ByFood.com/Food-Experiences?Q=A",
+			expected: "https://ByFood.com/food-experiences?Q=A",
+		},
+		{
+			name:     "trailing slash removal keeps the root",
+			rules:    []Rule{{TrailingSlash: PolicyRemove}},
+			input:    "https://byfood.com/",
+			expected: "https://byfood.com/",
+		},
+		{
+			name:     "later rules see earlier rewrites",
+			rules:    []Rule{{SetHost: "www.byfood.com"}, {Host: Match{Pattern: "www.byfood.com"}, DropFragment: true}},
+			input:    "https://byfood.jp/a#top",
+			expected: "https://www.byfood.com/a",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ruleSet, err := NewRuleSet(c.rules)
+			require.NoError(t, err)
+
+			input, err := url.Parse(c.input)
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expected, ruleSet.Apply(input).String())
+			assert.Equal(t, c.input, input.String())
+		})
+	}
+}
+
+func TestNewRuleSet(t *testing.T) {
+	cases := []struct {
+		name string
+		rule Rule
+		err  error
+	}{
+		{name: "unknown match type", rule: Rule{Host: Match{Type: "prefix", Pattern: "byfood"}}, err: ErrInvalidMatch},
+		{name: "unknown www policy", rule: Rule{WWW: "keep"}, err: ErrInvalidAction},
+		{name: "unknown trailing slash policy", rule: Rule{TrailingSlash: "keep"}, err: ErrInvalidAction},
+		{name: "unknown query policy", rule: Rule{Query: "strip"}, err: ErrInvalidAction},
+		{name: "unknown lowercase scope", rule: Rule{Lowercase: "query"}, err: ErrInvalidAction},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewRuleSet([]Rule{c.rule})
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+
+	_, err := NewRuleSet([]Rule{{Path: Match{Type: MatchRegex, Pattern: "("}}})
+	assert.Error(t, err)
+
+	_, err = NewRuleSet([]Rule{{Path: Match{Type: MatchGlob, Pattern: "["}}})
+	assert.Error(t, err)
+}
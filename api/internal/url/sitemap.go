@@ -0,0 +1,239 @@
+package url
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"book-api/pkg/apperror"
+	"book-api/pkg/bodylimit"
+)
+
+const (
+	// DefaultMaxSitemapEntries and DefaultMaxSitemapBytes are the limits of the sitemap protocol
+	DefaultMaxSitemapEntries = 50000
+	DefaultMaxSitemapBytes   = 50 << 20
+
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+// SitemapOptions bounds POST /url/sitemap, zero values fall back to the defaults above.
+type SitemapOptions struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// WithSitemapOptions overrides the limits of POST /url/sitemap.
+func WithSitemapOptions(options SitemapOptions) HandlerOption {
+	return func(h *Handler) {
+		h.sitemap = options
+	}
+}
+
+func (o SitemapOptions) withDefaults() SitemapOptions {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = DefaultMaxSitemapEntries
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = DefaultMaxSitemapBytes
+	}
+
+	return o
+}
+
+// sitemapEntry is a <url> of a urlset or a <sitemap> of a sitemap index, other child elements are not kept.
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	Lastmod    string `xml:"lastmod,omitempty"`
+	Changefreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// sitemapEntryElements names the entry element of each sitemap root.
+var sitemapEntryElements = map[string]string{
+	"urlset":       "url",
+	"sitemapindex": "sitemap",
+}
+
+// RewriteSitemap applies the operation of the query to every <loc> of an uploaded sitemap or sitemap index
+// and returns the same kind of document. The upload is parsed as it arrives, the first of entries with the
+// same rewritten loc is kept and a loc that fails to rewrite fails the request.
+func (h *Handler) RewriteSitemap(ctx *fiber.Ctx) error {
+	_, span := h.tracer.Start(ctx.Context(), "RewriteSitemap")
+	defer span.End()
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if mediaType != fiber.MIMEApplicationXML && mediaType != fiber.MIMETextXML {
+		return ErrUnsupportedSitemapType.WithMeta("contentType", mediaType)
+	}
+
+	operation := UrlOperation(ctx.Query("operation"))
+	if _, ok := h.ruleSets[string(operation)]; !ok {
+		return ErrUnsupportedOperation.WithMeta("operation", operation)
+	}
+	span.SetAttributes(attribute.String("operation", string(operation)))
+
+	body := bodylimit.Body(ctx, h.sitemap.MaxBytes)
+
+	root, entries, err := h.rewriteSitemap(ctx.Context(), body, operation)
+	if errors.Is(err, bodylimit.ErrTooLarge) {
+		ctx.Context().SetConnectionClose()
+		return ErrSitemapTooLarge.WithMeta("maxBytes", h.sitemap.MaxBytes)
+	}
+	if err != nil {
+		return err
+	}
+
+	span.SetAttributes(attribute.String("root", root), attribute.Int("entries", len(entries)))
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return writeSitemap(ctx.Response().BodyWriter(), root, entries)
+}
+
+// rewriteSitemap reads the document entry by entry and returns its root element with the rewritten entries.
+func (h *Handler) rewriteSitemap(ctx context.Context, body io.Reader, operation UrlOperation) (string, []sitemapEntry, error) {
+	decoder := xml.NewDecoder(body)
+
+	root, err := sitemapRoot(decoder)
+	if err != nil {
+		return "", nil, err
+	}
+	entryElement := sitemapEntryElements[root]
+
+	var (
+		entries []sitemapEntry
+		seen    = make(map[string]struct{})
+		read    int
+	)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", nil, sitemapReadError(err)
+		}
+
+		if _, ok := token.(xml.EndElement); ok {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != entryElement {
+			if err = decoder.Skip(); err != nil {
+				return "", nil, sitemapReadError(err)
+			}
+			continue
+		}
+
+		read++
+		if read > h.sitemap.MaxEntries {
+			return "", nil, ErrTooManySitemapEntries.WithMeta("maxEntries", h.sitemap.MaxEntries)
+		}
+
+		var entry sitemapEntry
+		if err = decoder.DecodeElement(&entry, &start); err != nil {
+			return "", nil, sitemapReadError(err)
+		}
+
+		loc := strings.TrimSpace(entry.Loc)
+		if entry.Loc, err = h.rewriteLoc(ctx, loc, operation); err != nil {
+			return "", nil, sitemapEntryError(err, read, loc)
+		}
+		if _, duplicate := seen[entry.Loc]; duplicate {
+			continue
+		}
+		seen[entry.Loc] = struct{}{}
+
+		entry.Lastmod = strings.TrimSpace(entry.Lastmod)
+		entry.Changefreq = strings.TrimSpace(entry.Changefreq)
+		entry.Priority = strings.TrimSpace(entry.Priority)
+		entries = append(entries, entry)
+	}
+
+	return root, entries, nil
+}
+
+func (h *Handler) rewriteLoc(ctx context.Context, loc string, operation UrlOperation) (string, error) {
+	if loc == "" {
+		return "", ErrUrlRequired
+	}
+
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", ErrInvalidUrl.WithCause(err)
+	}
+
+	return h.process(ctx, GetUrlRequest{Operation: operation, Url: u})
+}
+
+// sitemapRoot reads up to the root element, which must be a urlset or a sitemapindex.
+func sitemapRoot(decoder *xml.Decoder) (string, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", sitemapReadError(err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			if _, known := sitemapEntryElements[start.Name.Local]; !known {
+				return "", ErrInvalidSitemap.WithMeta("root", start.Name.Local)
+			}
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func writeSitemap(w io.Writer, root string, entries []sitemapEntry) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	start := xml.StartElement{Name: xml.Name{Local: root}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: sitemapNamespace}}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	element := xml.StartElement{Name: xml.Name{Local: sitemapEntryElements[root]}}
+	for _, entry := range entries {
+		if err := encoder.EncodeElement(entry, element); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.EncodeToken(start.End()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func sitemapReadError(err error) error {
+	switch {
+	case errors.Is(err, bodylimit.ErrTooLarge):
+		return err
+	case errors.Is(err, io.EOF):
+		return ErrInvalidSitemap.WithCause(io.ErrUnexpectedEOF)
+	}
+
+	return ErrInvalidSitemap.WithCause(err)
+}
+
+// sitemapEntryError keeps the code of the error the loc failed with and adds the loc and its 1-based entry.
+func sitemapEntryError(err error, entry int, loc string) error {
+	var appError *apperror.Error
+	if !errors.As(err, &appError) {
+		return err
+	}
+
+	return appError.WithMeta("entry", entry).WithMeta("loc", loc)
+}
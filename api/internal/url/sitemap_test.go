@@ -0,0 +1,234 @@
+package url
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"book-api/pkg/apperror"
+)
+
+func Test_RewriteSitemap(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation UrlOperation
+		body      string
+		expected  string
+	}{
+		{
+			name:      "urlset",
+			operation: UrlOperationCanonical,
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>
+      https://byfood.com/tours/?utm_source=x
+    </loc>
+    <lastmod>2026-01-02</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.8</priority>
+    <image:image><image:loc>https://byfood.com/a.jpg</image:loc></image:image>
+  </url>
+  <url>
+    <loc>https://byfood.com/tours#top</loc>
+    <lastmod>2026-03-04</lastmod>
+  </url>
+  <url>
+    <loc>https://byfood.com/Food-Experiences?a=1&amp;b=2</loc>
+    <priority>1.0</priority>
+  </url>
+</urlset>`,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://byfood.com/tours</loc>
+    <lastmod>2026-01-02</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>https://byfood.com/Food-Experiences</loc>
+    <priority>1.0</priority>
+  </url>
+</urlset>`,
+		},
+		{
+			name:      "sitemap index",
+			operation: UrlOperationAll,
+			body: `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://byfood.com/sitemap-Tours.xml</loc><lastmod>2026-01-02T10:00:00+09:00</lastmod></sitemap>
+  <sitemap><loc>https://BYFOOD.com/sitemap-Blog.xml?page=2</loc></sitemap>
+</sitemapindex>`,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://www.byfood.com/sitemap-Tours.xml</loc>
+    <lastmod>2026-01-02T10:00:00+09:00</lastmod>
+  </sitemap>
+  <sitemap>
+    <loc>https://www.byfood.com/sitemap-Blog.xml</loc>
+  </sitemap>
+</sitemapindex>`,
+		},
+		{
+			name:      "empty urlset",
+			operation: UrlOperationCanonical,
+			body:      `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupSitemapServer(t, SitemapOptions{})
+
+			req, err := http.NewRequest(http.MethodPost, "/url/sitemap?operation="+string(tc.operation), strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, fiber.MIMEApplicationXMLCharsetUTF8, res.Header.Get(fiber.HeaderContentType))
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(body))
+		})
+	}
+}
+
+func Test_RewriteSitemap_Rejected(t *testing.T) {
+	urlset := func(locs ...string) string {
+		var body strings.Builder
+		body.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		for _, loc := range locs {
+			fmt.Fprintf(&body, "<url><loc>%s</loc></url>", loc)
+		}
+		body.WriteString(`</urlset>`)
+		return body.String()
+	}
+
+	tests := []struct {
+		name           string
+		options        SitemapOptions
+		operation      string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedMeta   map[string]any
+	}{
+		{
+			name:           "host not allowed",
+			body:           urlset("https://byfood.com/a", "https://example.org/b"),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_HOST_NOT_ALLOWED",
+			expectedMeta:   map[string]any{"entry": float64(2), "loc": "https://example.org/b"},
+		},
+		{
+			name:           "missing loc",
+			body:           `<urlset><url><lastmod>2026-01-02</lastmod></url></urlset>`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_REQUIRED",
+			expectedMeta:   map[string]any{"entry": float64(1), "loc": ""},
+		},
+		{
+			name:           "unknown root",
+			body:           `<rss><channel></channel></rss>`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "SITEMAP_INVALID",
+			expectedMeta:   map[string]any{"root": "rss"},
+		},
+		{
+			name:           "malformed xml",
+			body:           `<urlset><url><loc>https://byfood.com/a</loc></url>`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "SITEMAP_INVALID",
+		},
+		{
+			name:           "unsupported operation",
+			operation:      "shorten",
+			body:           urlset("https://byfood.com/a"),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "URL_UNSUPPORTED_OPERATION",
+		},
+		{
+			name:           "too many entries",
+			options:        SitemapOptions{MaxEntries: 1},
+			body:           urlset("https://byfood.com/a", "https://byfood.com/b"),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "SITEMAP_TOO_MANY_ENTRIES",
+		},
+		{
+			name:           "too large",
+			options:        SitemapOptions{MaxBytes: 64},
+			body:           urlset("https://byfood.com/a", "https://byfood.com/b"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "SITEMAP_TOO_LARGE",
+		},
+		{
+			name:           "unsupported type",
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `{}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   "SITEMAP_UNSUPPORTED_TYPE",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupSitemapServer(t, tc.options)
+
+			operation := tc.operation
+			if operation == "" {
+				operation = string(UrlOperationCanonical)
+			}
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = fiber.MIMETextXML
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/url/sitemap?operation="+operation, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.Header.Set(fiber.HeaderContentType, contentType)
+
+			res, err := server.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			var problem apperror.Problem
+			require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+			assert.Equal(t, tc.expectedCode, problem.Code)
+			for key, value := range tc.expectedMeta {
+				assert.Equal(t, value, problem.Meta[key], key)
+			}
+		})
+	}
+}
+
+func setupSitemapServer(t *testing.T, options SitemapOptions) *fiber.App {
+	allowlist, err := NewAllowlist([]DomainRule{{Name: "byfood", Type: DomainRegistrable, Domain: "byfood.com"}})
+	require.NoError(t, err)
+
+	server, validate := setupServer()
+	NewHandler(
+		server,
+		validate,
+		otel.Tracer("url"),
+		WithRuleSets(byfoodRuleSets(t)),
+		WithAllowlists(map[string]*Allowlist{"canonical": allowlist, "all": allowlist}),
+		WithSitemapOptions(options),
+	).RegisterHandlers()
+
+	return server
+}
@@ -0,0 +1,12 @@
+package work
+
+import "book-api/pkg/apperror"
+
+var (
+	ErrWorkNotFound          = apperror.NotFound("WORK_NOT_FOUND", "work not found")
+	ErrWorkHasEditions       = apperror.Conflict("WORK_HAS_EDITIONS", "work still has editions")
+	ErrInvalidWorkTitle      = apperror.Invalid("INVALID_WORK_TITLE", "work title must not be blank")
+	ErrUnknownWork           = apperror.Invalid("UNKNOWN_WORK", "work does not exist")
+	ErrRepositoryUnavailable = apperror.Unavailable("WORK_REPOSITORY_UNAVAILABLE", "work repository is unavailable")
+	ErrRepositoryFailure     = apperror.Internal("WORK_REPOSITORY_FAILURE", "work repository failed")
+)
@@ -0,0 +1,20 @@
+package work
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/internal/catalog"
+)
+
+// NewHandler serves /work and the list at /works. The list leaves out the editions, GET /work/:id has them.
+func NewHandler(
+	server *fiber.App,
+	validator *validator.Validate,
+	tracer trace.Tracer,
+	repository Repository,
+	opts ...catalog.HandlerOption,
+) *catalog.Handler[WorkDTO] {
+	return catalog.NewHandler(server, validator, tracer, kind, repository, opts...)
+}
@@ -0,0 +1,85 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+
+	"book-api/internal/catalog"
+	"book-api/pkg/apperror"
+)
+
+func TestNewHandler(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	t.Run("a single work has its editions", func(t *testing.T) {
+		workId := uuid.NewString()
+		mockRepository := catalog.NewMockRepository[WorkDTO](mockController)
+		mockRepository.EXPECT().GetById(gomock.Any(), workId).Return(&WorkDTO{
+			Id:       workId,
+			Title:    "The Hobbit",
+			Editions: []EditionDTO{{Id: uuid.NewString(), Title: "The Hobbit", PublicationYear: "1937"}},
+		}, nil)
+
+		server := setupHandler(mockRepository)
+
+		res := send(t, server, http.MethodGet, "/work/"+workId, "")
+		require.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		var body struct {
+			Work WorkDTO `json:"work"`
+		}
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&body))
+		assert.Len(t, body.Work.Editions, 1)
+	})
+
+	t.Run("missing title", func(t *testing.T) {
+		server := setupHandler(nil)
+
+		res := send(t, server, http.MethodPost, "/work", `{}`)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		var problem apperror.Problem
+		require.NoError(t, json.ConfigDefault.NewDecoder(res.Body).Decode(&problem))
+		assert.Equal(t, []apperror.FieldError{{Field: "title", Rule: "required"}}, problem.Errors)
+	})
+}
+
+func send(t *testing.T, server *fiber.App, method, target, body string) *http.Response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+
+	res, err := server.Test(req, -1)
+	require.NoError(t, err)
+
+	return res
+}
+
+func setupHandler(repository Repository) *fiber.App {
+	server := fiber.New(fiber.Config{
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: true,
+		ErrorHandler:          apperror.ErrorHandler,
+	})
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(apperror.FieldName)
+
+	NewHandler(server, validate, otel.Tracer("work"), repository).RegisterHandlers()
+
+	return server
+}
@@ -0,0 +1,37 @@
+package work
+
+import (
+	"time"
+
+	"book-api/internal/catalog"
+)
+
+type WorkDTO struct {
+	Id        string     `json:"id" db:"id" validate:"omitempty,uuid"`
+	Title     string     `json:"title" db:"title" validate:"required,max=255"`
+	CreatedAt *time.Time `json:"createdAt,omitempty" db:"created_at"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"`
+
+	// Editions is only loaded for a single work
+	Editions []EditionDTO `json:"editions,omitempty" db:"-"`
+}
+
+func (w WorkDTO) Position() catalog.Cursor {
+	return catalog.Cursor{Label: w.Title, Id: w.Id}
+}
+
+func (w WorkDTO) WithId(id string) WorkDTO {
+	w.Id = id
+	return w
+}
+
+// EditionDTO is a live book of a work, as far as it tells the editions apart.
+type EditionDTO struct {
+	Id              string  `json:"id" db:"id"`
+	ISBN            string  `json:"isbn" db:"isbn"`
+	ISBN13          *string `json:"isbn13,omitempty" db:"isbn13"`
+	Title           string  `json:"title" db:"title"`
+	PublicationYear string  `json:"publicationYear" db:"publication_year"`
+	PublisherId     *string `json:"publisherId,omitempty" db:"publisher_id"`
+	PublisherName   *string `json:"publisherName,omitempty" db:"publisher_name"`
+}
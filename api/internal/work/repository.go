@@ -0,0 +1,73 @@
+package work
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"book-api/internal/catalog"
+)
+
+const editionColumns = "books.id, books.isbn, books.isbn13, books.title, books.publication_year, books.publisher_id, publishers.name as publisher_name"
+
+// kind knows works by title, which may repeat, and a work's books are its editions, so a work that still
+// has any can't be deleted.
+var kind = catalog.Kind{
+	Name:   "work",
+	Plural: "works",
+	Table:  "works",
+	Label:  "title",
+	Errors: catalog.Errors{
+		NotFound:     ErrWorkNotFound,
+		InUse:        ErrWorkHasEditions,
+		InvalidLabel: ErrInvalidWorkTitle,
+		Unavailable:  ErrRepositoryUnavailable,
+		Failure:      ErrRepositoryFailure,
+	},
+}
+
+type Repository = catalog.Repository[WorkDTO]
+
+// PgRepository is the shared catalogue repository with the editions added to a single work.
+type PgRepository struct {
+	*catalog.PgRepository[WorkDTO]
+	traceProvider *sdktrace.TracerProvider
+}
+
+func NewPgRepository(traceProvider *sdktrace.TracerProvider, connectionPool *pgxpool.Pool) *PgRepository {
+	return &PgRepository{
+		PgRepository:  catalog.NewPgRepository[WorkDTO](kind, traceProvider, connectionPool),
+		traceProvider: traceProvider,
+	}
+}
+
+// GetById loads the work with its live editions, oldest publication first.
+func (r *PgRepository) GetById(ctx context.Context, id string) (*WorkDTO, error) {
+	ctx, span := r.traceProvider.Tracer("workRepository").
+		Start(ctx, "GetWorkById", trace.WithAttributes(attribute.String("workId", id)))
+	defer span.End()
+
+	work, err := r.PgRepository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.ConnectionPool().Query(
+		ctx,
+		"select "+editionColumns+" from books left join publishers on publishers.id = books.publisher_id where books.work_id = $1 and books.deleted_at is null order by books.publication_year, books.created_at, books.id",
+		id,
+	)
+	if err != nil {
+		return nil, r.MapPgError(err)
+	}
+
+	if work.Editions, err = pgx.CollectRows(rows, pgx.RowToStructByName[EditionDTO]); err != nil {
+		return nil, r.MapPgError(err)
+	}
+
+	return work, nil
+}
@@ -0,0 +1,106 @@
+//go:build !pact
+// +build !pact
+
+package work
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"book-api/internal/catalog"
+	"book-api/migrations"
+	"book-api/pkg/migrate"
+)
+
+func TestPgRepository_Work(t *testing.T) {
+	t.Run("editions", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+		ctx := context.TODO()
+
+		work := &WorkDTO{Id: uuid.NewString(), Title: "The Hobbit"}
+		require.NoError(t, pgRepository.Create(ctx, work))
+
+		var publisherId string
+		require.NoError(t, pgRepository.ConnectionPool().QueryRow(ctx, "insert into publishers (name) values ('Allen & Unwin') returning id").Scan(&publisherId))
+
+		insert := "insert into books (id, cover_url, isbn, title, author, publication_year, work_id, publisher_id) values ($1, '', $2, $3, 'J.R.R. Tolkien', $4, $5, $6)"
+		reissue, original := uuid.NewString(), uuid.NewString()
+		_, err := pgRepository.ConnectionPool().Exec(ctx, insert, reissue, "978-0-547-92822-7", "The Hobbit, or There and Back Again", "2012", work.Id, nil)
+		require.NoError(t, err)
+		_, err = pgRepository.ConnectionPool().Exec(ctx, insert, original, "0-04-823070-1", "The Hobbit", "1937", work.Id, publisherId)
+		require.NoError(t, err)
+
+		found, err := pgRepository.GetById(ctx, work.Id)
+		require.NoError(t, err)
+		require.Len(t, found.Editions, 2)
+		assert.Equal(t, original, found.Editions[0].Id)
+		require.NotNil(t, found.Editions[0].PublisherName)
+		assert.Equal(t, "Allen & Unwin", *found.Editions[0].PublisherName)
+		assert.Equal(t, reissue, found.Editions[1].Id)
+		assert.Nil(t, found.Editions[1].PublisherId)
+
+		// the list only has the works themselves
+		page, err := pgRepository.List(ctx, catalog.PageRequest{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Records, 1)
+		assert.Empty(t, page.Records[0].Editions)
+
+		assert.ErrorIs(t, pgRepository.DeleteById(ctx, work.Id), ErrWorkHasEditions)
+
+		// a book inserted without a work gets one of its own
+		var workId, title string
+		require.NoError(t, pgRepository.ConnectionPool().QueryRow(
+			ctx,
+			"insert into books (id, cover_url, isbn, title, author, publication_year) values ($1, '', '', 'Mort', 'Terry Pratchett', '1987') returning work_id",
+			uuid.NewString(),
+		).Scan(&workId))
+		require.NoError(t, pgRepository.ConnectionPool().QueryRow(ctx, "select title from works where id = $1", workId).Scan(&title))
+		assert.Equal(t, "Mort", title)
+	})
+
+	t.Run("blank title", func(t *testing.T) {
+		pgRepository := setupRepository(t)
+
+		err := pgRepository.Create(context.TODO(), &WorkDTO{Id: uuid.NewString(), Title: "  "})
+		assert.ErrorIs(t, err, ErrInvalidWorkTitle)
+	})
+}
+
+func setupRepository(t *testing.T) *PgRepository {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres:alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+		postgres.WithSQLDriver("pgx"),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connectionString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
+
+	return NewPgRepository(trace.NewTracerProvider(), pool)
+}
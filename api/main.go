@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -28,10 +29,23 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.uber.org/zap"
 
+	"book-api/internal/author"
 	"book-api/internal/book"
+	"book-api/internal/catalog"
+	"book-api/internal/genre"
+	"book-api/internal/publisher"
+	"book-api/internal/series"
 	"book-api/internal/url"
+	"book-api/internal/work"
+	"book-api/pkg/apperror"
+	"book-api/pkg/auth"
+	"book-api/pkg/bodylimit"
 	"book-api/pkg/config"
+	"book-api/pkg/cursor"
+	"book-api/pkg/enrichment"
+	"book-api/pkg/isbn"
 	_ "book-api/pkg/log"
+	"book-api/pkg/storage"
 )
 
 type GlobalHandler interface {
@@ -44,8 +58,13 @@ var httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 	Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 }, []string{"route", "method", "status"})
 
+var coverURLsFailing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "book_cover_urls_failing",
+	Help: "Live books whose cover url failed its last check, by outcome",
+}, []string{"outcome"})
+
 func init() {
-	prometheus.MustRegister(httpRequestDuration)
+	prometheus.MustRegister(httpRequestDuration, coverURLsFailing)
 }
 
 func main() {
@@ -55,6 +74,12 @@ func main() {
 			panic(err)
 		}
 	}()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+
 	zap.L().Info("Server starting...")
 
 	traceProvider := initTracer(cfg)
@@ -65,6 +90,7 @@ func main() {
 		cfg.PostgresConfig.Username,
 		cfg.PostgresConfig.Password,
 		cfg.PostgresConfig.Database,
+		book.WithSchemaCheck(cfg.PostgresConfig.RequireLatestSchema),
 	)
 
 	server := fiber.New(fiber.Config{
@@ -75,9 +101,19 @@ func main() {
 		ReadTimeout:           10 * time.Second,
 		WriteTimeout:          10 * time.Second,
 		Concurrency:           256 * 1024,
+		ErrorHandler:          apperror.ErrorHandler,
+		// streamedRoutes read their upload as it arrives, bodylimit.Middleware keeps the limit for everything else.
+		// ReadTimeout then applies between reads of a streamed body rather than to all of it, see bodylimit.Body
+		BodyLimit:                    fiber.DefaultBodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 	server.Use(recover.New())
-	server.Use(cors.New(cors.Config{AllowOrigins: cfg.CorsOrigins}))
+	server.Use(cors.New(cors.Config{
+		AllowOrigins:  cfg.CorsOrigins,
+		ExposeHeaders: strings.Join([]string{fiber.HeaderETag, fiber.HeaderLocation, fiber.HeaderContentDisposition}, ", "),
+	}))
+	server.Use(bodylimit.Middleware(fiber.DefaultBodyLimit, streamedRoutes))
 	server.Use(otelfiber.Middleware())
 	server.Use(requestDurationMiddleware())
 	server.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
@@ -86,14 +122,94 @@ func main() {
 	})
 
 	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(apperror.FieldName)
+	if err := isbn.RegisterValidation(validate); err != nil {
+		zap.L().Fatal("Failed to register isbn validation", zap.Error(err))
+	}
+	authorizer := newAuthorizer(cfg.Auth)
+	cursorSigner := newCursorSigner(cfg)
+	coverStore := newCoverStore(cfg.Covers)
 	handlers := []GlobalHandler{
-		book.NewHandler(server, validate, traceProvider.Tracer("book"), bookPgRepository),
-		url.NewHandler(server, validate, traceProvider.Tracer("url")),
+		book.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("book"),
+			bookPgRepository,
+			book.WithCursorSigner(cursorSigner),
+			book.WithAuthorizer(authorizer),
+			book.WithMetadataProvider(newMetadataProvider(cfg.Enrichment)),
+			book.WithCoverStore(coverStore, cfg.Covers.MaxBytes),
+		),
+		author.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("author"),
+			author.NewPgRepository(traceProvider, bookPgRepository.ConnectionPool()),
+			catalog.WithCursorSigner(cursorSigner),
+			catalog.WithAuthorizer(authorizer),
+		),
+		publisher.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("publisher"),
+			publisher.NewPgRepository(traceProvider, bookPgRepository.ConnectionPool()),
+			catalog.WithCursorSigner(cursorSigner),
+			catalog.WithAuthorizer(authorizer),
+		),
+		series.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("series"),
+			series.NewPgRepository(traceProvider, bookPgRepository.ConnectionPool()),
+			catalog.WithCursorSigner(cursorSigner),
+			catalog.WithAuthorizer(authorizer),
+		),
+		work.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("work"),
+			work.NewPgRepository(traceProvider, bookPgRepository.ConnectionPool()),
+			catalog.WithCursorSigner(cursorSigner),
+			catalog.WithAuthorizer(authorizer),
+		),
+		genre.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("genre"),
+			genre.NewPgRepository(traceProvider, bookPgRepository.ConnectionPool()),
+			genre.WithAuthorizer(authorizer),
+		),
+		url.NewHandler(
+			server,
+			validate,
+			traceProvider.Tracer("url"),
+			url.WithRuleSets(newUrlRuleSets(cfg.Url)),
+			url.WithAllowlists(newUrlAllowlists(cfg.Url)),
+			url.WithBatchOptions(url.BatchOptions{
+				MaxItems:    cfg.Url.Batch.MaxItems,
+				MaxBytes:    cfg.Url.Batch.MaxBytes,
+				Concurrency: cfg.Url.Batch.Concurrency,
+			}),
+			url.WithSitemapOptions(url.SitemapOptions{
+				MaxEntries: cfg.Url.Sitemap.MaxEntries,
+				MaxBytes:   cfg.Url.Sitemap.MaxBytes,
+			}),
+		),
 	}
 	for _, handler := range handlers {
 		handler.RegisterHandlers()
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.Retention.DeletedBooksDays > 0 {
+		retention := time.Duration(cfg.Retention.DeletedBooksDays) * 24 * time.Hour
+		go book.NewRetentionJob(bookPgRepository, coverStore, retention, cfg.Retention.PurgeInterval).Run(jobsCtx)
+	}
+	if cfg.CoverCheck.Enabled {
+		go book.NewCoverChecker(bookPgRepository, newCoverCheckOptions(cfg.CoverCheck, cfg.Covers, coverStore)).Run(jobsCtx)
+	}
+
 	go func() {
 		if err := server.Listen(fmt.Sprintf("0.0.0.0:%s", cfg.ServerPort)); err != nil {
 			zap.L().Fatal("Failed to start server", zap.Error(err))
@@ -104,6 +220,178 @@ func main() {
 	gracefulShutdown(server)
 }
 
+func newAuthorizer(cfg config.AuthConfig) *auth.Authorizer {
+	if !cfg.Enabled {
+		zap.L().Warn("Authentication is disabled, every route is open")
+		return nil
+	}
+
+	authenticators := make([]auth.Authenticator, 0, 2)
+	if cfg.JWT.HMACSecret != "" || cfg.JWT.JWKSFile != "" {
+		jwtAuthenticator, err := auth.NewJWTAuthenticator(auth.JWTOptions{
+			HMACSecret: cfg.JWT.HMACSecret,
+			JWKSFile:   cfg.JWT.JWKSFile,
+			Issuer:     cfg.JWT.Issuer,
+			Audience:   cfg.JWT.Audience,
+			RolesClaim: cfg.JWT.RolesClaim,
+		})
+		if err != nil {
+			zap.L().Fatal("Failed to configure jwt authentication", zap.Error(err))
+		}
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+
+	if len(cfg.APIKeys) > 0 {
+		keys := make([]auth.APIKey, 0, len(cfg.APIKeys))
+		for _, key := range cfg.APIKeys {
+			if key.Key == "" {
+				zap.L().Fatal("Failed to configure api key authentication, a key is empty", zap.String("name", key.Name))
+			}
+			keys = append(keys, auth.APIKey{Name: key.Name, Key: key.Key, Roles: key.Roles})
+		}
+		authenticators = append(authenticators, auth.NewAPIKeyAuthenticator(keys))
+	}
+
+	if len(authenticators) == 0 {
+		zap.L().Fatal("Authentication is enabled without credentials, set auth.jwt or auth.apiKeys, or " + config.OverlayEnv + "=config/config.dev.json for local development")
+	}
+
+	return auth.NewAuthorizer(authenticators...)
+}
+
+// newCursorSigner only lets local development and tests fall back to a per-process secret, anywhere else
+// cursors have to survive restarts and work across replicas.
+func newCursorSigner(cfg *config.Config) *cursor.Signer {
+	if cfg.Pagination.CursorSecret == "" && !cfg.Local() {
+		zap.L().Fatal("Pagination cursor secret is empty, set " + config.CursorSecretEnv + ", or " + config.OverlayEnv + "=config/config.dev.json for local development")
+	}
+
+	return cursor.NewSigner(cfg.Pagination.CursorSecret)
+}
+
+// newMetadataProvider puts the circuit breaker behind the cache, so cached answers are served while
+// the upstream is paused.
+func newMetadataProvider(cfg config.EnrichmentConfig) enrichment.MetadataProvider {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var provider enrichment.MetadataProvider
+	switch cfg.Provider {
+	case "file":
+		fileProvider, err := enrichment.NewFileProvider(cfg.File)
+		if err != nil {
+			zap.L().Fatal("Failed to load metadata file", zap.Error(err))
+		}
+		provider = fileProvider
+	case "openlibrary", "":
+		provider = enrichment.NewOpenLibraryProvider(cfg.BaseURL, cfg.Timeout)
+	default:
+		zap.L().Fatal("Unknown metadata provider", zap.String("provider", cfg.Provider))
+	}
+
+	return enrichment.NewCache(
+		enrichment.NewCircuitBreaker(provider, cfg.FailureThreshold, cfg.OpenDuration),
+		enrichment.CacheOptions{TTL: cfg.CacheTTL, NegativeTTL: cfg.NegativeCacheTTL, Size: cfg.CacheSize},
+	)
+}
+
+func newUrlRuleSets(cfg config.UrlConfig) map[string]*url.RuleSet {
+	ruleSets := make(map[string]*url.RuleSet, len(cfg.RuleSets))
+	for name, ruleConfigs := range cfg.RuleSets {
+		rules := make([]url.Rule, 0, len(ruleConfigs))
+		for _, rule := range ruleConfigs {
+			rules = append(rules, url.Rule{
+				Name:          rule.Name,
+				Host:          url.Match{Type: url.MatchType(rule.Host.Type), Pattern: rule.Host.Pattern},
+				Path:          url.Match{Type: url.MatchType(rule.Path.Type), Pattern: rule.Path.Pattern},
+				ForceHTTPS:    rule.ForceHTTPS,
+				SetHost:       rule.SetHost,
+				WWW:           url.Policy(rule.WWW),
+				Query:         url.QueryPolicy(rule.Query),
+				QueryParams:   rule.QueryParams,
+				DropFragment:  rule.DropFragment,
+				Lowercase:     url.LowercaseScope(rule.Lowercase),
+				TrailingSlash: url.Policy(rule.TrailingSlash),
+			})
+		}
+
+		ruleSet, err := url.NewRuleSet(rules)
+		if err != nil {
+			zap.L().Fatal("Invalid url rule set", zap.String("ruleSet", name), zap.Error(err))
+		}
+		ruleSets[name] = ruleSet
+	}
+
+	return ruleSets
+}
+
+func newUrlAllowlists(cfg config.UrlConfig) map[string]*url.Allowlist {
+	allowlists := make(map[string]*url.Allowlist, len(cfg.Allowlists))
+	for operation, ruleConfigs := range cfg.Allowlists {
+		rules := make([]url.DomainRule, 0, len(ruleConfigs))
+		for _, rule := range ruleConfigs {
+			rules = append(rules, url.DomainRule{Name: rule.Name, Type: url.DomainRuleType(rule.Type), Domain: rule.Domain})
+		}
+
+		allowlist, err := url.NewAllowlist(rules)
+		if err != nil {
+			zap.L().Fatal("Invalid url allowlist", zap.String("operation", operation), zap.Error(err))
+		}
+		allowlists[operation] = allowlist
+	}
+
+	for operation := range cfg.RuleSets {
+		if _, ok := allowlists[operation]; !ok {
+			zap.L().Warn("Url operation has no allowlist and accepts any host", zap.String("operation", operation))
+		}
+	}
+
+	return allowlists
+}
+
+func newCoverStore(cfg config.CoversConfig) storage.BlobStore {
+	switch cfg.Store {
+	case "":
+		return nil
+	case "fs":
+		store, err := storage.NewFileSystemStore(cfg.Dir)
+		if err != nil {
+			zap.L().Fatal("Failed to open cover directory", zap.Error(err))
+		}
+		return store
+	case "s3":
+		store, err := storage.NewS3Store(cfg.S3.Endpoint, cfg.S3.Region, cfg.S3.Bucket, cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey, cfg.S3.Timeout)
+		if err != nil {
+			zap.L().Fatal("Invalid cover store configuration", zap.Error(err))
+		}
+		return store
+	default:
+		zap.L().Fatal("Unknown cover store", zap.String("store", cfg.Store))
+		return nil
+	}
+}
+
+func newCoverCheckOptions(cfg config.CoverCheckConfig, covers config.CoversConfig, store storage.BlobStore) book.CoverCheckOptions {
+	options := book.CoverCheckOptions{
+		Interval:          cfg.Interval,
+		RecheckAfter:      cfg.RecheckAfter,
+		Concurrency:       cfg.Concurrency,
+		RequestsPerSecond: cfg.RequestsPerSecond,
+		Timeout:           cfg.Timeout,
+		Failing:           coverURLsFailing,
+	}
+
+	if cfg.Mirror {
+		if store == nil {
+			zap.L().Fatal("Mirroring cover urls needs a cover store")
+		}
+		options.Mirror, options.MirrorMaxBytes = store, covers.MaxBytes
+	}
+
+	return options
+}
+
 func gracefulShutdown(server *fiber.App) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -134,6 +422,15 @@ func requestDurationMiddleware() fiber.Handler {
 	}
 }
 
+// streamedRoutes read their body as it arrives under a limit of their own, every other route is held to the
+// server body limit by bodylimit.Middleware.
+var streamedRoutes = []bodylimit.Route{
+	{Method: fiber.MethodPost, Path: "/books/import"},
+	{Method: fiber.MethodPut, Path: "/book/*/cover"},
+	{Method: fiber.MethodPost, Path: "/url/sitemap"},
+	{Method: fiber.MethodPost, Path: "/urls/batch"},
+}
+
 func initTracer(cfg *config.Config) *sdktrace.TracerProvider {
 	exporter, err := otlptrace.New(
 		context.Background(),
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"book-api/migrations"
+	"book-api/pkg/config"
+	"book-api/pkg/migrate"
+)
+
+const migrateUsage = "usage: book-api migrate up|down|status|to <version>"
+
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		zap.L().Fatal(migrateUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	connectionPool, err := pgxpool.New(ctx, cfg.PostgresConfig.ConnectionString())
+	if err != nil {
+		zap.L().Fatal("failed to connect database", zap.Error(err))
+	}
+	defer connectionPool.Close()
+
+	migrator, err := migrate.New(connectionPool, migrations.FS)
+	if err != nil {
+		zap.L().Fatal("failed to load migrations", zap.Error(err))
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			zap.L().Fatal(migrateUsage)
+		}
+
+		var version int
+		version, err = strconv.Atoi(args[1])
+		if err != nil {
+			zap.L().Fatal("invalid migration version", zap.String("version", args[1]))
+		}
+
+		err = migrator.To(ctx, version)
+	case "status":
+		err = printMigrationStatus(ctx, migrator)
+	default:
+		zap.L().Fatal(migrateUsage)
+	}
+
+	if err != nil {
+		zap.L().Fatal("migration failed", zap.Error(err))
+	}
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return writer.Flush()
+}
@@ -1,4 +1,4 @@
-CREATE TABLE books (
+CREATE TABLE IF NOT EXISTS books (
     id UUID PRIMARY KEY UNIQUE NOT NULL DEFAULT gen_random_uuid(),
     cover_url varchar NOT NULL,
     isbn varchar NOT NULL,
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS books_created_at_id_idx ON books (created_at DESC, id DESC) WHERE deleted_at IS NULL;
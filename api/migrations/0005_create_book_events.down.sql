@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS book_events;
+
+DROP FUNCTION IF EXISTS book_events_reject_change();
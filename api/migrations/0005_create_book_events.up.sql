@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS book_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    book_id UUID NOT NULL,
+    event_type varchar(16) NOT NULL,
+    version integer NOT NULL,
+    actor varchar NOT NULL DEFAULT '',
+    trace_id varchar(32) NOT NULL DEFAULT '',
+    before jsonb,
+    after jsonb,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS book_events_book_id_idx ON book_events (book_id, id DESC);
+
+CREATE OR REPLACE FUNCTION book_events_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'book_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER book_events_append_only
+    BEFORE UPDATE OR DELETE ON book_events
+    FOR EACH ROW EXECUTE FUNCTION book_events_reject_change();
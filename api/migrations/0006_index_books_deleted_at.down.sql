@@ -0,0 +1 @@
+DROP INDEX IF EXISTS books_deleted_at_id_idx;
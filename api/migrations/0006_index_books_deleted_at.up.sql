@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS books_deleted_at_id_idx ON books (deleted_at DESC, id DESC) WHERE deleted_at IS NOT NULL;
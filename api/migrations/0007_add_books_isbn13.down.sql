@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS books_isbn13_live_idx;
+DROP TRIGGER IF EXISTS books_isbn13 ON books;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn13;
+DROP FUNCTION IF EXISTS books_set_isbn13();
+DROP FUNCTION IF EXISTS book_isbn13(text);
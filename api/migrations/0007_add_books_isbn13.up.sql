@@ -0,0 +1,63 @@
+-- book_isbn13 mirrors isbn.Normalize in pkg/isbn, it returns the ISBN-13 of a valid ISBN-10 or ISBN-13 and null otherwise.
+CREATE OR REPLACE FUNCTION book_isbn13(isbn text) RETURNS varchar(13) AS $$
+DECLARE
+    digits text := upper(regexp_replace(isbn, '[- ]', '', 'g'));
+    body text;
+    total int := 0;
+BEGIN
+    IF digits ~ '^[0-9]{9}[0-9X]$' THEN
+        FOR i IN 1..10 LOOP
+            total := total + (CASE WHEN substr(digits, i, 1) = 'X' THEN 10 ELSE substr(digits, i, 1)::int END) * (11 - i);
+        END LOOP;
+        IF total % 11 <> 0 THEN
+            RETURN NULL;
+        END IF;
+        body := '978' || left(digits, 9);
+    ELSIF digits ~ '^97[89][0-9]{10}$' THEN
+        body := left(digits, 12);
+    ELSE
+        RETURN NULL;
+    END IF;
+
+    total := 0;
+    FOR i IN 1..12 LOOP
+        total := total + substr(body, i, 1)::int * (CASE WHEN i % 2 = 0 THEN 3 ELSE 1 END);
+    END LOOP;
+    body := body || ((10 - total % 10) % 10)::text;
+
+    IF length(digits) = 13 AND body <> digits THEN
+        RETURN NULL;
+    END IF;
+
+    RETURN body;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION books_set_isbn13() RETURNS trigger AS $$
+BEGIN
+    NEW.isbn13 := book_isbn13(NEW.isbn);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn13 varchar(13);
+
+-- Only the oldest live book keeps an ISBN that was stored more than once, the later copies are left
+-- with a null isbn13 so the unique index can be built. Find them with `isbn13 IS NULL` and merge or trash them.
+UPDATE books SET isbn13 = ranked.isbn13
+FROM (
+    SELECT id,
+           book_isbn13(isbn) AS isbn13,
+           row_number() OVER (PARTITION BY book_isbn13(isbn), deleted_at IS NULL ORDER BY created_at, id) AS position
+    FROM books
+) AS ranked
+WHERE books.id = ranked.id
+  AND ranked.isbn13 IS NOT NULL
+  AND (ranked.position = 1 OR books.deleted_at IS NOT NULL);
+
+DROP TRIGGER IF EXISTS books_isbn13 ON books;
+CREATE TRIGGER books_isbn13
+    BEFORE INSERT OR UPDATE OF isbn ON books
+    FOR EACH ROW EXECUTE FUNCTION books_set_isbn13();
+
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn13_live_idx ON books (isbn13) WHERE deleted_at IS NULL;
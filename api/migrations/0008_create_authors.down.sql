@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS book_credit_authors(uuid);
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS authors;
+DROP FUNCTION IF EXISTS author_name_key(text);
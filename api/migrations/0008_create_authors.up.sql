@@ -0,0 +1,49 @@
+-- author_name_key folds case, punctuation and spacing, so "J.R.R. Tolkien" and "JRR Tolkien" are one author.
+CREATE OR REPLACE FUNCTION author_name_key(name text) RETURNS text AS $$
+    SELECT lower(regexp_replace(name, '[^[:alnum:]]+', '', 'g'));
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS authors (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL,
+    name_key text GENERATED ALWAYS AS (author_name_key(name)) STORED CHECK (name_key <> ''),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS authors_name_key_idx ON authors (name_key);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES authors (id),
+    role varchar(16) NOT NULL DEFAULT 'author' CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    position integer NOT NULL,
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_id_idx ON book_authors (author_id, role);
+
+-- book_credit_authors replaces the credits of a book with the names in its free text author, split on
+-- commas, semicolons, ampersands and "and". Names it does not know yet become new authors.
+CREATE OR REPLACE FUNCTION book_credit_authors(target uuid) RETURNS void AS $$
+BEGIN
+    DELETE FROM book_authors WHERE book_id = target;
+
+    INSERT INTO authors (name)
+    SELECT btrim(part.name)
+    FROM books
+    CROSS JOIN LATERAL regexp_split_to_table(books.author, '\s*[,;&]\s*|\s+and\s+', 'i') AS part(name)
+    WHERE books.id = target AND author_name_key(part.name) <> ''
+    ON CONFLICT (name_key) DO NOTHING;
+
+    INSERT INTO book_authors (book_id, author_id, role, position)
+    SELECT DISTINCT ON (authors.id) target, authors.id, 'author', part.position
+    FROM books
+    CROSS JOIN LATERAL regexp_split_to_table(books.author, '\s*[,;&]\s*|\s+and\s+', 'i') WITH ORDINALITY AS part(name, position)
+    JOIN authors ON authors.name_key = author_name_key(part.name)
+    WHERE books.id = target
+    ORDER BY authors.id, part.position;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT book_credit_authors(id) FROM books;
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS books_work ON books;
+DROP FUNCTION IF EXISTS books_set_work();
+ALTER TABLE books
+    DROP COLUMN IF EXISTS series_position,
+    DROP COLUMN IF EXISTS series_id,
+    DROP COLUMN IF EXISTS publisher_id,
+    DROP COLUMN IF EXISTS work_id;
+DROP TABLE IF EXISTS works;
+DROP TABLE IF EXISTS series;
+DROP TABLE IF EXISTS publishers;
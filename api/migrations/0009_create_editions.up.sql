@@ -0,0 +1,73 @@
+-- publisher names fold like author names, so "O'Reilly" and "OReilly" are one publisher.
+CREATE TABLE IF NOT EXISTS publishers (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL,
+    name_key text GENERATED ALWAYS AS (author_name_key(name)) STORED CHECK (name_key <> ''),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS publishers_name_key_idx ON publishers (name_key);
+
+-- series names are not unique, unrelated series are often called the same.
+CREATE TABLE IF NOT EXISTS series (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL CHECK (btrim(name) <> ''),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS series_name_idx ON series (name, id);
+
+-- a work is the book as written, every row of books is one edition of a work.
+CREATE TABLE IF NOT EXISTS works (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    title varchar(255) NOT NULL CHECK (btrim(title) <> ''),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS works_title_idx ON works (title, id);
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS work_id UUID REFERENCES works (id),
+    ADD COLUMN IF NOT EXISTS publisher_id UUID REFERENCES publishers (id),
+    ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES series (id),
+    ADD COLUMN IF NOT EXISTS series_position integer CHECK (series_position > 0),
+    ADD CONSTRAINT books_series_position_series_check CHECK (series_position IS NULL OR series_id IS NOT NULL);
+
+-- Existing books with the same title and author become editions of one work.
+WITH grouped AS (
+    SELECT lower(btrim(title)) AS title_key,
+           lower(btrim(author)) AS author_key,
+           gen_random_uuid() AS work_id,
+           min(title) AS title,
+           min(created_at) AS created_at
+    FROM books
+    GROUP BY lower(btrim(title)), lower(btrim(author))
+), inserted AS (
+    INSERT INTO works (id, title, created_at)
+    SELECT work_id, title, created_at FROM grouped
+)
+UPDATE books SET work_id = grouped.work_id
+FROM grouped
+WHERE lower(btrim(books.title)) = grouped.title_key AND lower(btrim(books.author)) = grouped.author_key;
+
+ALTER TABLE books ALTER COLUMN work_id SET NOT NULL;
+
+-- books_set_work gives a book inserted without a work one of its own, titled like the book.
+CREATE OR REPLACE FUNCTION books_set_work() RETURNS trigger AS $$
+BEGIN
+    IF NEW.work_id IS NULL THEN
+        INSERT INTO works (title, created_at) VALUES (NEW.title, coalesce(NEW.created_at, now())) RETURNING id INTO NEW.work_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_work ON books;
+CREATE TRIGGER books_work BEFORE INSERT ON books FOR EACH ROW EXECUTE FUNCTION books_set_work();
+
+CREATE INDEX IF NOT EXISTS books_work_id_idx ON books (work_id);
+CREATE INDEX IF NOT EXISTS books_publisher_id_idx ON books (publisher_id);
+CREATE INDEX IF NOT EXISTS books_series_id_idx ON books (series_id, series_position);
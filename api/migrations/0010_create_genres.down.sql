@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS books_tags_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS tags;
+DROP TABLE IF EXISTS book_genres;
+DROP FUNCTION IF EXISTS genre_subtree(text);
+DROP TABLE IF EXISTS genres;
@@ -0,0 +1,43 @@
+-- genres form a tree, a book filed under a genre is also found under every genre above it.
+-- dewey and bisac optionally map a genre onto the library and the book trade classifications.
+CREATE TABLE IF NOT EXISTS genres (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    parent_id UUID REFERENCES genres (id),
+    name varchar(100) NOT NULL,
+    slug varchar(100) NOT NULL,
+    dewey varchar(16),
+    bisac varchar(9),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT genres_name_check CHECK (btrim(name) <> ''),
+    CONSTRAINT genres_slug_check CHECK (slug ~ '^[[:alnum:]]+(-[[:alnum:]]+)*$' AND slug = lower(slug)),
+    CONSTRAINT genres_parent_check CHECK (parent_id <> id),
+    CONSTRAINT genres_dewey_check CHECK (dewey ~ '^[0-9]{3}(\.[0-9]+)?$'),
+    CONSTRAINT genres_bisac_check CHECK (bisac ~ '^[A-Z]{3}[0-9]{6}$')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS genres_slug_idx ON genres (slug);
+CREATE INDEX IF NOT EXISTS genres_parent_id_idx ON genres (parent_id);
+
+-- genre_subtree returns the genre named by slug or id and every genre below it.
+CREATE OR REPLACE FUNCTION genre_subtree(ref text) RETURNS SETOF uuid AS $$
+    WITH RECURSIVE subtree AS (
+        SELECT id FROM genres WHERE slug = lower(ref) OR id::text = lower(ref)
+        UNION
+        SELECT genres.id FROM genres JOIN subtree ON genres.parent_id = subtree.id
+    )
+    SELECT id FROM subtree;
+$$ LANGUAGE sql STABLE;
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id UUID NOT NULL REFERENCES genres (id),
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_genres_genre_id_idx ON book_genres (genre_id);
+
+-- tags are free-form, written lowercased with single spaces so "Space  Opera" and "space opera" are one tag.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS books_tags_idx ON books USING gin (tags);
@@ -0,0 +1,14 @@
+-- book_covers describes the image uploaded for a book, the bytes live in the blob store under
+-- covers/<book_id>/<digest>/<size>. A new upload gets a new digest, so cached copies of the old one never mix in.
+CREATE TABLE IF NOT EXISTS book_covers (
+    book_id UUID PRIMARY KEY NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    digest varchar(64) NOT NULL,
+    content_type varchar(32) NOT NULL,
+    thumbnail_type varchar(32) NOT NULL,
+    width integer NOT NULL,
+    height integer NOT NULL,
+    byte_size bigint NOT NULL,
+    sizes text[] NOT NULL DEFAULT '{}',
+    uploaded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT book_covers_dimensions_check CHECK (width > 0 AND height > 0 AND byte_size > 0)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE book_covers DROP COLUMN IF EXISTS source_url;
+DROP TABLE IF EXISTS cover_checks;
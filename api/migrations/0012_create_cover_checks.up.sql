@@ -0,0 +1,20 @@
+-- cover_checks keeps the latest health check of each book's cover_url. A check of a url the book no longer has
+-- is stale and gets redone. failing_since is when the current run of failed checks began.
+CREATE TABLE IF NOT EXISTS cover_checks (
+    book_id UUID PRIMARY KEY NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    cover_url varchar NOT NULL,
+    outcome varchar(16) NOT NULL,
+    status_code integer,
+    content_type varchar(255),
+    error text,
+    failures integer NOT NULL DEFAULT 0,
+    failing_since TIMESTAMP WITH TIME ZONE,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT cover_checks_outcome_check CHECK (outcome IN ('ok', 'http_error', 'not_image', 'unreachable'))
+);
+
+CREATE INDEX IF NOT EXISTS cover_checks_checked_at_idx ON cover_checks (checked_at);
+CREATE INDEX IF NOT EXISTS cover_checks_failing_idx ON cover_checks (failing_since, book_id) WHERE failing_since IS NOT NULL;
+
+-- source_url is set on covers mirrored from the cover_url, uploads leave it null
+ALTER TABLE book_covers ADD COLUMN IF NOT EXISTS source_url varchar;
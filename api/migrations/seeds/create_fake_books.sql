@@ -18,4 +18,6 @@ INSERT INTO books (cover_url, isbn, title, author, publication_year) VALUES
 ('https://covers.openlibrary.org/b/id/8406786-L.jpg', '978-0-441-17271-9', 'Dune', 'Frank Herbert', '1965'),
 ('https://covers.openlibrary.org/b/id/8231617-L.jpg', '978-0-385-33348-1', 'The Kite Runner', 'Khaled Hosseini', '2003'),
 ('https://covers.openlibrary.org/b/id/8234658-L.jpg', '978-0-375-75155-8', 'Life of Pi', 'Yann Martel', '2001'),
-('https://covers.openlibrary.org/b/id/10089275-L.jpg', '978-0-679-73224-2', 'One Hundred Years of Solitude', 'Gabriel García Márquez', '1967');
\ No newline at end of file
+('https://covers.openlibrary.org/b/id/10089275-L.jpg', '978-0-679-73224-2', 'One Hundred Years of Solitude', 'Gabriel García Márquez', '1967');
+
+SELECT book_credit_authors(id) FROM books WHERE NOT EXISTS (SELECT 1 FROM book_authors WHERE book_id = books.id);
@@ -0,0 +1,206 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	KindConflict
+	KindUnavailable
+	KindUnauthenticated
+	KindForbidden
+	KindPreconditionFailed
+	KindPreconditionRequired
+	KindTooLarge
+	KindUnsupportedMediaType
+)
+
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Meta    map[string]any
+	Err     error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Invalid(code, message string) *Error {
+	return New(KindInvalid, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+func Unauthenticated(code, message string) *Error {
+	return New(KindUnauthenticated, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func PreconditionRequired(code, message string) *Error {
+	return New(KindPreconditionRequired, code, message)
+}
+
+func TooLarge(code, message string) *Error {
+	return New(KindTooLarge, code, message)
+}
+
+func UnsupportedMediaType(code, message string) *Error {
+	return New(KindUnsupportedMediaType, code, message)
+}
+
+func Internal(code, message string) *Error {
+	return New(KindInternal, code, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches on the error code so sentinel errors keep working after WithCause or WithMeta copies them.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+
+	return e.Code == t.Code
+}
+
+func (e *Error) WithCause(err error) *Error {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
+
+func (e *Error) WithMeta(key string, value any) *Error {
+	clone := *e
+	clone.Meta = make(map[string]any, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		clone.Meta[k] = v
+	}
+	clone.Meta[key] = value
+	return &clone
+}
+
+func (e *Error) WithFields(fields []FieldError) *Error {
+	clone := *e
+	clone.Fields = fields
+	return &clone
+}
+
+var (
+	ErrInvalidBody   = Invalid("INVALID_BODY", "request body could not be parsed")
+	ErrInvalidQuery  = Invalid("INVALID_QUERY", "request query could not be parsed")
+	ErrInvalidCursor = Invalid("INVALID_CURSOR", "cursor is invalid or was issued by another server")
+	ErrValidation    = Invalid("VALIDATION_FAILED", "request validation failed")
+)
+
+func FromValidation(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return ErrValidation.WithCause(err)
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, FieldError{
+			Field: fieldPath(fieldError),
+			Rule:  fieldError.Tag(),
+			Param: fieldError.Param(),
+		})
+	}
+
+	validationError := ErrValidation.WithCause(err)
+	validationError.Fields = fields
+	return validationError
+}
+
+func FromParamValidation(param string, err error) *Error {
+	validationError := FromValidation(err)
+	for i := range validationError.Fields {
+		validationError.Fields[i].Field = param
+	}
+
+	return validationError
+}
+
+// FieldName reports request field names as clients send them, for use with validator.RegisterTagNameFunc.
+func FieldName(field reflect.StructField) string {
+	for _, tagKey := range []string{"json", "query", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tagKey), ",")
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldPath drops the root struct name and untagged embedded structs from the namespace,
+// e.g. "UpdateBookRequest.CreateBookRequest.isbn" becomes "isbn" like it is in the JSON body.
+func fieldPath(fieldError validator.FieldError) string {
+	namespace := strings.Split(fieldError.Namespace(), ".")
+	structNamespace := strings.Split(fieldError.StructNamespace(), ".")
+	if len(namespace) < 2 || len(namespace) != len(structNamespace) {
+		return fieldError.Field()
+	}
+
+	path := make([]string, 0, len(namespace)-1)
+	for i := 1; i < len(namespace)-1; i++ {
+		// FieldName only falls back to the Go name for untagged fields, which in request structs are embedded ones
+		if namespace[i] == structNamespace[i] {
+			continue
+		}
+		path = append(path, namespace[i])
+	}
+
+	return strings.Join(append(path, namespace[len(namespace)-1]), ".")
+}
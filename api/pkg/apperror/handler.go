@@ -0,0 +1,118 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	TraceId  string         `json:"traceId,omitempty"`
+	Errors   []FieldError   `json:"errors,omitempty"`
+	Meta     map[string]any `json:"meta,omitempty"`
+}
+
+var kindStatus = map[Kind]int{
+	KindInternal:        fiber.StatusInternalServerError,
+	KindInvalid:         fiber.StatusBadRequest,
+	KindNotFound:        fiber.StatusNotFound,
+	KindConflict:        fiber.StatusConflict,
+	KindUnavailable:     fiber.StatusServiceUnavailable,
+	KindUnauthenticated: fiber.StatusUnauthorized,
+	KindForbidden:       fiber.StatusForbidden,
+
+	KindPreconditionFailed:   fiber.StatusPreconditionFailed,
+	KindPreconditionRequired: fiber.StatusPreconditionRequired,
+	KindTooLarge:             fiber.StatusRequestEntityTooLarge,
+	KindUnsupportedMediaType: fiber.StatusUnsupportedMediaType,
+}
+
+func StatusOf(kind Kind) int {
+	if status, ok := kindStatus[kind]; ok {
+		return status
+	}
+
+	return fiber.StatusInternalServerError
+}
+
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	problem := NewProblem(err)
+	problem.Instance = ctx.OriginalURL()
+	problem.TraceId = traceId(ctx)
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		zap.L().Error(
+			"request failed",
+			zap.String("path", ctx.Path()),
+			zap.String("method", ctx.Method()),
+			zap.String("traceId", problem.TraceId),
+			zap.Error(err),
+		)
+	}
+
+	return ctx.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+}
+
+func NewProblem(err error) *Problem {
+	var appError *Error
+	if errors.As(err, &appError) {
+		status := StatusOf(appError.Kind)
+		return &Problem{
+			Type:   problemType(appError.Code),
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: appError.Message,
+			Code:   appError.Code,
+			Errors: appError.Fields,
+			Meta:   appError.Meta,
+		}
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		code := codeFromStatus(fiberError.Code)
+		return &Problem{
+			Type:   problemType(code),
+			Title:  http.StatusText(fiberError.Code),
+			Status: fiberError.Code,
+			Detail: fiberError.Message,
+			Code:   code,
+		}
+	}
+
+	return &Problem{
+		Type:   problemType("INTERNAL_ERROR"),
+		Title:  http.StatusText(fiber.StatusInternalServerError),
+		Status: fiber.StatusInternalServerError,
+		Code:   "INTERNAL_ERROR",
+	}
+}
+
+func problemType(code string) string {
+	return "urn:book-api:problem:" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+func codeFromStatus(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+func traceId(ctx *fiber.Ctx) string {
+	spanContext := trace.SpanContextFromContext(ctx.UserContext())
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,158 @@
+package apperror
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	json "github.com/bytedance/sonic"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(FieldName)
+
+	type request struct {
+		Isbn string `json:"isbn" validate:"required,isbn"`
+		Page int    `query:"page" validate:"min=1"`
+	}
+	validationErr := validate.Struct(request{Isbn: "invalid"})
+	require.Error(t, validationErr)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedFields []FieldError
+	}{
+		{
+			name:           "validation error",
+			err:            FromValidation(validationErr),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_FAILED",
+			expectedFields: []FieldError{
+				{Field: "isbn", Rule: "isbn"},
+				{Field: "page", Rule: "min", Param: "1"},
+			},
+		},
+		{
+			name:           "not found error",
+			err:            NotFound("BOOK_NOT_FOUND", "book not found").WithCause(errors.New("no rows")),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "BOOK_NOT_FOUND",
+		},
+		{
+			name:           "conflict error",
+			err:            Conflict("BOOK_ALREADY_EXISTS", "book already exists"),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "BOOK_ALREADY_EXISTS",
+		},
+		{
+			name:           "unavailable error",
+			err:            Unavailable("BOOK_REPOSITORY_UNAVAILABLE", "book repository is unavailable"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "BOOK_REPOSITORY_UNAVAILABLE",
+		},
+		{
+			name:           "too large error",
+			err:            TooLarge("COVER_TOO_LARGE", "cover image is larger than allowed"),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "COVER_TOO_LARGE",
+		},
+		{
+			name:           "unsupported media type error",
+			err:            UnsupportedMediaType("UNSUPPORTED_COVER_TYPE", "cover must be a jpeg, png or gif image"),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   "UNSUPPORTED_COVER_TYPE",
+		},
+		{
+			name:           "fiber error",
+			err:            fiber.ErrMethodNotAllowed,
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedCode:   "METHOD_NOT_ALLOWED",
+		},
+		{
+			name:           "unknown error",
+			err:            errors.New("boom"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "INTERNAL_ERROR",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fiber.New(fiber.Config{
+				JSONEncoder:           json.Marshal,
+				JSONDecoder:           json.Unmarshal,
+				DisableStartupMessage: true,
+				ErrorHandler:          ErrorHandler,
+			})
+			server.Get("/", func(ctx *fiber.Ctx) error {
+				return tc.err
+			})
+
+			res, err := server.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(body, &problem))
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+			assert.Equal(t, MIMEApplicationProblemJSON, res.Header.Get(fiber.HeaderContentType))
+			assert.Equal(t, tc.expectedStatus, problem.Status)
+			assert.Equal(t, tc.expectedCode, problem.Code)
+			assert.Equal(t, tc.expectedFields, problem.Errors)
+			assert.Equal(t, "/", problem.Instance)
+		})
+	}
+}
+
+func TestFromValidation(t *testing.T) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(FieldName)
+
+	type author struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type createRequest struct {
+		Title  string `json:"title" validate:"required"`
+		Author author `json:"author"`
+	}
+	type updateRequest struct {
+		createRequest
+		Id string `json:"id" validate:"required,uuid4"`
+	}
+
+	validationErr := FromValidation(validate.Struct(updateRequest{}))
+
+	assert.Equal(t, "VALIDATION_FAILED", validationErr.Code)
+	assert.Equal(t, []FieldError{
+		{Field: "title", Rule: "required"},
+		{Field: "author.name", Rule: "required"},
+		{Field: "id", Rule: "required"},
+	}, validationErr.Fields)
+}
+
+func TestFromParamValidation(t *testing.T) {
+	validationErr := FromParamValidation("id", validator.New().Var("123", "required,uuid4"))
+
+	assert.Equal(t, []FieldError{{Field: "id", Rule: "uuid4"}}, validationErr.Fields)
+}
+
+func TestError_Is(t *testing.T) {
+	sentinel := NotFound("BOOK_NOT_FOUND", "book not found")
+
+	assert.ErrorIs(t, sentinel.WithCause(errors.New("no rows")), sentinel)
+	assert.ErrorIs(t, sentinel.WithMeta("id", "1"), sentinel)
+	assert.NotErrorIs(t, Conflict("BOOK_ALREADY_EXISTS", "book already exists"), sentinel)
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+var errInvalidAPIKey = errors.New("api key is not recognised")
+
+type APIKey struct {
+	Name  string
+	Key   string
+	Roles []string
+}
+
+type APIKeyAuthenticator struct {
+	keys []APIKey
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(ctx *fiber.Ctx) (*Principal, error) {
+	presented := ctx.Get(HeaderAPIKey)
+	if presented == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// every configured key is compared so the response time does not reveal which prefix matched
+	var matched *APIKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(a.keys[i].Key), []byte(presented)) == 1 {
+			matched = &a.keys[i]
+		}
+	}
+
+	if matched == nil {
+		return nil, errInvalidAPIKey
+	}
+
+	return &Principal{
+		Subject: matched.Name,
+		Roles:   toRoles(matched.Roles),
+		Method:  "apiKey",
+	}, nil
+}
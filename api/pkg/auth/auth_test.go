@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"book-api/pkg/apperror"
+)
+
+func TestPrincipal_HasRole(t *testing.T) {
+	admin := &Principal{Roles: []Role{RoleAdmin}}
+	reader := &Principal{Roles: []Role{RoleReader}}
+
+	assert.True(t, admin.HasRole(RoleReader))
+	assert.True(t, admin.HasRole(RoleEditor))
+	assert.True(t, reader.HasRole(RoleReader))
+	assert.False(t, reader.HasRole(RoleEditor))
+	assert.False(t, (&Principal{}).HasRole(RoleReader))
+}
+
+func TestAuthorizer_Require(t *testing.T) {
+	const secret = "test-secret-of-at-least-32-bytes"
+
+	jwtAuthenticator, err := NewJWTAuthenticator(JWTOptions{HMACSecret: secret, Issuer: "book-api-test"})
+	require.NoError(t, err)
+
+	authorizer := NewAuthorizer(
+		jwtAuthenticator,
+		NewAPIKeyAuthenticator([]APIKey{{Name: "ci", Key: "reader-key", Roles: []string{"reader"}}}),
+	)
+
+	server := fiber.New(fiber.Config{ErrorHandler: apperror.ErrorHandler})
+	server.Get("/read", authorizer.Require(RoleReader), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(PrincipalFrom(ctx).Subject)
+	})
+	server.Delete("/delete", authorizer.Require(RoleAdmin), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	send := func(method, path string, headers map[string]string) *http.Response {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		return res
+	}
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	t.Run("hs256 token", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{
+			fiber.HeaderAuthorization: sign(jwt.MapClaims{
+				"sub":   "jane",
+				"iss":   "book-api-test",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+				"roles": []string{"editor"},
+			}),
+		})
+
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("space separated roles", func(t *testing.T) {
+		res := send(http.MethodDelete, "/delete", map[string]string{
+			fiber.HeaderAuthorization: sign(jwt.MapClaims{
+				"sub":   "root",
+				"iss":   "book-api-test",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+				"roles": "reader admin",
+			}),
+		})
+
+		assert.Equal(t, fiber.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("token without subject", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{
+			fiber.HeaderAuthorization: sign(jwt.MapClaims{
+				"iss":   "book-api-test",
+				"exp":   time.Now().Add(time.Minute).Unix(),
+				"roles": []string{"reader"},
+			}),
+		})
+
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{
+			fiber.HeaderAuthorization: sign(jwt.MapClaims{
+				"sub": "jane",
+				"iss": "book-api-test",
+				"exp": time.Now().Add(-time.Minute).Unix(),
+			}),
+		})
+
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{
+			fiber.HeaderAuthorization: sign(jwt.MapClaims{
+				"sub": "jane",
+				"iss": "someone-else",
+				"exp": time.Now().Add(time.Minute).Unix(),
+			}),
+		})
+
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{HeaderAPIKey: "reader-key"})
+
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("unknown api key", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", map[string]string{HeaderAPIKey: "guess"})
+
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		res := send(http.MethodGet, "/read", nil)
+
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, `Bearer realm="book-api"`, res.Header.Get(fiber.HeaderWWWAuthenticate))
+	})
+
+	t.Run("insufficient role", func(t *testing.T) {
+		res := send(http.MethodDelete, "/delete", map[string]string{HeaderAPIKey: "reader-key"})
+
+		assert.Equal(t, fiber.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("nil authorizer", func(t *testing.T) {
+		var disabled *Authorizer
+		open := fiber.New()
+		open.Get("/", disabled.Require(RoleAdmin), func(ctx *fiber.Ctx) error {
+			return ctx.SendStatus(fiber.StatusOK)
+		})
+
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+
+		res, err := open.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+}
+
+func TestJWTAuthenticator_RS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	jwks, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	authenticator, err := NewJWTAuthenticator(JWTOptions{JWKSFile: jwksFile, Audience: "book-api", RolesClaim: "groups"})
+	require.NoError(t, err)
+
+	server := fiber.New(fiber.Config{ErrorHandler: apperror.ErrorHandler})
+	server.Get("/", NewAuthorizer(authenticator).Require(RoleEditor), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(PrincipalFrom(ctx).Subject)
+	})
+
+	sign := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"sub":    "svc",
+			"aud":    "book-api",
+			"exp":    time.Now().Add(time.Minute).Unix(),
+			"groups": []string{"editor"},
+		})
+		token.Header["kid"] = kid
+
+		signed, err := token.SignedString(privateKey)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderAuthorization, sign("test-key"))
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("unknown key id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderAuthorization, sign("rotated-key"))
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("hs256 rejected without secret", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "svc",
+			"aud": "book-api",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}).SignedString([]byte("anything"))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		res, err := server.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+	})
+}
+
+func TestNewJWTAuthenticator(t *testing.T) {
+	t.Run("no keys", func(t *testing.T) {
+		_, err := NewJWTAuthenticator(JWTOptions{})
+
+		assert.ErrorIs(t, err, errNoVerificationKeys)
+	})
+
+	t.Run("short hmac secret", func(t *testing.T) {
+		_, err := NewJWTAuthenticator(JWTOptions{HMACSecret: "test-secret"})
+
+		assert.ErrorIs(t, err, errShortHMACSecret)
+	})
+
+	t.Run("missing jwks file", func(t *testing.T) {
+		_, err := NewJWTAuthenticator(JWTOptions{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,120 @@
+package bodylimit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"path"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrTooLarge = errors.New("request body is larger than allowed")
+
+// Route names the requests of one route by method and a path.Match pattern, like /book/*/cover.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Middleware keeps every request body within limit, which must be the BodyLimit of the app. With
+// StreamRequestBody fasthttp buffers a body of known length up to that limit and leaves larger or
+// chunked ones on the connection, so those are refused or read here under the same limit.
+// Streamed routes are left alone, their handlers read the body through Body with a limit of their own.
+func Middleware(limit int, streamed []Route) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, route := range streamed {
+			if matched, _ := path.Match(route.Path, c.Path()); matched && c.Method() == route.Method {
+				return c.Next()
+			}
+		}
+
+		if c.Request().Header.ContentLength() > limit {
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+
+		if body := stream(c); body != nil && c.Request().Header.ContentLength() < 0 {
+			buffered, err := io.ReadAll(Reader(body, int64(limit)))
+			if errors.Is(err, ErrTooLarge) {
+				c.Context().SetConnectionClose()
+				return fiber.ErrRequestEntityTooLarge
+			}
+			if err != nil {
+				return fiber.ErrBadRequest
+			}
+			c.Request().SetBody(buffered)
+		}
+
+		return c.Next()
+	}
+}
+
+// Body is the request body as it arrives, failing with ErrTooLarge past limit bytes.
+func Body(c *fiber.Ctx, limit int64) io.Reader {
+	body := stream(c)
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	return Reader(body, limit)
+}
+
+// stream is the body left on the connection, nil when fasthttp already read all of it. The read deadline
+// fasthttp sets from ReadTimeout covers the whole request, so it is moved forward on every read of the
+// stream instead, an upload then only times out once the client stops sending for that long.
+func stream(c *fiber.Ctx) io.Reader {
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		return nil
+	}
+
+	conn := c.Context().Conn()
+	timeout := c.App().Config().ReadTimeout
+	if conn == nil || timeout <= 0 {
+		return body
+	}
+
+	return &deadlineReader{r: body, conn: conn, timeout: timeout}
+}
+
+type deadlineReader struct {
+	r       io.Reader
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	_ = d.conn.SetReadDeadline(time.Now().Add(d.timeout))
+
+	return d.r.Read(p)
+}
+
+// Reader fails with ErrTooLarge once more than limit bytes were read from r, a body of exactly limit bytes passes.
+func Reader(r io.Reader, limit int64) io.Reader {
+	return &cappedReader{r: r, remaining: limit + 1}
+}
+
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining <= 0 {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
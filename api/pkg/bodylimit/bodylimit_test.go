@@ -0,0 +1,120 @@
+package bodylimit
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunked hides the length of its reader so the request is sent with chunked transfer encoding.
+type chunked struct{ io.Reader }
+
+func TestMiddleware(t *testing.T) {
+	const limit = 16
+
+	cases := []struct {
+		name           string
+		method         string
+		path           string
+		body           io.Reader
+		expectedStatus int
+		expectedLength int
+	}{
+		{name: "within the limit", method: http.MethodPost, path: "/book", body: strings.NewReader(strings.Repeat("a", limit)), expectedStatus: http.StatusOK, expectedLength: limit},
+		{name: "content length above the limit", method: http.MethodPost, path: "/book", body: strings.NewReader(strings.Repeat("a", limit+1)), expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked within the limit", method: http.MethodPost, path: "/book", body: chunked{strings.NewReader(strings.Repeat("a", limit))}, expectedStatus: http.StatusOK, expectedLength: limit},
+		{name: "chunked above the limit", method: http.MethodPost, path: "/book", body: chunked{strings.NewReader(strings.Repeat("a", 10*limit))}, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "streamed route", method: http.MethodPut, path: "/book/1/cover", body: chunked{strings.NewReader(strings.Repeat("a", 10*limit))}, expectedStatus: http.StatusOK, expectedLength: 10 * limit},
+		{name: "streamed path with another method", method: http.MethodPost, path: "/book/1/cover", body: strings.NewReader(strings.Repeat("a", limit+1)), expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{BodyLimit: limit, StreamRequestBody: true, DisableStartupMessage: true})
+			app.Use(Middleware(limit, []Route{{Method: http.MethodPut, Path: "/book/*/cover"}}))
+			app.All("/*", func(c *fiber.Ctx) error {
+				body, err := io.ReadAll(Body(c, 1<<20))
+				if err != nil {
+					return err
+				}
+				return c.SendString(strconv.Itoa(len(body)))
+			})
+
+			req, err := http.NewRequest(c.method, c.path, c.body)
+			require.NoError(t, err)
+			if _, ok := c.body.(chunked); ok {
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			res, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedStatus, res.StatusCode)
+
+			if c.expectedStatus == http.StatusOK {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, strconv.Itoa(c.expectedLength), string(body))
+			}
+		})
+	}
+}
+
+func TestBody_SlowUpload(t *testing.T) {
+	const readTimeout = 200 * time.Millisecond
+
+	app := fiber.New(fiber.Config{ReadTimeout: readTimeout, StreamRequestBody: true, DisableStartupMessage: true})
+	app.Put("/book/:id/cover", func(c *fiber.Ctx) error {
+		body, err := io.ReadAll(Body(c, 1<<20))
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.Itoa(len(body)))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	defer func() { _ = app.Shutdown() }()
+
+	// every chunk arrives well within the read timeout, all of them together take several times as long
+	body, writer := io.Pipe()
+	go func() {
+		for range 8 {
+			time.Sleep(readTimeout / 2)
+			if _, err := writer.Write([]byte(strings.Repeat("a", 16))); err != nil {
+				return
+			}
+		}
+		_ = writer.Close()
+	}()
+
+	req, err := http.NewRequest(http.MethodPut, "http://"+listener.Addr().String()+"/book/1/cover", chunked{body})
+	require.NoError(t, err)
+	req.TransferEncoding = []string{"chunked"}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	received, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(8*16), string(received))
+}
+
+func TestReader(t *testing.T) {
+	body, err := io.ReadAll(Reader(strings.NewReader("abcd"), 4))
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(body))
+
+	body, err = io.ReadAll(Reader(strings.NewReader("abcde"), 4))
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.Len(t, body, 5)
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/providers/file"
@@ -16,13 +18,169 @@ type PostgresConfig struct {
 	Username string `koanf:"username"`
 	Password string `koanf:"password"`
 	Database string `koanf:"database"`
+
+	RequireLatestSchema bool `koanf:"requireLatestSchema"`
+}
+
+func (c PostgresConfig) ConnectionString() string {
+	return fmt.Sprintf(
+		"user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
+		c.Username, c.Password, c.Host, c.Port, c.Database,
+	)
+}
+
+// PaginationConfig holds the secret list cursors are signed with. CursorSecretEnv overrides it, and only a
+// local or test Environment may leave it empty.
+type PaginationConfig struct {
+	CursorSecret string `koanf:"cursorSecret"`
+}
+
+// RetentionConfig controls how long soft deleted books stay restorable, zero days keeps them forever.
+type RetentionConfig struct {
+	DeletedBooksDays int           `koanf:"deletedBooksDays"`
+	PurgeInterval    time.Duration `koanf:"purgeInterval"`
+}
+
+type JWTConfig struct {
+	HMACSecret string `koanf:"hmacSecret"`
+	JWKSFile   string `koanf:"jwksFile"`
+	Issuer     string `koanf:"issuer"`
+	Audience   string `koanf:"audience"`
+	RolesClaim string `koanf:"rolesClaim"`
+}
+
+type APIKeyConfig struct {
+	Name  string   `koanf:"name"`
+	Key   string   `koanf:"key"`
+	Roles []string `koanf:"roles"`
+}
+
+// AuthConfig guards the routes, it is enabled unless the config turns it off.
+type AuthConfig struct {
+	Enabled bool           `koanf:"enabled"`
+	JWT     JWTConfig      `koanf:"jwt"`
+	APIKeys []APIKeyConfig `koanf:"apiKeys"`
+}
+
+// EnrichmentConfig selects the catalogue behind POST /book/enrich, provider is "openlibrary" or "file".
+// Zero durations and sizes fall back to the enrichment package defaults.
+type EnrichmentConfig struct {
+	Enabled          bool          `koanf:"enabled"`
+	Provider         string        `koanf:"provider"`
+	BaseURL          string        `koanf:"baseUrl"`
+	File             string        `koanf:"file"`
+	Timeout          time.Duration `koanf:"timeout"`
+	CacheTTL         time.Duration `koanf:"cacheTtl"`
+	NegativeCacheTTL time.Duration `koanf:"negativeCacheTtl"`
+	CacheSize        int           `koanf:"cacheSize"`
+	FailureThreshold int           `koanf:"failureThreshold"`
+	OpenDuration     time.Duration `koanf:"openDuration"`
+}
+
+type S3Config struct {
+	Endpoint        string        `koanf:"endpoint"`
+	Region          string        `koanf:"region"`
+	Bucket          string        `koanf:"bucket"`
+	AccessKeyID     string        `koanf:"accessKeyId"`
+	SecretAccessKey string        `koanf:"secretAccessKey"`
+	Timeout         time.Duration `koanf:"timeout"`
+}
+
+// CoversConfig selects where uploaded covers are kept, store is "fs" for a local directory or "s3" for
+// any S3 compatible service. Leaving store empty disables uploads, zero maxBytes uses the book package default.
+type CoversConfig struct {
+	Store    string   `koanf:"store"`
+	Dir      string   `koanf:"dir"`
+	MaxBytes int64    `koanf:"maxBytes"`
+	S3       S3Config `koanf:"s3"`
+}
+
+// CoverCheckConfig controls the background check of book cover urls, zero values use the book package defaults.
+// Mirror copies healthy cover urls into the covers store, it needs a store configured under covers.
+type CoverCheckConfig struct {
+	Enabled           bool          `koanf:"enabled"`
+	Interval          time.Duration `koanf:"interval"`
+	RecheckAfter      time.Duration `koanf:"recheckAfter"`
+	Concurrency       int           `koanf:"concurrency"`
+	RequestsPerSecond int           `koanf:"requestsPerSecond"`
+	Timeout           time.Duration `koanf:"timeout"`
+	Mirror            bool          `koanf:"mirror"`
+}
+
+type UrlMatchConfig struct {
+	Type    string `koanf:"type"`
+	Pattern string `koanf:"pattern"`
+}
+
+// UrlRuleConfig is one rule of a url rule set, see url.Rule for what each action does.
+type UrlRuleConfig struct {
+	Name          string         `koanf:"name"`
+	Host          UrlMatchConfig `koanf:"host"`
+	Path          UrlMatchConfig `koanf:"path"`
+	ForceHTTPS    bool           `koanf:"forceHttps"`
+	SetHost       string         `koanf:"setHost"`
+	WWW           string         `koanf:"www"`
+	Query         string         `koanf:"query"`
+	QueryParams   []string       `koanf:"queryParams"`
+	DropFragment  bool           `koanf:"dropFragment"`
+	Lowercase     string         `koanf:"lowercase"`
+	TrailingSlash string         `koanf:"trailingSlash"`
+}
+
+// UrlDomainRuleConfig allows hosts by domain, type is "exact", "wildcard" or "registrable".
+type UrlDomainRuleConfig struct {
+	Name   string `koanf:"name"`
+	Type   string `koanf:"type"`
+	Domain string `koanf:"domain"`
+}
+
+// UrlBatchConfig limits POST /urls/batch, zero values use the handler defaults.
+type UrlBatchConfig struct {
+	MaxItems    int   `koanf:"maxItems"`
+	MaxBytes    int64 `koanf:"maxBytes"`
+	Concurrency int   `koanf:"concurrency"`
+}
+
+// UrlSitemapConfig limits POST /url/sitemap, zero values use the limits of the sitemap protocol.
+type UrlSitemapConfig struct {
+	MaxEntries int   `koanf:"maxEntries"`
+	MaxBytes   int64 `koanf:"maxBytes"`
+}
+
+// UrlConfig holds the rule sets POST /url selects by operation, each an ordered list of rules,
+// and the allowlist of hosts each operation accepts.
+type UrlConfig struct {
+	RuleSets   map[string][]UrlRuleConfig       `koanf:"ruleSets"`
+	Allowlists map[string][]UrlDomainRuleConfig `koanf:"allowlists"`
+	Batch      UrlBatchConfig                   `koanf:"batch"`
+	Sitemap    UrlSitemapConfig                 `koanf:"sitemap"`
 }
 
 type Config struct {
-	CorsOrigins       string         `koanf:"corsOrigins"`
-	ServerPort        string         `koanf:"serverPort"`
-	OtelTraceEndpoint string         `koanf:"otelTraceEndpoint"`
-	PostgresConfig    PostgresConfig `koanf:"postgresql"`
+	Environment       string           `koanf:"environment"`
+	CorsOrigins       string           `koanf:"corsOrigins"`
+	ServerPort        string           `koanf:"serverPort"`
+	OtelTraceEndpoint string           `koanf:"otelTraceEndpoint"`
+	PostgresConfig    PostgresConfig   `koanf:"postgresql"`
+	Pagination        PaginationConfig `koanf:"pagination"`
+	Auth              AuthConfig       `koanf:"auth"`
+	Retention         RetentionConfig  `koanf:"retention"`
+	Enrichment        EnrichmentConfig `koanf:"enrichment"`
+	Covers            CoversConfig     `koanf:"covers"`
+	CoverCheck        CoverCheckConfig `koanf:"coverCheck"`
+	Url               UrlConfig        `koanf:"url"`
+}
+
+// OverlayEnv names a config file merged over config/config.json, relative to the api directory
+// unless absolute. config/config.dev.json holds sample credentials for local development.
+const OverlayEnv = "BOOK_API_CONFIG"
+
+// CursorSecretEnv holds the pagination cursor secret, so it stays out of the config files.
+const CursorSecretEnv = "BOOK_API_CURSOR_SECRET"
+
+// Local reports whether the config is for local development or tests, which may run without secrets.
+func (c *Config) Local() bool {
+	return c.Environment == "local" || c.Environment == "test"
 }
 
 func Read() *Config {
@@ -35,6 +193,27 @@ func Read() *Config {
 		panic(fmt.Sprintf("error occurred while reading config: %s", err))
 	}
 
+	if overlay := os.Getenv(OverlayEnv); overlay != "" {
+		if !filepath.IsAbs(overlay) {
+			overlay = filepath.Join(rootDir, overlay)
+		}
+		if err := koanfInstance.Load(file.Provider(overlay), json.Parser()); err != nil {
+			panic(fmt.Sprintf("error occurred while reading config overlay: %s", err))
+		}
+	}
+
+	if secret := os.Getenv(CursorSecretEnv); secret != "" {
+		if err := koanfInstance.Set("pagination.cursorSecret", secret); err != nil {
+			panic(fmt.Sprintf("error occurred while reading %s: %s", CursorSecretEnv, err))
+		}
+	}
+
+	if !koanfInstance.Exists("auth.enabled") {
+		if err := koanfInstance.Set("auth.enabled", true); err != nil {
+			panic(fmt.Sprintf("error occurred while defaulting config: %s", err))
+		}
+	}
+
 	var config Config
 	if err := koanfInstance.Unmarshal("", &config); err != nil {
 		panic(fmt.Sprintf("error occurred while unmarshalling config: %s", err))
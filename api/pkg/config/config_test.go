@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,5 +11,40 @@ func TestConfig_Read(t *testing.T) {
 	assert.NotPanics(t, func() {
 		config := Read()
 		assert.NotNil(t, config)
+		assert.Equal(t, time.Hour, config.Retention.PurgeInterval)
+		assert.Equal(t, 3*time.Second, config.Enrichment.Timeout)
+		assert.Len(t, config.Url.RuleSets["all"], 2)
+		assert.Equal(t, "www.byfood.com", config.Url.RuleSets["all"][0].SetHost)
+		assert.Equal(t, "registrable", config.Url.Allowlists["all"][0].Type)
+		assert.Equal(t, 50000, config.Url.Batch.MaxItems)
+		assert.Equal(t, 50000, config.Url.Sitemap.MaxEntries)
+		assert.True(t, config.Auth.Enabled)
+		assert.Empty(t, config.Auth.JWT.HMACSecret)
+		assert.Empty(t, config.Auth.APIKeys)
+		assert.Empty(t, config.Pagination.CursorSecret)
+		assert.False(t, config.Local())
+	})
+}
+
+func TestConfig_Read_CursorSecretEnv(t *testing.T) {
+	t.Setenv(CursorSecretEnv, "cursor-secret-from-env")
+
+	assert.NotPanics(t, func() {
+		config := Read()
+		assert.Equal(t, "cursor-secret-from-env", config.Pagination.CursorSecret)
+	})
+}
+
+func TestConfig_Read_Overlay(t *testing.T) {
+	t.Setenv(OverlayEnv, "config/config.dev.json")
+
+	assert.NotPanics(t, func() {
+		config := Read()
+		assert.True(t, config.Auth.Enabled)
+		assert.Equal(t, "local-development-jwt-secret-not-for-production", config.Auth.JWT.HMACSecret)
+		assert.Equal(t, "roles", config.Auth.JWT.RolesClaim)
+		assert.Len(t, config.Auth.APIKeys, 3)
+		assert.True(t, config.Local())
+		assert.Equal(t, time.Hour, config.Retention.PurgeInterval)
 	})
 }
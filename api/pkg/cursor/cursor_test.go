@@ -0,0 +1,70 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	CreatedAt int64  `json:"c"`
+	Id        string `json:"i"`
+}
+
+var books = Scope{Kind: "books", Query: map[string]string{"search": "clean"}}
+
+func TestSigner(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		signer := NewSigner("secret")
+
+		token, err := signer.Encode(books, position{CreatedAt: 1700000000000000, Id: "7c9affa4-5c43-4cb4-a5fb-dcc291fbba59"})
+		require.NoError(t, err)
+
+		var decoded position
+		require.NoError(t, signer.Decode(token, books, &decoded))
+		assert.Equal(t, position{CreatedAt: 1700000000000000, Id: "7c9affa4-5c43-4cb4-a5fb-dcc291fbba59"}, decoded)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		signer := NewSigner("secret")
+
+		token, err := signer.Encode(books, position{CreatedAt: 1, Id: "a"})
+		require.NoError(t, err)
+
+		forged, err := signer.Encode(books, position{CreatedAt: 2, Id: "b"})
+		require.NoError(t, err)
+
+		payload, _, _ := strings.Cut(forged, ".")
+		_, signature, _ := strings.Cut(token, ".")
+
+		var decoded position
+		assert.ErrorIs(t, signer.Decode(payload+"."+signature, books, &decoded), ErrInvalidCursor)
+	})
+
+	t.Run("different secret", func(t *testing.T) {
+		token, err := NewSigner("secret").Encode(books, position{CreatedAt: 1, Id: "a"})
+		require.NoError(t, err)
+
+		var decoded position
+		assert.ErrorIs(t, NewSigner("other").Decode(token, books, &decoded), ErrInvalidCursor)
+	})
+
+	t.Run("malformed token", func(t *testing.T) {
+		var decoded position
+		assert.ErrorIs(t, NewSigner("").Decode("not-a-cursor", books, &decoded), ErrInvalidCursor)
+	})
+
+	t.Run("other scope", func(t *testing.T) {
+		signer := NewSigner("secret")
+
+		token, err := signer.Encode(books, position{CreatedAt: 1, Id: "a"})
+		require.NoError(t, err)
+
+		var decoded position
+		assert.ErrorIs(t, signer.Decode(token, Scope{Kind: "books", Query: map[string]string{"search": "code"}}, &decoded), ErrCursorMismatch)
+		assert.ErrorIs(t, signer.Decode(token, Scope{Kind: "authors", Query: books.Query}, &decoded), ErrCursorMismatch)
+		assert.ErrorIs(t, signer.Decode(token, Scope{Kind: "books"}, &decoded), ErrCursorMismatch)
+	})
+}
@@ -0,0 +1,91 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+// CircuitBreaker stops calling a provider after threshold consecutive failures. While open every lookup
+// fails fast with ErrCircuitOpen, once openDuration passed a single probe decides whether it closes again.
+// Unknown ISBNs and callers that gave up are not failures of the provider.
+type CircuitBreaker struct {
+	provider     MetadataProvider
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(provider MetadataProvider, threshold int, openDuration time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	if openDuration <= 0 {
+		openDuration = defaultOpenDuration
+	}
+
+	return &CircuitBreaker{
+		provider:     provider,
+		threshold:    threshold,
+		openDuration: openDuration,
+		now:          time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Lookup(ctx context.Context, isbn13 string) (*Metadata, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := b.provider.Lookup(ctx, isbn13)
+	b.record(probe, err)
+	return metadata, err
+}
+
+func (b *CircuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, nil
+	}
+
+	if b.probing || b.now().Before(b.openUntil) {
+		return false, ErrCircuitOpen.WithMeta("retryAfter", b.openUntil.UTC())
+	}
+
+	b.probing = true
+	return true, nil
+}
+
+func (b *CircuitBreaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case err == nil, errors.Is(err, ErrMetadataNotFound):
+		b.failures = 0
+	case errors.Is(err, context.Canceled):
+	default:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = b.now().Add(b.openDuration)
+		}
+	}
+}
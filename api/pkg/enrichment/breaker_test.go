@@ -0,0 +1,49 @@
+package enrichment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_Lookup(t *testing.T) {
+	var upstream error
+	provider := &countingProvider{fn: func(context.Context, string) (*Metadata, error) {
+		if upstream != nil {
+			return nil, upstream
+		}
+		return &Metadata{Title: "Clean Code"}, nil
+	}}
+	breaker := NewCircuitBreaker(provider, 3, time.Minute)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	lookup := func() error {
+		_, err := breaker.Lookup(context.Background(), "9780132350884")
+		return err
+	}
+
+	upstream = ErrMetadataNotFound
+	for range 5 {
+		assert.ErrorIs(t, lookup(), ErrMetadataNotFound)
+	}
+
+	upstream = ErrProviderTimeout
+	for range 3 {
+		assert.ErrorIs(t, lookup(), ErrProviderTimeout)
+	}
+	calls := provider.calls.Load()
+	assert.ErrorIs(t, lookup(), ErrCircuitOpen, "open after three failures")
+	assert.Equal(t, calls, provider.calls.Load(), "open circuit does not call the provider")
+
+	now = now.Add(time.Minute)
+	assert.ErrorIs(t, lookup(), ErrProviderTimeout, "failed probe")
+	assert.ErrorIs(t, lookup(), ErrCircuitOpen, "failed probe opens it again")
+
+	now = now.Add(time.Minute)
+	upstream = nil
+	assert.NoError(t, lookup(), "successful probe")
+	assert.NoError(t, lookup(), "closed again")
+}
@@ -0,0 +1,143 @@
+package enrichment
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheTTL         = 24 * time.Hour
+	defaultNegativeCacheTTL = time.Hour
+	defaultCacheSize        = 10_000
+)
+
+type CacheOptions struct {
+	TTL time.Duration
+	// NegativeTTL is how long an ISBN the catalogue does not know is answered without asking again
+	NegativeTTL time.Duration
+	Size        int
+}
+
+type cacheEntry struct {
+	isbn13    string
+	metadata  *Metadata
+	expiresAt time.Time
+}
+
+// Cache keeps the most recently used lookups and lets concurrent lookups of one ISBN share a single
+// upstream call. Failures are never cached, so a provider that recovers is asked again right away.
+type Cache struct {
+	provider    MetadataProvider
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	now         func() time.Time
+	group       singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	recency *list.List
+}
+
+func NewCache(provider MetadataProvider, options CacheOptions) *Cache {
+	cache := &Cache{
+		provider:    provider,
+		ttl:         options.TTL,
+		negativeTTL: options.NegativeTTL,
+		size:        options.Size,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		recency:     list.New(),
+	}
+	if cache.ttl <= 0 {
+		cache.ttl = defaultCacheTTL
+	}
+	if cache.negativeTTL <= 0 {
+		cache.negativeTTL = defaultNegativeCacheTTL
+	}
+	if cache.size <= 0 {
+		cache.size = defaultCacheSize
+	}
+
+	return cache
+}
+
+func (c *Cache) Lookup(ctx context.Context, isbn13 string) (*Metadata, error) {
+	if entry, ok := c.get(isbn13); ok {
+		if entry.metadata == nil {
+			return nil, ErrMetadataNotFound
+		}
+		return entry.metadata.clone(), nil
+	}
+
+	// the shared call outlives a caller that gives up, the provider bounds it with its own timeout
+	result := c.group.DoChan(isbn13, func() (any, error) {
+		return c.fetch(context.WithoutCancel(ctx), isbn13)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ErrProviderTimeout.WithCause(ctx.Err())
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Metadata).clone(), nil
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, isbn13 string) (*Metadata, error) {
+	metadata, err := c.provider.Lookup(ctx, isbn13)
+	switch {
+	case err == nil:
+		c.put(isbn13, metadata, c.ttl)
+	case errors.Is(err, ErrMetadataNotFound):
+		c.put(isbn13, nil, c.negativeTTL)
+	}
+
+	return metadata, err
+}
+
+func (c *Cache) get(isbn13 string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[isbn13]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.recency.Remove(element)
+		delete(c.entries, isbn13)
+		return nil, false
+	}
+
+	c.recency.MoveToFront(element)
+	return entry, true
+}
+
+func (c *Cache) put(isbn13 string, metadata *Metadata, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{isbn13: isbn13, metadata: metadata, expiresAt: c.now().Add(ttl)}
+	if element, ok := c.entries[isbn13]; ok {
+		element.Value = entry
+		c.recency.MoveToFront(element)
+		return
+	}
+
+	c.entries[isbn13] = c.recency.PushFront(entry)
+	for c.recency.Len() > c.size {
+		oldest := c.recency.Back()
+		c.recency.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).isbn13)
+	}
+}
@@ -0,0 +1,122 @@
+package enrichment
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider answers from fn and counts how often it was asked.
+type countingProvider struct {
+	calls atomic.Int32
+	fn    func(ctx context.Context, isbn13 string) (*Metadata, error)
+}
+
+func (p *countingProvider) Lookup(ctx context.Context, isbn13 string) (*Metadata, error) {
+	p.calls.Add(1)
+	return p.fn(ctx, isbn13)
+}
+
+func TestCache_Lookup(t *testing.T) {
+	found := func(ctx context.Context, isbn13 string) (*Metadata, error) {
+		if isbn13 == "9780000000002" {
+			return nil, ErrMetadataNotFound
+		}
+		return &Metadata{ISBN13: isbn13, Title: "Title " + isbn13}, nil
+	}
+
+	t.Run("hits and negative hits skip the provider until they expire", func(t *testing.T) {
+		provider := &countingProvider{fn: found}
+		cache := NewCache(provider, CacheOptions{TTL: time.Minute, NegativeTTL: time.Second})
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for range 3 {
+			metadata, err := cache.Lookup(context.Background(), "9780000000001")
+			require.NoError(t, err)
+			assert.Equal(t, "Title 9780000000001", metadata.Title)
+
+			_, err = cache.Lookup(context.Background(), "9780000000002")
+			assert.ErrorIs(t, err, ErrMetadataNotFound)
+		}
+		assert.Equal(t, int32(2), provider.calls.Load())
+
+		now = now.Add(2 * time.Second)
+		_, _ = cache.Lookup(context.Background(), "9780000000001")
+		_, _ = cache.Lookup(context.Background(), "9780000000002")
+		assert.Equal(t, int32(3), provider.calls.Load(), "only the negative entry expired")
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		provider := &countingProvider{fn: func(context.Context, string) (*Metadata, error) {
+			return nil, ErrProviderUnavailable
+		}}
+		cache := NewCache(provider, CacheOptions{})
+
+		for range 2 {
+			_, err := cache.Lookup(context.Background(), "9780000000001")
+			assert.ErrorIs(t, err, ErrProviderUnavailable)
+		}
+		assert.Equal(t, int32(2), provider.calls.Load())
+	})
+
+	t.Run("least recently used entry is evicted", func(t *testing.T) {
+		provider := &countingProvider{fn: found}
+		cache := NewCache(provider, CacheOptions{Size: 2})
+
+		for _, isbn13 := range []string{"9780000000001", "9780000000003", "9780000000001", "9780000000004"} {
+			_, err := cache.Lookup(context.Background(), isbn13)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), provider.calls.Load())
+
+		_, _ = cache.Lookup(context.Background(), "9780000000001")
+		assert.Equal(t, int32(3), provider.calls.Load())
+		_, _ = cache.Lookup(context.Background(), "9780000000003")
+		assert.Equal(t, int32(4), provider.calls.Load())
+	})
+
+	t.Run("concurrent lookups share one call", func(t *testing.T) {
+		release := make(chan struct{})
+		provider := &countingProvider{fn: func(ctx context.Context, isbn13 string) (*Metadata, error) {
+			<-release
+			return found(ctx, isbn13)
+		}}
+		cache := NewCache(provider, CacheOptions{})
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cache.Lookup(context.Background(), "9780000000001")
+				assert.NoError(t, err)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), provider.calls.Load())
+	})
+
+	t.Run("caller deadline does not wait for the provider", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		provider := &countingProvider{fn: func(ctx context.Context, isbn13 string) (*Metadata, error) {
+			<-release
+			return found(ctx, isbn13)
+		}}
+		cache := NewCache(provider, CacheOptions{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := cache.Lookup(ctx, "9780000000001")
+		assert.ErrorIs(t, err, ErrProviderTimeout)
+	})
+}
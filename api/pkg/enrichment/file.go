@@ -0,0 +1,64 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	json "github.com/bytedance/sonic"
+
+	"book-api/pkg/isbn"
+)
+
+// FileProvider answers from a JSON object of Metadata keyed by ISBN, for tests and offline development.
+type FileProvider struct {
+	records map[string]*Metadata
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records map[string]*Metadata
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return NewStaticProvider(records)
+}
+
+// NewStaticProvider is FileProvider over records already in memory, keys may be any ISBN form.
+func NewStaticProvider(records map[string]*Metadata) (*FileProvider, error) {
+	provider := &FileProvider{records: make(map[string]*Metadata, len(records))}
+	for key, metadata := range records {
+		if metadata == nil {
+			continue
+		}
+
+		isbn13, err := isbn.Normalize(key)
+		if err != nil {
+			return nil, fmt.Errorf("record %q: %w", key, err)
+		}
+
+		record := metadata.clone()
+		record.ISBN13 = isbn13
+		provider.records[isbn13] = record
+	}
+
+	return provider, nil
+}
+
+func (p *FileProvider) Lookup(ctx context.Context, isbn13 string) (*Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, ErrProviderUnavailable.WithCause(err)
+	}
+
+	metadata, ok := p.records[isbn13]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	return metadata.clone(), nil
+}
@@ -0,0 +1,46 @@
+package enrichment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	provider, err := NewFileProvider("testdata/metadata.json")
+	require.NoError(t, err)
+
+	t.Run("keys are normalized", func(t *testing.T) {
+		metadata, err := provider.Lookup(context.Background(), "9780132350884")
+		require.NoError(t, err)
+		assert.Equal(t, "9780132350884", metadata.ISBN13)
+		assert.Equal(t, "Clean Code", metadata.Title)
+	})
+
+	t.Run("lookups return copies", func(t *testing.T) {
+		metadata, err := provider.Lookup(context.Background(), "9780061120084")
+		require.NoError(t, err)
+		metadata.Authors[0] = "changed"
+
+		metadata, err = provider.Lookup(context.Background(), "9780061120084")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Harper Lee"}, metadata.Authors)
+	})
+
+	t.Run("unknown isbn", func(t *testing.T) {
+		_, err := provider.Lookup(context.Background(), "9780201485677")
+		assert.ErrorIs(t, err, ErrMetadataNotFound)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewStaticProvider(map[string]*Metadata{"123": {Title: "Nope"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewFileProvider("testdata/missing.json")
+		assert.Error(t, err)
+	})
+}
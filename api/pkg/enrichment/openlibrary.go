@@ -0,0 +1,144 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+)
+
+const (
+	DefaultOpenLibraryURL = "https://openlibrary.org"
+	defaultTimeout        = 3 * time.Second
+	// maxResponseBytes bounds a single record, Open Library answers a one ISBN query in a few kilobytes
+	maxResponseBytes = 1 << 20
+)
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+type openLibraryRecord struct {
+	Title   string `json:"title"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Publishers []struct {
+		Name string `json:"name"`
+	} `json:"publishers"`
+	PublishDate string `json:"publish_date"`
+	Cover       struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+}
+
+// OpenLibraryProvider reads the Open Library books API, any service answering
+// /api/books?bibkeys=ISBN:...&jscmd=data in the same shape works as base URL.
+type OpenLibraryProvider struct {
+	client  *http.Client
+	baseURL string
+	timeout time.Duration
+}
+
+func NewOpenLibraryProvider(baseURL string, timeout time.Duration) *OpenLibraryProvider {
+	if baseURL == "" {
+		baseURL = DefaultOpenLibraryURL
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &OpenLibraryProvider{
+		client:  &http.Client{Timeout: timeout},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		timeout: timeout,
+	}
+}
+
+func (p *OpenLibraryProvider) Lookup(ctx context.Context, isbn13 string) (*Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	bibKey := "ISBN:" + isbn13
+	query := url.Values{"bibkeys": {bibKey}, "format": {"json"}, "jscmd": {"data"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/books?"+query.Encode(), nil)
+	if err != nil {
+		return nil, ErrProviderUnavailable.WithCause(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "book-api")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, p.failure(ctx, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrMetadataNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, ErrProviderUnavailable.WithCause(fmt.Errorf("unexpected status %d", res.StatusCode)).
+			WithMeta("status", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	if err != nil {
+		return nil, p.failure(ctx, err)
+	}
+
+	var records map[string]openLibraryRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return nil, ErrProviderUnavailable.WithCause(err)
+	}
+
+	record, ok := records[bibKey]
+	if !ok {
+		return nil, ErrMetadataNotFound
+	}
+
+	return record.metadata(isbn13), nil
+}
+
+func (p *OpenLibraryProvider) failure(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrProviderTimeout.WithCause(err)
+	}
+
+	return ErrProviderUnavailable.WithCause(err)
+}
+
+// metadata keeps the first four digit year of publish_date, which is free text such as "October 1, 1988".
+func (r openLibraryRecord) metadata(isbn13 string) *Metadata {
+	metadata := &Metadata{
+		ISBN13:          isbn13,
+		Title:           strings.TrimSpace(r.Title),
+		PublicationYear: yearPattern.FindString(r.PublishDate),
+	}
+	for _, author := range r.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			metadata.Authors = append(metadata.Authors, name)
+		}
+	}
+	for _, publisher := range r.Publishers {
+		if name := strings.TrimSpace(publisher.Name); name != "" {
+			metadata.Publishers = append(metadata.Publishers, name)
+		}
+	}
+
+	for _, cover := range []string{r.Cover.Large, r.Cover.Medium, r.Cover.Small} {
+		if cover != "" {
+			metadata.CoverUrl = cover
+			break
+		}
+	}
+
+	return metadata
+}
@@ -0,0 +1,78 @@
+package enrichment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenLibraryProvider_Lookup(t *testing.T) {
+	t.Run("maps the data record", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/books", r.URL.Path)
+			assert.Equal(t, "ISBN:9780140328721", r.URL.Query().Get("bibkeys"))
+			assert.Equal(t, "data", r.URL.Query().Get("jscmd"))
+			_, _ = w.Write([]byte(`{"ISBN:9780140328721": {
+				"title": "Fantastic Mr Fox",
+				"authors": [{"name": "Roald Dahl"}, {"name": " "}],
+				"publishers": [{"name": "Puffin"}],
+				"publish_date": "October 1, 1988",
+				"cover": {"small": "https://covers.example/s.jpg", "medium": "https://covers.example/m.jpg"}
+			}}`))
+		}))
+		defer server.Close()
+
+		metadata, err := NewOpenLibraryProvider(server.URL+"/", time.Second).Lookup(context.Background(), "9780140328721")
+		require.NoError(t, err)
+		assert.Equal(t, &Metadata{
+			ISBN13:          "9780140328721",
+			Title:           "Fantastic Mr Fox",
+			Authors:         []string{"Roald Dahl"},
+			Publishers:      []string{"Puffin"},
+			PublicationYear: "1988",
+			CoverUrl:        "https://covers.example/m.jpg",
+		}, metadata)
+	})
+
+	t.Run("unknown isbn", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		_, err := NewOpenLibraryProvider(server.URL, time.Second).Lookup(context.Background(), "9780140328721")
+		assert.ErrorIs(t, err, ErrMetadataNotFound)
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := NewOpenLibraryProvider(server.URL, time.Second).Lookup(context.Background(), "9780140328721")
+		assert.ErrorIs(t, err, ErrProviderUnavailable)
+	})
+
+	t.Run("slow upstream times out", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		start := time.Now()
+		_, err := NewOpenLibraryProvider(server.URL, 50*time.Millisecond).Lookup(context.Background(), "9780140328721")
+		assert.ErrorIs(t, err, ErrProviderTimeout)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,38 @@
+package enrichment
+
+import (
+	"context"
+	"slices"
+
+	"book-api/pkg/apperror"
+)
+
+var (
+	ErrMetadataNotFound    = apperror.NotFound("METADATA_NOT_FOUND", "no catalogue record exists for this ISBN")
+	ErrProviderUnavailable = apperror.Unavailable("METADATA_PROVIDER_UNAVAILABLE", "metadata provider is unavailable")
+	ErrProviderTimeout     = apperror.Unavailable("METADATA_PROVIDER_TIMEOUT", "metadata provider did not answer in time")
+	ErrCircuitOpen         = apperror.Unavailable("METADATA_PROVIDER_CIRCUIT_OPEN", "metadata provider failed repeatedly and is paused")
+)
+
+// Metadata is what an external catalogue knows about an edition, fields it does not know stay empty.
+type Metadata struct {
+	ISBN13          string   `json:"isbn13"`
+	Title           string   `json:"title"`
+	Authors         []string `json:"authors,omitempty"`
+	Publishers      []string `json:"publishers,omitempty"`
+	PublicationYear string   `json:"publicationYear,omitempty"`
+	CoverUrl        string   `json:"coverUrl,omitempty"`
+}
+
+func (m *Metadata) clone() *Metadata {
+	clone := *m
+	clone.Authors = slices.Clone(m.Authors)
+	clone.Publishers = slices.Clone(m.Publishers)
+	return &clone
+}
+
+// MetadataProvider looks an edition up by its canonical ISBN-13. It returns ErrMetadataNotFound when the
+// catalogue has no record, every other error means the lookup itself failed.
+type MetadataProvider interface {
+	Lookup(ctx context.Context, isbn13 string) (*Metadata, error)
+}
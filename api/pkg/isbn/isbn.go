@@ -0,0 +1,131 @@
+// Package isbn is the authoritative ISBN check of the API. Request bodies are validated with it through the
+// isbn tag installed by RegisterValidation, go-playground's own isbn check is not used, and book_isbn13 in
+// migration 0007 mirrors Normalize in SQL. When the two disagree, Normalize is right and the SQL follows.
+package isbn
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrInvalidLength    = errors.New("isbn must have 10 or 13 digits")
+	ErrInvalidCharacter = errors.New("isbn may only contain digits, hyphens, spaces and a trailing X on ISBN-10")
+	ErrInvalidChecksum  = errors.New("isbn check digit does not match")
+	ErrInvalidPrefix    = errors.New("isbn-13 must start with 978 or 979")
+	ErrNoISBN10         = errors.New("only ISBN-13 starting with 978 have an ISBN-10 form")
+)
+
+// Normalize returns the canonical ISBN-13 of an ISBN-10 or ISBN-13 written with or without
+// hyphens and spaces, so "0-06-112008-1", "978-0-06-112008-4" and "9780061120084" all compare equal.
+func Normalize(isbn string) (string, error) {
+	digits, err := clean(isbn)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(digits) {
+	case 10:
+		if checkDigit10(digits[:9]) != digits[9] {
+			return "", ErrInvalidChecksum
+		}
+		prefixed := "978" + digits[:9]
+		return prefixed + string(checkDigit13(prefixed)), nil
+	case 13:
+		if strings.ContainsRune(digits, 'X') {
+			return "", ErrInvalidCharacter
+		}
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", ErrInvalidPrefix
+		}
+		if checkDigit13(digits[:12]) != digits[12] {
+			return "", ErrInvalidChecksum
+		}
+		return digits, nil
+	}
+
+	return "", ErrInvalidLength
+}
+
+// Valid reports whether Normalize accepts isbn.
+func Valid(isbn string) bool {
+	_, err := Normalize(isbn)
+	return err == nil
+}
+
+// RegisterValidation makes the isbn tag of v accept what Valid accepts, any hyphens and spaces and a
+// lower case x included, instead of go-playground's stricter regular expressions.
+func RegisterValidation(v *validator.Validate) error {
+	return v.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		return Valid(fl.Field().String())
+	})
+}
+
+// ToISBN10 converts back to the ten digit form, which only exists for the 978 prefix.
+func ToISBN10(isbn string) (string, error) {
+	isbn13, err := Normalize(isbn)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(isbn13, "978") {
+		return "", ErrNoISBN10
+	}
+
+	return isbn13[3:12] + string(checkDigit10(isbn13[3:12])), nil
+}
+
+// clean drops separators and upper cases the X check digit, every other rune must be a digit.
+func clean(isbn string) (string, error) {
+	isbn = strings.TrimSpace(isbn)
+
+	var builder strings.Builder
+	builder.Grow(13)
+	for i, r := range isbn {
+		switch {
+		case r >= '0' && r <= '9':
+			builder.WriteRune(r)
+		case r == 'x' || r == 'X':
+			if i != len(isbn)-1 {
+				return "", ErrInvalidCharacter
+			}
+			builder.WriteByte('X')
+		case r == '-' || r == ' ':
+		default:
+			return "", ErrInvalidCharacter
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// checkDigit10 weighs the nine digits 10 down to 2, the check digit makes the sum divisible by 11.
+func checkDigit10(digits string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+
+	return byte('0' + check)
+}
+
+// checkDigit13 weighs the twelve digits alternately 1 and 3, the check digit makes the sum divisible by 10.
+func checkDigit13(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
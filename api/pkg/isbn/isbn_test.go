@@ -0,0 +1,64 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name     string
+		isbn     string
+		expected string
+		err      error
+	}{
+		{name: "isbn-13", isbn: "9780061120084", expected: "9780061120084"},
+		{name: "hyphenated isbn-13", isbn: "978-0-06-112008-4", expected: "9780061120084"},
+		{name: "isbn-10", isbn: "0-06-112008-1", expected: "9780061120084"},
+		{name: "isbn-10 with x check digit", isbn: "0-8044-2957-x", expected: "9780804429573"},
+		{name: "979 prefix", isbn: "979-10-90636-07-1", expected: "9791090636071"},
+		{name: "spaces", isbn: " 978 0 13 235088 4 ", expected: "9780132350884"},
+		{name: "bad isbn-13 checksum", isbn: "9780061120085", err: ErrInvalidChecksum},
+		{name: "bad isbn-10 checksum", isbn: "0061120082", err: ErrInvalidChecksum},
+		{name: "not a bookland prefix", isbn: "4006381333931", err: ErrInvalidPrefix},
+		{name: "too short", isbn: "978006112008", err: ErrInvalidLength},
+		{name: "x inside", isbn: "00611X0081", err: ErrInvalidCharacter},
+		{name: "x on isbn-13", isbn: "978006112008X", err: ErrInvalidCharacter},
+		{name: "letters", isbn: "ISBN 9780061120084", err: ErrInvalidCharacter},
+		{name: "empty", isbn: "", err: ErrInvalidLength},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			normalized, err := Normalize(c.isbn)
+
+			assert.ErrorIs(t, err, c.err)
+			assert.Equal(t, c.expected, normalized)
+			assert.Equal(t, c.err == nil, Valid(c.isbn))
+		})
+	}
+}
+
+func TestToISBN10(t *testing.T) {
+	isbn10, err := ToISBN10("978-0-8044-2957-3")
+	assert.NoError(t, err)
+	assert.Equal(t, "080442957X", isbn10)
+
+	isbn13, err := Normalize(isbn10)
+	assert.NoError(t, err)
+	assert.Equal(t, "9780804429573", isbn13)
+
+	_, err = ToISBN10("9791090636071")
+	assert.ErrorIs(t, err, ErrNoISBN10)
+}
+
+func TestRegisterValidation(t *testing.T) {
+	validate := validator.New()
+	assert.NoError(t, RegisterValidation(validate))
+
+	assert.NoError(t, validate.Var("9-7-8-0-0-6-1-1-2-0-0-8-4", "isbn"))
+	assert.NoError(t, validate.Var("080442957x", "isbn"))
+	assert.Error(t, validate.Var("9780061120085", "isbn"))
+	assert.Error(t, validate.Var("4006381333931", "isbn"))
+}
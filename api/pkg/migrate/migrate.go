@@ -0,0 +1,349 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// lockKey is an arbitrary constant shared by every runner so only one of them migrates at a time.
+const lockKey int64 = 7_318_202_417
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	connectionPool *pgxpool.Pool
+	migrations     []Migration
+}
+
+func New(connectionPool *pgxpool.Pool, source fs.FS) (*Migrator, error) {
+	migrations, err := Load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		connectionPool: connectionPool,
+		migrations:     migrations,
+	}, nil
+}
+
+func Load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(connection *pgxpool.Conn) error {
+		current, err := currentVersion(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		if current == 0 {
+			return nil
+		}
+
+		target := 0
+		for _, migration := range m.migrations {
+			if migration.Version < current {
+				target = migration.Version
+			}
+		}
+
+		return m.migrate(ctx, connection, current, target)
+	})
+}
+
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target != 0 && !m.has(target) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	return m.withLock(ctx, func(connection *pgxpool.Conn) error {
+		current, err := currentVersion(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		return m.migrate(ctx, connection, current, target)
+	})
+}
+
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	connection, err := m.connectionPool.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer connection.Release()
+
+	return currentVersion(ctx, connection)
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	connection, err := m.connectionPool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer connection.Release()
+
+	if err = ensureTable(ctx, connection); err != nil {
+		return nil, err
+	}
+
+	rows, err := connection.Query(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	appliedAt := make(map[int]time.Time)
+	var (
+		version   int
+		timestamp time.Time
+	)
+	if _, err = pgx.ForEachRow(rows, []any{&version, &timestamp}, func() error {
+		appliedAt[version] = timestamp
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if timestamp, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &timestamp
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) has(version int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Migrator) migrate(ctx context.Context, connection *pgxpool.Conn, current, target int) error {
+	if target >= current {
+		for _, migration := range m.migrations {
+			if migration.Version <= current || migration.Version > target {
+				continue
+			}
+
+			if err := apply(ctx, connection, migration, migration.Up, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		if migration.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+
+		if err := apply(ctx, connection, migration, migration.Down, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(connection *pgxpool.Conn) error) error {
+	connection, err := m.connectionPool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer connection.Release()
+
+	if _, err = connection.Exec(ctx, "select pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := connection.Exec(context.Background(), "select pg_advisory_unlock($1)", lockKey); err != nil {
+			zap.L().Error("failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	if err = ensureTable(ctx, connection); err != nil {
+		return err
+	}
+
+	return fn(connection)
+}
+
+func apply(ctx context.Context, connection *pgxpool.Conn, migration Migration, script string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+
+	err := pgx.BeginFunc(ctx, connection, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+
+		if up {
+			_, err := tx.Exec(
+				ctx,
+				"insert into schema_migrations (version, name, applied_at) values ($1, $2, $3)",
+				migration.Version,
+				migration.Name,
+				time.Now().UTC(),
+			)
+			return err
+		}
+
+		_, err := tx.Exec(ctx, "delete from schema_migrations where version = $1", migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s %d_%s: %w", direction, migration.Version, migration.Name, err)
+	}
+
+	zap.L().Info(
+		"migration applied",
+		zap.String("direction", direction),
+		zap.Int("version", migration.Version),
+		zap.String("name", migration.Name),
+	)
+
+	return nil
+}
+
+func ensureTable(ctx context.Context, connection *pgxpool.Conn) error {
+	if _, err := connection.Exec(
+		ctx,
+		`create table if not exists schema_migrations (
+			version bigint primary key,
+			name varchar not null,
+			applied_at timestamp with time zone not null default now()
+		)`,
+	); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+func currentVersion(ctx context.Context, connection *pgxpool.Conn) (int, error) {
+	var exists bool
+	if err := connection.
+		QueryRow(ctx, "select to_regclass('schema_migrations') is not null").
+		Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := connection.
+		QueryRow(ctx, "select coalesce(max(version), 0) from schema_migrations").
+		Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return version, nil
+}
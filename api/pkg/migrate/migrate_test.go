@@ -0,0 +1,114 @@
+//go:build !pact
+// +build !pact
+
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		migrations, err := Load(fstest.MapFS{
+			"0002_add_index.up.sql":      {Data: []byte("create index books_title_idx on books (title);")},
+			"0002_add_index.down.sql":    {Data: []byte("drop index books_title_idx;")},
+			"0001_create_books.up.sql":   {Data: []byte("create table books (id uuid);")},
+			"0001_create_books.down.sql": {Data: []byte("drop table books;")},
+			"README.md":                  {Data: []byte("ignored")},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "create_books", migrations[0].Name)
+		assert.Equal(t, "drop table books;", migrations[0].Down)
+		assert.Equal(t, 2, migrations[1].Version)
+	})
+
+	t.Run("missing up script", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"0001_create_books.down.sql": {Data: []byte("drop table books;")},
+		})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("conflicting names", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"0001_create_books.up.sql":    {Data: []byte("create table books (id uuid);")},
+			"0001_create_titles.down.sql": {Data: []byte("drop table books;")},
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	source := fstest.MapFS{
+		"0001_create_books.up.sql":   {Data: []byte("create table books (id uuid primary key);")},
+		"0001_create_books.down.sql": {Data: []byte("drop table books;")},
+		"0002_add_title.up.sql":      {Data: []byte("alter table books add column title varchar;")},
+		"0002_add_title.down.sql":    {Data: []byte("alter table books drop column title;")},
+	}
+
+	ctx := context.Background()
+	migrator, err := New(setupPool(t), source)
+	require.NoError(t, err)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	require.NoError(t, migrator.Up(ctx))
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	require.NoError(t, migrator.Down(ctx))
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+
+	require.NoError(t, migrator.To(ctx, 0))
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	assert.ErrorIs(t, migrator.To(ctx, 3), ErrUnknownVersion)
+}
+
+func setupPool(t *testing.T) *pgxpool.Pool {
+	ctx := context.Background()
+	postgresContainer, err := postgres.Run(
+		ctx,
+		"postgres:alpine",
+		postgres.WithDatabase("test"),
+		postgres.WithUsername("root"),
+		postgres.WithPassword("root"),
+		postgres.BasicWaitStrategies(),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = postgresContainer.Terminate(ctx)
+		require.NoError(t, err)
+	})
+
+	connectionString, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	pool, err := pgxpool.New(ctx, connectionString)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
@@ -0,0 +1,385 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidDocument  = errors.New("document is not valid json")
+	ErrInvalidPatch     = errors.New("patch is not valid json")
+	ErrUnknownOperation = errors.New("op must be add, remove, replace, move, copy or test")
+	ErrMissingField     = errors.New("operation is missing a member it requires")
+	ErrInvalidPointer   = errors.New("json pointer must be empty or start with / and escape ~ as ~0")
+	ErrPathNotFound     = errors.New("json pointer does not name a value of the document")
+	ErrInvalidIndex     = errors.New("array index must be a number without leading zeros in range, or - to append")
+	ErrMoveIntoChild    = errors.New("a value cannot be moved into one of its own children")
+	ErrTestFailed       = errors.New("test operation found a different value")
+)
+
+// Merge applies an RFC 7396 merge patch to doc. Objects in the patch are merged member by member,
+// a null member removes it and every other value replaces what doc holds.
+func Merge(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+
+	return object
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies an RFC 6902 JSON Patch to doc. Operations run in order and the first one that fails
+// fails the whole patch, its error names the operation by index.
+func Apply(doc, patch []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	var operations []operation
+	if err = json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range operations {
+		if root, err = op.apply(root); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func (o operation) apply(root any) (any, error) {
+	if o.Path == nil {
+		return nil, fmt.Errorf("%w: path", ErrMissingField)
+	}
+
+	path, err := parsePointer(*o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return nil, fmt.Errorf("%w: value", ErrMissingField)
+		}
+
+		value, err := decode(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch o.Op {
+		case "add":
+			return update(root, path, addTo(value))
+		case "replace":
+			return update(root, path, replaceIn(value))
+		}
+
+		current, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w at %q", ErrTestFailed, *o.Path)
+		}
+		return root, nil
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("%w: the whole document cannot be removed", ErrPathNotFound)
+		}
+		return update(root, path, removeFrom)
+	case "move", "copy":
+		if o.From == nil {
+			return nil, fmt.Errorf("%w: from", ErrMissingField)
+		}
+
+		from, err := parsePointer(*o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "copy" {
+			return update(root, path, addTo(clone(value)))
+		}
+
+		if slices.Equal(from, path) {
+			return root, nil
+		}
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, ErrMoveIntoChild
+		}
+
+		if root, err = update(root, from, removeFrom); err != nil {
+			return nil, err
+		}
+		return update(root, path, addTo(value))
+	}
+
+	return nil, fmt.Errorf("%w, got %q", ErrUnknownOperation, o.Op)
+}
+
+// parsePointer splits an RFC 6901 pointer into its unescaped reference tokens, "" is the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w, got %q", ErrInvalidPointer, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, fmt.Errorf("%w, got %q", ErrInvalidPointer, pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// update hands the container holding the value at path to leaf and stores what leaf returns in its place,
+// containers are rebuilt on the way back up because inserting into an array may move it.
+func update(node any, path []string, leaf func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 0 {
+		// the whole document is handed over as the only item of an array, add and replace both leave leaf's value there
+		wrapped, err := leaf([]any{node}, "0")
+		if err != nil {
+			return nil, err
+		}
+		return wrapped.([]any)[0], nil
+	}
+	if len(path) == 1 {
+		return leaf(node, path[0])
+	}
+
+	child, err := get(node, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := update(child, path[1:], leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	return replaceIn(updated)(node, path[0])
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: no member %q", ErrPathNotFound, token)
+			}
+			node = value
+		case []any:
+			i, err := index(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is below a value that is neither an object nor an array", ErrPathNotFound, token)
+		}
+	}
+
+	return node, nil
+}
+
+func addTo(value any) func(container any, token string) (any, error) {
+	return func(container any, token string) (any, error) {
+		switch container := container.(type) {
+		case map[string]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			if token == "-" {
+				return append(container, value), nil
+			}
+
+			i, err := index(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(container, i, value), nil
+		}
+
+		return nil, fmt.Errorf("%w: %q is below a value that is neither an object nor an array", ErrPathNotFound, token)
+	}
+}
+
+func replaceIn(value any) func(container any, token string) (any, error) {
+	return func(container any, token string) (any, error) {
+		switch container := container.(type) {
+		case map[string]any:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("%w: no member %q", ErrPathNotFound, token)
+			}
+			container[token] = value
+			return container, nil
+		case []any:
+			i, err := index(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			container[i] = value
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("%w: %q is below a value that is neither an object nor an array", ErrPathNotFound, token)
+	}
+}
+
+func removeFrom(container any, token string) (any, error) {
+	switch container := container.(type) {
+	case map[string]any:
+		if _, ok := container[token]; !ok {
+			return nil, fmt.Errorf("%w: no member %q", ErrPathNotFound, token)
+		}
+		delete(container, token)
+		return container, nil
+	case []any:
+		i, err := index(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Delete(container, i, i+1), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q is below a value that is neither an object nor an array", ErrPathNotFound, token)
+}
+
+// index parses an array index token that may be at most last.
+func index(token string, last int) (int, error) {
+	if token == "" || len(token) > 1 && token[0] == '0' || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w, got %q", ErrInvalidIndex, token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > last {
+		return 0, fmt.Errorf("%w, got %q", ErrInvalidIndex, token)
+	}
+
+	return i, nil
+}
+
+// equal compares decoded values as RFC 6902 test does, numbers by value and objects ignoring member order.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	}
+
+	return a == b
+}
+
+func clone(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for name, member := range value {
+			copied[name] = clone(member)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, item := range value {
+			copied[i] = clone(item)
+		}
+		return copied
+	}
+
+	return value
+}
+
+// decode keeps numbers as written, so a patch never rounds a value it does not touch.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the json value")
+	}
+
+	return value, nil
+}
@@ -0,0 +1,88 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      error
+	}{
+		{name: "replaces a member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "adds a member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{name: "null removes a member", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{name: "arrays are replaced", doc: `{"a":["b"]}`, patch: `{"a":["c","d"]}`, expected: `{"a":["c","d"]}`},
+		{name: "objects merge deeply", doc: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"b":null,"f":1}}`, expected: `{"a":{"d":"e","f":1}}`},
+		{name: "object replaces a scalar", doc: `{"a":"b"}`, patch: `{"a":{"c":null,"d":2}}`, expected: `{"a":{"d":2}}`},
+		{name: "non object patch replaces the document", doc: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{name: "numbers are kept as written", doc: `{"a":12345678901234567890}`, patch: `{}`, expected: `{"a":12345678901234567890}`},
+		{name: "invalid patch", doc: `{}`, patch: `{"a":`, err: ErrInvalidPatch},
+		{name: "trailing data", doc: `{}`, patch: `{} {}`, err: ErrInvalidPatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			merged, err := Merge([]byte(c.doc), []byte(c.patch))
+
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.JSONEq(t, c.expected, string(merged))
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      error
+	}{
+		{name: "add member", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, expected: `{"baz":"qux","foo":"bar"}`},
+		{name: "add array item", doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, expected: `{"foo":["bar","qux","baz"]}`},
+		{name: "append array item", doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, expected: `{"foo":["bar",["abc","def"]]}`},
+		{name: "add null", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":null}]`, expected: `{"foo":"bar","baz":null}`},
+		{name: "remove member", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, expected: `{"foo":"bar"}`},
+		{name: "remove array item", doc: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, expected: `{"foo":["bar","baz"]}`},
+		{name: "replace", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, expected: `{"baz":"boo","foo":"bar"}`},
+		{name: "replace document", doc: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"","value":[1]}]`, expected: `[1]`},
+		{name: "move member", doc: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{name: "move array item", doc: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, expected: `{"foo":["all","cows","eat","grass"]}`},
+		{name: "copy is independent", doc: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, expected: `{"a":{"b":1},"c":{"b":2}}`},
+		{name: "test passes", doc: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, expected: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{name: "test ignores member order", doc: `{"a":{"b":1,"c":2}}`, patch: `[{"op":"test","path":"/a","value":{"c":2,"b":1}}]`, expected: `{"a":{"b":1,"c":2}}`},
+		{name: "escaped pointer", doc: `{"/":9,"~1":10}`, patch: `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, expected: `{"~1":10}`},
+		{name: "test fails", doc: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, err: ErrTestFailed},
+		{name: "test string against number", doc: `{"a":"1"}`, patch: `[{"op":"test","path":"/a","value":1}]`, err: ErrTestFailed},
+		{name: "later operation fails the patch", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2},{"op":"remove","path":"/c"}]`, err: ErrPathNotFound},
+		{name: "add below missing parent", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, err: ErrPathNotFound},
+		{name: "add below null", doc: `{"a":null}`, patch: `[{"op":"add","path":"/a/b","value":1}]`, err: ErrPathNotFound},
+		{name: "replace missing member", doc: `{}`, patch: `[{"op":"replace","path":"/a","value":1}]`, err: ErrPathNotFound},
+		{name: "index out of range", doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/2","value":"x"}]`, err: ErrInvalidIndex},
+		{name: "index with leading zero", doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"remove","path":"/foo/01"}]`, err: ErrInvalidIndex},
+		{name: "move into own child", doc: `{"a":{"b":{}}}`, patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`, err: ErrMoveIntoChild},
+		{name: "missing value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, err: ErrMissingField},
+		{name: "missing from", doc: `{}`, patch: `[{"op":"copy","path":"/a"}]`, err: ErrMissingField},
+		{name: "unknown op", doc: `{}`, patch: `[{"op":"merge","path":"/a","value":1}]`, err: ErrUnknownOperation},
+		{name: "pointer without slash", doc: `{}`, patch: `[{"op":"add","path":"a","value":1}]`, err: ErrInvalidPointer},
+		{name: "bad escape", doc: `{}`, patch: `[{"op":"add","path":"/a~2","value":1}]`, err: ErrInvalidPointer},
+		{name: "not an array", doc: `{}`, patch: `{"op":"add","path":"/a","value":1}`, err: ErrInvalidPatch},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			patched, err := Apply([]byte(c.doc), []byte(c.patch))
+
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.JSONEq(t, c.expected, string(patched))
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrForbiddenAddress  = errors.New("address is not public")
+	ErrUnsupportedScheme = errors.New("only http and https urls are fetched")
+	ErrTooManyRedirects  = errors.New("too many redirects")
+)
+
+// nonPublicPrefixes are the ranges netip has no predicate for that must not be dialed either.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// NewClient returns a client for urls taken from users. Every connection, redirects included, is checked
+// after DNS resolution and refused unless it goes to a public address, so a name pointing at an internal
+// host doesn't get through. Only http and https are requested and at most maxRedirects redirects followed.
+// Proxies from the environment are not used, they would dial the target on the client's behalf.
+func NewClient(timeout time.Duration, maxRedirects int) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: schemeChecker{transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+// Public reports whether addr is a public unicast address.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// control runs once the address is resolved, right before each connection is made.
+func control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+
+	return nil
+}
+
+type schemeChecker struct {
+	next http.RoundTripper
+}
+
+func (s schemeChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, req.URL.Scheme)
+	}
+
+	return s.next.RoundTrip(req)
+}
@@ -0,0 +1,64 @@
+package safehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublic(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"224.0.0.1":       false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	}
+	for address, expected := range cases {
+		assert.Equal(t, expected, Public(netip.MustParseAddr(address)), address)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, 2)
+
+	t.Run("loopback is refused", func(t *testing.T) {
+		_, err := client.Get(server.URL)
+		assert.ErrorIs(t, err, ErrForbiddenAddress)
+		assert.Zero(t, requests)
+	})
+
+	t.Run("only http and https", func(t *testing.T) {
+		_, err := client.Get("ftp://example.com/cover.jpg")
+		assert.ErrorIs(t, err, ErrUnsupportedScheme)
+	})
+
+	t.Run("redirects are limited", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://example.com", nil)
+		require.NoError(t, err)
+
+		assert.NoError(t, client.CheckRedirect(req, make([]*http.Request, 2)))
+		assert.ErrorIs(t, client.CheckRedirect(req, make([]*http.Request, 3)), ErrTooManyRedirects)
+	})
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileSystemStore keeps blobs as files below a root directory, keys map onto relative paths.
+type FileSystemStore struct {
+	root string
+}
+
+func NewFileSystemStore(root string) (*FileSystemStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSystemStore{root: root}, nil
+}
+
+// Put writes to a temporary file next to the target and renames it into place.
+func (s *FileSystemStore) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return ErrStoreUnavailable.WithCause(err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), ".blob-*")
+	if err != nil {
+		return ErrStoreUnavailable.WithCause(err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = io.Copy(file, body); err != nil {
+		file.Close()
+		return ErrStoreUnavailable.WithCause(err)
+	}
+
+	if err = file.Close(); err != nil {
+		return ErrStoreUnavailable.WithCause(err)
+	}
+
+	if err = os.Rename(file.Name(), target); err != nil {
+		return ErrStoreUnavailable.WithCause(err)
+	}
+
+	return nil
+}
+
+func (s *FileSystemStore) Get(_ context.Context, key string) (*Blob, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(s.root, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound.WithCause(err)
+	}
+	if err != nil {
+		return nil, ErrStoreUnavailable.WithCause(err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, ErrStoreUnavailable.WithCause(err)
+	}
+
+	return &Blob{Body: file, Size: info.Size()}, nil
+}
+
+func (s *FileSystemStore) Delete(_ context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(s.root, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ErrStoreUnavailable.WithCause(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSystemStore(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		store, err := NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+		ctx := context.TODO()
+
+		require.NoError(t, store.Put(ctx, "covers/1/small", strings.NewReader("first"), 5, "image/jpeg"))
+		require.NoError(t, store.Put(ctx, "covers/1/small", strings.NewReader("second"), 6, "image/jpeg"))
+
+		blob, err := store.Get(ctx, "covers/1/small")
+		require.NoError(t, err)
+		content, err := io.ReadAll(blob.Body)
+		require.NoError(t, err)
+		require.NoError(t, blob.Body.Close())
+		assert.Equal(t, "second", string(content))
+		assert.Equal(t, int64(6), blob.Size)
+
+		require.NoError(t, store.Delete(ctx, "covers/1/small"))
+		require.NoError(t, store.Delete(ctx, "covers/1/small"))
+
+		_, err = store.Get(ctx, "covers/1/small")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("keys cannot leave the root", func(t *testing.T) {
+		store, err := NewFileSystemStore(t.TempDir())
+		require.NoError(t, err)
+
+		for _, key := range []string{"", "../secret", "/etc/passwd", "covers/../../secret", "covers//small", "covers/./small"} {
+			err = store.Put(context.TODO(), key, strings.NewReader("x"), 1, "")
+			assert.ErrorIs(t, err, ErrInvalidBlobKey, key)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region  = "us-east-1"
+	defaultS3Timeout = 30 * time.Second
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	amzDateFormat    = "20060102T150405Z"
+	// maxErrorBytes bounds how much of an error response ends up in the returned cause
+	maxErrorBytes = 4 << 10
+)
+
+// S3Store talks to an S3 compatible object store (AWS S3, MinIO, Ceph RGW...) with path style requests
+// signed by AWS signature version 4. Payloads are sent unsigned so uploads stream without buffering.
+type S3Store struct {
+	client   *http.Client
+	endpoint *url.URL
+	bucket   string
+	signer   signer
+	now      func() time.Time
+}
+
+func NewS3Store(endpoint, region, bucket, accessKeyID, secretAccessKey string, timeout time.Duration) (*S3Store, error) {
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q must be an absolute URL", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+	if timeout <= 0 {
+		timeout = defaultS3Timeout
+	}
+
+	return &S3Store{
+		client:   &http.Client{Timeout: timeout},
+		endpoint: endpointURL,
+		bucket:   bucket,
+		signer:   signer{accessKeyID: accessKeyID, secretAccessKey: secretAccessKey, region: region, service: "s3"},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return s.failure(res)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (*Blob, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return &Blob{Body: res.Body, Size: res.ContentLength}, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, ErrBlobNotFound.WithMeta("key", key)
+	default:
+		defer res.Body.Close()
+		return nil, s.failure(res)
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s.failure(res)
+	}
+
+	return nil
+}
+
+func (s *S3Store) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+
+	target := *s.endpoint
+	target.RawPath = target.Path + "/" + uriEncode(s.bucket) + "/" + strings.Join(segments, "/")
+	target.Path = target.Path + "/" + s.bucket + "/" + key
+
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	s.signer.sign(req, unsignedPayload, s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, ErrStoreUnavailable.WithCause(err)
+	}
+
+	return res, nil
+}
+
+func (s *S3Store) failure(res *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBytes))
+	return ErrStoreUnavailable.WithCause(fmt.Errorf("s3 answered %s: %s", res.Status, strings.TrimSpace(string(message))))
+}
+
+// signer implements AWS signature version 4 for requests carried in headers, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+type signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+}
+
+// sign sets X-Amz-Date, X-Amz-Content-Sha256 and Authorization, every header already on the request is signed.
+func (s signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Del("Authorization")
+
+	signedHeaders := []string{"host"}
+	for name := range req.Header {
+		signedHeaders = append(signedHeaders, strings.ToLower(name))
+	}
+	sort.Strings(signedHeaders)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID,
+		s.scope(now),
+		strings.Join(signedHeaders, ";"),
+		s.signature(req, signedHeaders, payloadHash, now),
+	))
+}
+
+func (s signer) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+func (s signer) signature(req *http.Request, signedHeaders []string, payloadHash string, now time.Time) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	var canonicalRequest strings.Builder
+	canonicalRequest.WriteString(req.Method + "\n" + path + "\n" + canonicalQuery(req.URL.Query()) + "\n")
+	for _, name := range signedHeaders {
+		value := strings.Join(req.Header.Values(name), ",")
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		}
+		canonicalRequest.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest.WriteString("\n" + strings.Join(signedHeaders, ";") + "\n" + payloadHash)
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(amzDateFormat) + "\n" + s.scope(now) + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretAccessKey)
+	for _, part := range []string{now.Format("20060102"), s.region, s.service, "aws4_request", stringToSign} {
+		key = hmacSHA256(key, part)
+	}
+
+	return hex.EncodeToString(key)
+}
+
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent encodes everything but the RFC 3986 unreserved characters, which is what signature v4 expects.
+func uriEncode(value string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '_' || b == '.' || b == '~' {
+			encoded.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", b)
+	}
+
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"book-api/pkg/apperror"
+)
+
+var (
+	ErrBlobNotFound     = apperror.NotFound("BLOB_NOT_FOUND", "blob does not exist")
+	ErrInvalidBlobKey   = apperror.Invalid("INVALID_BLOB_KEY", "blob key must be a relative slash separated path")
+	ErrStoreUnavailable = apperror.Unavailable("BLOB_STORE_UNAVAILABLE", "blob store is unavailable")
+)
+
+// Blob is an object read from a BlobStore, the caller closes Body.
+type Blob struct {
+	Body io.ReadCloser
+	Size int64
+}
+
+// BlobStore keeps binary objects under slash separated keys like "covers/<bookId>/small". Put replaces an
+// existing object as a whole, readers never see a partial one. Get returns ErrBlobNotFound for a missing key,
+// Delete succeeds whether or not the key exists.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey rejects keys that could escape the store, "..", absolute and unclean paths.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrInvalidBlobKey.WithMeta("key", key)
+	}
+
+	return nil
+}
@@ -30,8 +30,11 @@ export interface IBookClient {
     params?: GetAllBooksRequest,
   ): Promise<{ books: GetAllBooksResponse; error?: HTTPError }>;
   getBookById(id: string): Promise<{ book: BookDTO; error?: HTTPError }>;
-  updateBook(book: CreateBookRequest): Promise<HTTPError | undefined>;
-  deleteBookById(id: string): Promise<HTTPError | undefined>;
+  updateBook(
+    book: CreateBookRequest,
+    version: number,
+  ): Promise<HTTPError | undefined>;
+  deleteBookById(id: string, version: number): Promise<HTTPError | undefined>;
 }
 
 // TODO: Possible refactoring with cache libraries (etc. SWC, TanstackQuery)
@@ -202,13 +205,27 @@ export default class BookClient implements IBookClient {
       });
   }
 
-  async updateBook(book: CreateBookRequest): Promise<HTTPError | undefined> {
+  /**
+   * The API rejects writes without the ETag of the version the caller read,
+   * so concurrent edits surface as 412 instead of overwriting each other
+   */
+  private ifMatch(version: number) {
+    return { "If-Match": `"${version}"` };
+  }
+
+  async updateBook(
+    book: CreateBookRequest,
+    version: number,
+  ): Promise<HTTPError | undefined> {
     return await trace
       .getTracer("book-web-app")
       .startActiveSpan("updateBook", async (span) => {
         try {
           span.setAttribute("bookId", book.id);
-          await this.bookApi.put(`book/${book.id}`, { json: book });
+          await this.bookApi.put(`book/${book.id}`, {
+            json: book,
+            headers: this.ifMatch(version),
+          });
 
           span.setStatus({
             code: SpanStatusCode.OK,
@@ -224,13 +241,18 @@ export default class BookClient implements IBookClient {
       });
   }
 
-  async deleteBookById(id: string): Promise<HTTPError | undefined> {
+  async deleteBookById(
+    id: string,
+    version: number,
+  ): Promise<HTTPError | undefined> {
     return await trace
       .getTracer("book-web-app")
       .startActiveSpan("deleteBookById", async (span) => {
         try {
           span.setAttribute("bookId", id);
-          await this.bookApi.delete(`book/${id}`);
+          await this.bookApi.delete(`book/${id}`, {
+            headers: this.ifMatch(version),
+          });
 
           span.setStatus({
             code: SpanStatusCode.OK,
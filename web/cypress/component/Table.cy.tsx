@@ -23,6 +23,7 @@ describe("Table.cy.tsx", () => {
         title: title(),
         author: author(),
         publicationYear: faker.date.past().getFullYear().toString(),
+        version: 1,
         createdAt: faker.date.past().toString(),
       });
     }
@@ -5,6 +5,7 @@ export type BookDTO = {
   author: string;
   publicationYear: string;
   isbn?: string;
+  version: number;
   createdAt: string;
   updatedAt?: string;
   deletedAt?: string;
@@ -252,13 +252,16 @@ describe.only("[pact]", () => {
         .given("A book with id and fields for update")
         .uponReceiving("A request to update a book")
         .withRequest("PUT", `/book/${bookExample.id}`, (builder) => {
-          builder.headers({ "Content-Type": "application/json" });
+          builder.headers({
+            "Content-Type": "application/json",
+            "If-Match": '"1"',
+          });
           builder.jsonBody(bookExample);
         })
         .willRespondWith(204)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.updateBook(bookExample);
+          const error = await bookClient.updateBook(bookExample, 1);
 
           expect(error).toBeUndefined();
         });
@@ -270,13 +273,16 @@ describe.only("[pact]", () => {
         .given("Not existed book with id and fields for update")
         .uponReceiving("Book not found for update")
         .withRequest("PUT", `/book/${bookExample.id}`, (builder) => {
-          builder.headers({ "Content-Type": "application/json" });
+          builder.headers({
+            "Content-Type": "application/json",
+            "If-Match": '"1"',
+          });
           builder.jsonBody(bookExample);
         })
         .willRespondWith(404)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.updateBook(bookExample);
+          const error = await bookClient.updateBook(bookExample, 1);
 
           expect(error).toBeDefined();
           expect(error).toBeInstanceOf(HTTPError);
@@ -290,13 +296,16 @@ describe.only("[pact]", () => {
         .given("A book with id and fields but while server in error state")
         .uponReceiving("Error occurred while updating a book")
         .withRequest("PUT", `/book/${bookExample.id}`, (builder) => {
-          builder.headers({ "Content-Type": "application/json" });
+          builder.headers({
+            "Content-Type": "application/json",
+            "If-Match": '"1"',
+          });
           builder.jsonBody(bookExample);
         })
         .willRespondWith(500)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.updateBook(bookExample);
+          const error = await bookClient.updateBook(bookExample, 1);
 
           expect(error).toBeDefined();
           expect(error).toBeInstanceOf(HTTPError);
@@ -313,11 +322,13 @@ describe.only("[pact]", () => {
         .addInteraction()
         .given("A book with id for delete")
         .uponReceiving("A request to delete a book")
-        .withRequest("DELETE", `/book/${bookId}`)
+        .withRequest("DELETE", `/book/${bookId}`, (builder) =>
+          builder.headers({ "If-Match": '"1"' }),
+        )
         .willRespondWith(204)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.deleteBookById(bookId);
+          const error = await bookClient.deleteBookById(bookId, 1);
 
           expect(error).toBeUndefined();
         });
@@ -328,11 +339,13 @@ describe.only("[pact]", () => {
         .addInteraction()
         .given("Not existed book with id for delete")
         .uponReceiving("Book not found for delete")
-        .withRequest("DELETE", `/book/${bookId}`)
+        .withRequest("DELETE", `/book/${bookId}`, (builder) =>
+          builder.headers({ "If-Match": '"1"' }),
+        )
         .willRespondWith(404)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.deleteBookById(bookId);
+          const error = await bookClient.deleteBookById(bookId, 1);
 
           expect(error).toBeDefined();
           expect(error).toBeInstanceOf(HTTPError);
@@ -345,11 +358,13 @@ describe.only("[pact]", () => {
         .addInteraction()
         .given("A book with id while server in error state for delete")
         .uponReceiving("Error occurred while deleting a book")
-        .withRequest("DELETE", `/book/${bookId}`)
+        .withRequest("DELETE", `/book/${bookId}`, (builder) =>
+          builder.headers({ "If-Match": '"1"' }),
+        )
         .willRespondWith(500)
         .executeTest(async (mockserver) => {
           const bookClient = new BookClient(mockserver.url);
-          const error = await bookClient.deleteBookById(bookId);
+          const error = await bookClient.deleteBookById(bookId, 1);
 
           expect(error).toBeDefined();
           expect(error).toBeInstanceOf(HTTPError);